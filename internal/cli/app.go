@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/config"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
@@ -10,11 +11,19 @@ import (
 
 // App represents the CLI application
 type App struct {
-	config      *config.Config
-	storage     storage.Storage
-	specService services.SpecService
-	linkService services.LinkService
-	llmService  services.LLMService
+	config           *config.Config
+	storage          storage.Storage
+	specService      services.SpecService
+	linkService      services.LinkService
+	gitService       services.GitService
+	reportService    services.ReportService
+	doctorService    services.DoctorService
+	migrationService services.MigrationService
+	importService    services.ImportService
+	statusService    services.StatusService
+	queryService     services.QueryService
+	searchService    services.SearchService
+	llmService       services.LLMService
 }
 
 // NewApp creates a new CLI application
@@ -24,7 +33,7 @@ func NewApp() (*App, error) {
 		return nil, err
 	}
 
-	store, err := storage.New(cfg.Storage.Path)
+	store, err := storage.Open(cfg.Storage.Backend, cfg.Storage.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -35,12 +44,24 @@ func NewApp() (*App, error) {
 		llmService = services.NewLLMService(cfg.LLM.AnthropicAPIKey)
 	}
 
+	gitService := services.NewGitService()
+	specService := services.NewSpecService(store)
+	linkService := services.NewLinkService(store, gitService)
+
 	return &App{
-		config:      cfg,
-		storage:     store,
-		specService: services.NewSpecService(store),
-		linkService: services.NewLinkService(store),
-		llmService:  llmService,
+		config:           cfg,
+		storage:          store,
+		specService:      specService,
+		linkService:      linkService,
+		gitService:       gitService,
+		reportService:    services.NewReportService(store, specService, gitService),
+		doctorService:    services.NewDoctorService(store, specService),
+		migrationService: services.NewMigrationService(store, specService),
+		importService:    services.NewImportService(store, specService),
+		statusService:    services.NewStatusService(store, specService),
+		queryService:     services.NewQueryService(store, specService),
+		searchService:    services.NewSearchService(store),
+		llmService:       llmService,
 	}, nil
 }
 
@@ -61,6 +82,9 @@ func (a *App) InitializeZamm() error {
 
 // Close closes the application and cleans up resources
 func (a *App) Close() error {
-	// File-based storage doesn't need to be closed
+	// File-based storage doesn't need to be closed, a database does
+	if closer, ok := a.storage.(io.Closer); ok {
+		return closer.Close()
+	}
 	return nil
 }
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// createCheckCommand creates the consistency check commands
+func (a *App) createCheckCommand(jsonOutput *bool) *cobra.Command {
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the specification store for problems",
+	}
+
+	// check cycles
+	cyclesCmd := &cobra.Command{
+		Use:   "cycles",
+		Short: "Find cycles in the specification hierarchy",
+		Long: `Look for chains of child-to-parent links in spec-links.csv that lead back to
+where they started. The hierarchy is meant to be acyclic, and commands that walk
+up from a node to the root never finish on a cycle. Exits with an error if any
+cycles are found, reporting one cycle for each group of nodes caught in a loop.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cycles, err := a.specService.FindCycles()
+			if err != nil {
+				return err
+			}
+
+			if *jsonOutput {
+				if err := a.outputJSON(cycles); err != nil {
+					return err
+				}
+			} else if len(cycles) == 0 {
+				fmt.Println("No cycles found")
+			} else {
+				for _, cycle := range cycles {
+					fmt.Println(cycle.String())
+				}
+			}
+
+			if len(cycles) > 0 {
+				return models.NewZammError(models.ErrTypeConflict, fmt.Sprintf("found %d cycle(s) in the spec hierarchy", len(cycles)))
+			}
+			return nil
+		},
+	}
+
+	checkCmd.AddCommand(cyclesCmd)
+	return checkCmd
+}
@@ -23,7 +23,7 @@ func TestMessageDumpingWithDebugWriter(t *testing.T) {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
 	specService := services.NewSpecService(fileStorage)
-	linkService := services.NewLinkService(fileStorage)
+	linkService := services.NewLinkService(fileStorage, services.NewGitService())
 
 	app := &App{
 		config:      cfg,
@@ -92,7 +92,7 @@ func TestMessageDumpingWithoutDebugWriter(t *testing.T) {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
 	specService := services.NewSpecService(fileStorage)
-	linkService := services.NewLinkService(fileStorage)
+	linkService := services.NewLinkService(fileStorage, services.NewGitService())
 
 	app := &App{
 		config:      cfg,
@@ -124,7 +124,7 @@ func TestDebugWriterFieldExists(t *testing.T) {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
 	specService := services.NewSpecService(fileStorage)
-	linkService := services.NewLinkService(fileStorage)
+	linkService := services.NewLinkService(fileStorage, services.NewGitService())
 
 	app := &App{
 		config:      cfg,
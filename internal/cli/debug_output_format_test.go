@@ -23,7 +23,7 @@ func TestSpewOutputFormat(t *testing.T) {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
 	specService := services.NewSpecService(fileStorage)
-	linkService := services.NewLinkService(fileStorage)
+	linkService := services.NewLinkService(fileStorage, services.NewGitService())
 
 	app := &App{
 		config:      cfg,
@@ -75,7 +75,7 @@ func TestComplexMessageTypeFormatting(t *testing.T) {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
 	specService := services.NewSpecService(fileStorage)
-	linkService := services.NewLinkService(fileStorage)
+	linkService := services.NewLinkService(fileStorage, services.NewGitService())
 
 	app := &App{
 		config:      cfg,
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// createDoctorCommand creates the store consistency checking command
+func (a *App) createDoctorCommand(jsonOutput *bool) *cobra.Command {
+	var fix bool
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the .zamm store for inconsistencies",
+		Long: `Check that node-files.csv, spec-links.csv, commit-links.csv, project_metadata.json
+and the markdown files under .zamm/nodes and docs/ agree with each other. Reports:
+
+- mappings in node-files.csv to files that don't exist or can't be read
+- markdown files with a frontmatter ID that node-files.csv doesn't know about
+- several markdown files with the same frontmatter ID
+- spec and commit links that refer to nodes that don't exist
+- a root ID that doesn't resolve to a node
+- cycles in the spec hierarchy
+- child links sections that no longer match a node's children
+
+With --fix, mappings are added, corrected or removed, dangling links are removed,
+a missing root is pointed at the only project node if there is exactly one, and
+out of date child links sections are regenerated. Duplicate IDs, unreadable files
+and cycles have to be fixed by hand. Exits with an error if any issues remain.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := a.doctorService.Diagnose(fix)
+			if err != nil {
+				return err
+			}
+
+			if *jsonOutput {
+				if err := a.outputJSON(report); err != nil {
+					return err
+				}
+			} else if err := a.outputDoctorReport(report, fix); err != nil {
+				return err
+			}
+
+			if remaining := report.Unresolved(); remaining > 0 {
+				return models.NewZammError(models.ErrTypeConflict, fmt.Sprintf("%d issue(s) remain in the .zamm store", remaining))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&fix, "fix", false, "Repair the issues that can be fixed automatically")
+
+	return cmd
+}
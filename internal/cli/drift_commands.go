@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+)
+
+// createDriftCommand creates the drift detection command
+func (a *App) createDriftCommand(jsonOutput *bool) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "List specifications edited after their last implementing commit",
+		Long: `Compare the git history of every specification's markdown file with the
+commits linked to it as implements, updates or fixes. A specification is stale
+when a commit changed its title or content without being an ancestor of any of
+those commits, or when its file has uncommitted edits. Moving a spec file or
+regenerating its child links does not count as a change.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := a.reportService.DriftReport()
+			if err != nil {
+				return err
+			}
+
+			if !all {
+				stale := make([]services.SpecDrift, 0, report.StaleSpecs)
+				for _, spec := range report.Specs {
+					if spec.Status == services.DriftStatusStale {
+						stale = append(stale, spec)
+					}
+				}
+				report.Specs = stale
+			}
+
+			if *jsonOutput {
+				return a.outputJSON(report)
+			}
+
+			return a.outputDriftTable(report, all)
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Show every specification, not only stale ones")
+
+	return cmd
+}
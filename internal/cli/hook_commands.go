@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+)
+
+// hookMarker identifies hook scripts written by zamm so that they can be safely overwritten
+const hookMarker = "# Installed by zamm hooks install"
+
+// hookScripts maps git hook names to the zamm subcommand each one runs
+var hookScripts = map[string]string{
+	"commit-msg":  `hooks commit-msg "$1"`,
+	"post-commit": "hooks post-commit",
+}
+
+// createHooksCommand creates the git hook management commands
+func (a *App) createHooksCommand(quiet *bool) *cobra.Command {
+	hooksCmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manage git hooks for automatic spec linking",
+		Long: `Install git hooks that keep spec-commit links up to date at commit time.
+
+The commit-msg hook rejects commits whose Implements, Updates, Fixes, Refactors,
+Documents or Tests trailers reference spec IDs or slugs that do not exist.
+The post-commit hook links the new commit to every spec its trailers reference.`,
+	}
+
+	// hooks install
+	var repoPath, binary string
+	var force bool
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install commit-msg and post-commit hooks into a repository",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repoPath == "" {
+				repoPath = a.config.Git.DefaultRepo
+			}
+
+			hooksDir, err := a.gitService.HooksDir(repoPath)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(hooksDir, 0755); err != nil {
+				return fmt.Errorf("failed to create hooks directory: %w", err)
+			}
+
+			for _, hookName := range []string{"commit-msg", "post-commit"} {
+				hookPath := filepath.Join(hooksDir, hookName)
+				if err := checkHookOverwritable(hookPath, force); err != nil {
+					return err
+				}
+
+				if err := os.WriteFile(hookPath, []byte(generateHookScript(binary, hookScripts[hookName])), 0755); err != nil {
+					return fmt.Errorf("failed to write %s hook: %w", hookName, err)
+				}
+
+				if !*quiet {
+					fmt.Printf("Installed %s hook: %s\n", hookName, hookPath)
+				}
+			}
+			return nil
+		},
+	}
+	installCmd.Flags().StringVar(&repoPath, "repo", "", "Repository path (default: current directory)")
+	installCmd.Flags().StringVar(&binary, "binary", "zamm", "zamm executable the hooks should invoke")
+	installCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing hooks that were not installed by zamm")
+
+	// hooks commit-msg (invoked by the installed hook)
+	commitMsgCmd := &cobra.Command{
+		Use:    "commit-msg <message-file>",
+		Short:  "Validate spec trailers in a commit message",
+		Args:   cobra.ExactArgs(1),
+		Hidden: true,
+		// Printed by git on every rejected commit, so keep the output to the error itself
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			message, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read commit message: %w", err)
+			}
+
+			unresolved := a.linkService.CheckTrailers(string(message))
+			if len(unresolved) == 0 {
+				return nil
+			}
+
+			details := make([]string, 0, len(unresolved))
+			for _, trailer := range unresolved {
+				details = append(details, fmt.Sprintf("%s: %s (%s)", trailer.Label, trailer.Reference, trailer.Reason))
+			}
+			zammErr := models.NewZammError(models.ErrTypeValidation, "commit message references unknown specs")
+			zammErr.Details = strings.Join(details, "; ")
+			return zammErr
+		},
+	}
+
+	// hooks post-commit (invoked by the installed hook)
+	postCommitCmd := &cobra.Command{
+		Use:          "post-commit",
+		Short:        "Link the latest commit to the specs named in its trailers",
+		Args:         cobra.NoArgs,
+		Hidden:       true,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := a.linkService.ScanCommits(a.config.Git.DefaultRepo, services.LogOptions{
+				RevRange: "HEAD",
+				MaxCount: 1,
+			})
+			if err != nil {
+				return err
+			}
+
+			if !*quiet {
+				for _, link := range result.Created {
+					fmt.Printf("zamm: linked %s to spec %s (%s)\n", link.CommitID[:12], link.SpecID, link.LinkLabel)
+				}
+			}
+			return nil
+		},
+	}
+
+	hooksCmd.AddCommand(installCmd, commitMsgCmd, postCommitCmd)
+	return hooksCmd
+}
+
+// generateHookScript renders a POSIX shell hook that runs a zamm subcommand,
+// skipping silently when zamm is not installed so that commits are never blocked by it
+func generateHookScript(binary, subcommand string) string {
+	binary = shellQuote(binary)
+	return fmt.Sprintf(`#!/bin/sh
+%s
+command -v %s >/dev/null 2>&1 || exit 0
+exec %s %s
+`, hookMarker, binary, binary, subcommand)
+}
+
+// shellQuote single-quotes a word for a POSIX shell, unless it is made up only of
+// characters the shell leaves alone
+func shellQuote(word string) string {
+	if word != "" && strings.Trim(word, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-+=.,:/@%") == "" {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// checkHookOverwritable refuses to replace hooks that zamm did not write unless forced
+func checkHookOverwritable(hookPath string, force bool) error {
+	existing, err := os.ReadFile(hookPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read existing hook %s: %w", hookPath, err)
+	}
+
+	if force || strings.Contains(string(existing), hookMarker) {
+		return nil
+	}
+
+	zammErr := models.NewZammError(models.ErrTypeConflict, fmt.Sprintf("hook already exists: %s", hookPath))
+	if strings.Contains(string(existing), "lefthook") {
+		zammErr.Details = "hooks are managed by lefthook; add `zamm hooks commit-msg {1}` and `zamm hooks post-commit` to lefthook.yml instead, or rerun with --force"
+	} else {
+		zammErr.Details = "rerun with --force to overwrite it"
+	}
+	return zammErr
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHookScript(t *testing.T) {
+	script := generateHookScript("zamm", `hooks commit-msg "$1"`)
+
+	if !strings.HasPrefix(script, "#!/bin/sh\n") {
+		t.Error("Hook script should start with a shebang")
+	}
+	if !strings.Contains(script, hookMarker) {
+		t.Error("Hook script should contain the zamm marker")
+	}
+	if !strings.Contains(script, `exec zamm hooks commit-msg "$1"`) {
+		t.Errorf("Hook script should run the zamm subcommand, got:\n%s", script)
+	}
+}
+
+func TestGenerateHookScriptQuotesBinary(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	// A binary path with a space and a quote in it, which records the arguments it gets
+	dir := filepath.Join(t.TempDir(), "My Tools", "it's")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	binary := filepath.Join(dir, "zamm")
+	argsFile := filepath.Join(t.TempDir(), "args")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho \"$@\" > \""+argsFile+"\"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake binary: %v", err)
+	}
+
+	hook := filepath.Join(t.TempDir(), "post-commit")
+	if err := os.WriteFile(hook, []byte(generateHookScript(binary, "hooks post-commit")), 0755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	if output, err := exec.Command("sh", hook).CombinedOutput(); err != nil {
+		t.Fatalf("Hook failed: %v\n%s", err, output)
+	}
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Expected the hook to run the binary: %v", err)
+	}
+	if strings.TrimSpace(string(args)) != "hooks post-commit" {
+		t.Errorf("Expected the binary to get the subcommand, got %q", args)
+	}
+}
+
+func TestCheckHookOverwritable(t *testing.T) {
+	hooksDir := t.TempDir()
+
+	missing := filepath.Join(hooksDir, "missing")
+	if err := checkHookOverwritable(missing, false); err != nil {
+		t.Errorf("Expected missing hook to be writable, got: %v", err)
+	}
+
+	managed := filepath.Join(hooksDir, "managed")
+	if err := os.WriteFile(managed, []byte(generateHookScript("zamm", "hooks post-commit")), 0755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	if err := checkHookOverwritable(managed, false); err != nil {
+		t.Errorf("Expected zamm hook to be overwritable, got: %v", err)
+	}
+
+	foreign := filepath.Join(hooksDir, "foreign")
+	if err := os.WriteFile(foreign, []byte("#!/bin/sh\nlefthook run commit-msg \"$@\"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	err := checkHookOverwritable(foreign, false)
+	if err == nil {
+		t.Fatal("Expected foreign hook not to be overwritten without --force")
+	}
+	if !strings.Contains(err.Error(), "lefthook") {
+		t.Errorf("Expected error to mention lefthook, got: %v", err)
+	}
+	if err := checkHookOverwritable(foreign, true); err != nil {
+		t.Errorf("Expected --force to allow overwriting, got: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+)
+
+// createImportCommand creates the command that turns existing markdown documentation into nodes
+func (a *App) createImportCommand(jsonOutput, quiet *bool) *cobra.Command {
+	var parentID string
+	var dryRun bool
+	importCmd := &cobra.Command{
+		Use:   "import <directory>",
+		Short: "Import a tree of plain markdown files as specs",
+		Long: `Turn every markdown file under <directory> into a spec. A directory's README.md becomes
+the parent of the other files and directories next to it; the files of a directory
+without one join the directory above. The top of the tree goes under --parent, or the
+root node by default.
+
+Titles come from each file's first # heading, or its name if it has none. Files stay
+where they are: zamm adds frontmatter to them, records them in node-files.csv and adds
+child links to every parent. Files that already have frontmatter are skipped, except
+that a README that is already a node takes in the new files next to it.
+
+Use --dry-run to preview the hierarchy first.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Documentation is often imported into a project that has no root node yet
+			if err := a.InitializeZamm(); err != nil {
+				return fmt.Errorf("failed to initialize zamm: %w", err)
+			}
+
+			var plan *services.ImportPlan
+			var err error
+			if dryRun {
+				plan, err = a.importService.PlanImport(args[0], parentID)
+			} else {
+				plan, err = a.importService.Import(args[0], parentID)
+			}
+			if err != nil {
+				return err
+			}
+
+			if *jsonOutput {
+				return a.outputJSON(plan)
+			}
+			if !*quiet {
+				a.outputImportPlan(plan, dryRun)
+			}
+			return nil
+		},
+	}
+	importCmd.Flags().StringVar(&parentID, "parent", "", "ID of the node to import under (defaults to the root node)")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the hierarchy that would be imported without changing anything")
+
+	return importCmd
+}
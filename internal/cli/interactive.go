@@ -11,6 +11,8 @@ import (
 	"github.com/spf13/cobra"
 	interactive "github.com/zamm-dev/zamm-golang-mvp-11/internal/cli/interactive"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/cli/interactive/nodes"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
 )
 
 // Model represents the state of our TUI application
@@ -52,6 +54,8 @@ func NewModel(app *App, debugWriter io.Writer) *Model {
 
 	combinedSvc := interactive.NewCombinedService(app.linkService, app.specService)
 	specListView := nodes.NewSpecExplorer(combinedSvc, app.specService)
+	specListView.SetQueryService(app.queryService)
+	specListView.SetSearchService(app.searchService)
 
 	stateManager := interactive.NewStateManager(specListView)
 	appAdapter := interactive.NewAppAdapter(app.specService, app.linkService, app.llmService, app.storage, app.config)
@@ -89,6 +93,12 @@ func (a *App) runInteractiveMode(debug bool) error {
 	model := NewModel(a, debugWriter)
 
 	p := tea.NewProgram(model, tea.WithAltScreen())
+	if fileStorage, ok := a.storage.(*storage.FileStorage); ok {
+		if watcher, err := storage.NewWatcher(fileStorage); err == nil {
+			defer func() { _ = watcher.Close() }()
+			go a.watchForExplorer(p, watcher, fileStorage)
+		}
+	}
 	_, err := p.Run()
 
 	// Ensure proper cleanup of debug file on program exit
@@ -102,6 +112,19 @@ func (a *App) runInteractiveMode(debug bool) error {
 	return err
 }
 
+// watchForExplorer folds hand edits of node files back into the store as zamm watch does,
+// and tells the explorer to reload once a burst of changes has been handled
+func (a *App) watchForExplorer(p *tea.Program, watcher *storage.Watcher, fileStorage *storage.FileStorage) {
+	watchService := services.NewWatchService(fileStorage, a.specService)
+	for change := range watcher.Changes() {
+		// Files caught part way through a save are picked up again on the next change
+		_, _ = watchService.HandleChange(change)
+		if len(watcher.Changes()) == 0 {
+			p.Send(interactive.NodeFilesChangedMsg{})
+		}
+	}
+}
+
 // Init is the first function that will be called
 func (m *Model) Init() tea.Cmd {
 	return tea.Batch(textinput.Blink, m.coordinator.LoadSpecsCmd())
@@ -123,6 +146,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.stateManager.HandleMessageDismissal(msg) {
 			return m, tea.Batch(m.coordinator.LoadSpecsCmd(), m.stateManager.RefreshSpecListView())
 		}
+	case interactive.NodeFilesChangedMsg:
+		// Reload even while a form has focus, so the explorer is current once it closes
+		return m, m.messageRouter.RouteMessage(msg)
 	}
 
 	// Always try to forward messages to StateManager components first
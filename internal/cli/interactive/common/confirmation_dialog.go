@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
 )
 
 type ConfirmationDialogConfig struct {
@@ -20,8 +21,19 @@ type ConfirmationAcceptedMsg struct {
 	Action    string
 	TargetID  string
 	ExtraData interface{}
+	Strategy  services.DeleteStrategy // How to delete a spec's children, for "delete_spec"
 }
 
+// DeletePreview describes what deleting a spec would change under each strategy that
+// applies to it. Reparent is only set when the spec has children.
+type DeletePreview struct {
+	Delete   *services.DeletionPlan
+	Reparent *services.DeletionPlan
+}
+
+// previewListLimit caps how many node titles the deletion preview lists
+const previewListLimit = 5
+
 type ConfirmationCancelledMsg struct{}
 
 type DeleteConfirmationDialog struct {
@@ -61,18 +73,34 @@ func (d DeleteConfirmationDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc", "n":
 			return d, func() tea.Msg { return ConfirmationCancelledMsg{} }
 		case "y":
-			return d, func() tea.Msg {
-				return ConfirmationAcceptedMsg{
-					Action:    d.config.ConfirmAction,
-					TargetID:  d.config.TargetID,
-					ExtraData: d.config.ExtraData,
-				}
+			if preview, ok := d.config.ExtraData.(DeletePreview); ok && preview.Reparent != nil {
+				return d, nil // A spec with children needs a strategy
+			}
+			return d, d.accept(services.DeleteRefuse)
+		case "c":
+			if preview, ok := d.config.ExtraData.(DeletePreview); ok && preview.Reparent != nil {
+				return d, d.accept(services.DeleteCascade)
+			}
+		case "r":
+			if preview, ok := d.config.ExtraData.(DeletePreview); ok && preview.Reparent != nil {
+				return d, d.accept(services.DeleteReparent)
 			}
 		}
 	}
 	return d, nil
 }
 
+func (d DeleteConfirmationDialog) accept(strategy services.DeleteStrategy) tea.Cmd {
+	return func() tea.Msg {
+		return ConfirmationAcceptedMsg{
+			Action:    d.config.ConfirmAction,
+			TargetID:  d.config.TargetID,
+			ExtraData: d.config.ExtraData,
+			Strategy:  strategy,
+		}
+	}
+}
+
 // View renders the confirmation dialog
 func (d DeleteConfirmationDialog) View() string {
 	var sb strings.Builder
@@ -83,6 +111,10 @@ func (d DeleteConfirmationDialog) View() string {
 	switch d.config.ConfirmAction {
 	case "delete_spec":
 		sb.WriteString(fmt.Sprintf("Are you sure you want to delete the specification '%s'?\n\n", d.config.TargetTitle))
+		if preview, ok := d.config.ExtraData.(DeletePreview); ok {
+			renderDeletePreview(&sb, preview)
+			return sb.String()
+		}
 	case "delete_link":
 		if linkData, ok := d.config.ExtraData.(LinkItem); ok {
 			sb.WriteString(fmt.Sprintf("Are you sure you want to delete the link to commit %s?\n\n", linkData.CommitID[:12]+"..."))
@@ -96,3 +128,38 @@ func (d DeleteConfirmationDialog) View() string {
 	sb.WriteString("Press 'y' to confirm, 'n' or Esc to cancel")
 	return sb.String()
 }
+
+func renderDeletePreview(sb *strings.Builder, preview DeletePreview) {
+	plan := preview.Delete
+	if preview.Reparent == nil {
+		fmt.Fprintf(sb, "This also removes %d hierarchy link(s) and %d commit link(s).\n\n",
+			len(plan.RemovedSpecLinks), len(plan.RemovedCommitLinks))
+		sb.WriteString("Press 'y' to confirm, 'n' or Esc to cancel")
+		return
+	}
+
+	fmt.Fprintf(sb, "It has %d child node(s). Choose what happens to them:\n\n", len(plan.Children))
+
+	fmt.Fprintf(sb, "  c  Cascade: delete %d node(s) and %d commit link(s)\n",
+		len(plan.DeletedNodes), len(plan.RemovedCommitLinks))
+	for i, node := range plan.DeletedNodes[1:] {
+		if i == previewListLimit {
+			fmt.Fprintf(sb, "       ... and %d more\n", len(plan.DeletedNodes)-1-previewListLimit)
+			break
+		}
+		fmt.Fprintf(sb, "       - %s\n", node.Title)
+	}
+
+	reparent := preview.Reparent
+	if len(reparent.UpdatedNodes) == 0 {
+		sb.WriteString("  r  Reparent: leave its children without a parent\n\n")
+	} else {
+		titles := make([]string, 0, len(reparent.UpdatedNodes))
+		for _, node := range reparent.UpdatedNodes {
+			titles = append(titles, "'"+node.Title+"'")
+		}
+		fmt.Fprintf(sb, "  r  Reparent: move its children under %s\n\n", strings.Join(titles, ", "))
+	}
+
+	sb.WriteString("Press 'c' or 'r' to delete, 'n' or Esc to cancel")
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+)
+
+func pressKey(d DeleteConfirmationDialog, key string) tea.Msg {
+	_, cmd := d.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	if cmd == nil {
+		return nil
+	}
+	return cmd()
+}
+
+func TestDeleteConfirmationDialogLeaf(t *testing.T) {
+	dialog := NewDeleteConfirmationDialog(ConfirmationDialogConfig{
+		ConfirmAction: "delete_spec",
+		TargetID:      "leaf",
+		TargetTitle:   "Leaf",
+		ExtraData: DeletePreview{Delete: &services.DeletionPlan{
+			DeletedNodes: []services.NodeSummary{{ID: "leaf", Title: "Leaf"}},
+		}},
+	})
+
+	if view := dialog.View(); !strings.Contains(view, "Press 'y' to confirm") {
+		t.Errorf("Expected a plain confirmation for a spec without children:\n%s", view)
+	}
+	if msg := pressKey(dialog, "c"); msg != nil {
+		t.Errorf("Expected 'c' to do nothing for a spec without children, got %v", msg)
+	}
+
+	accepted, ok := pressKey(dialog, "y").(ConfirmationAcceptedMsg)
+	if !ok || accepted.Strategy != services.DeleteRefuse || accepted.TargetID != "leaf" {
+		t.Errorf("Expected 'y' to delete the spec, got %+v", accepted)
+	}
+}
+
+func TestDeleteConfirmationDialogWithChildren(t *testing.T) {
+	children := []services.NodeSummary{{ID: "child", Title: "Child"}}
+	dialog := NewDeleteConfirmationDialog(ConfirmationDialogConfig{
+		ConfirmAction: "delete_spec",
+		TargetID:      "parent",
+		TargetTitle:   "Parent",
+		ExtraData: DeletePreview{
+			Delete: &services.DeletionPlan{
+				Children:     children,
+				DeletedNodes: []services.NodeSummary{{ID: "parent", Title: "Parent"}, {ID: "child", Title: "Child"}},
+			},
+			Reparent: &services.DeletionPlan{
+				Children:     children,
+				DeletedNodes: []services.NodeSummary{{ID: "parent", Title: "Parent"}},
+				UpdatedNodes: []services.NodeSummary{{ID: "root", Title: "Root"}},
+			},
+		},
+	})
+
+	view := dialog.View()
+	for _, expected := range []string{"Cascade: delete 2 node(s)", "- Child", "move its children under 'Root'"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected preview to contain %q:\n%s", expected, view)
+		}
+	}
+
+	if msg := pressKey(dialog, "y"); msg != nil {
+		t.Errorf("Expected 'y' to require a strategy for a spec with children, got %v", msg)
+	}
+	if accepted, ok := pressKey(dialog, "c").(ConfirmationAcceptedMsg); !ok || accepted.Strategy != services.DeleteCascade {
+		t.Errorf("Expected 'c' to cascade, got %+v", accepted)
+	}
+	if accepted, ok := pressKey(dialog, "r").(ConfirmationAcceptedMsg); !ok || accepted.Strategy != services.DeleteReparent {
+		t.Errorf("Expected 'r' to reparent, got %+v", accepted)
+	}
+}
@@ -641,16 +641,8 @@ func (l LinkEditor) removeParentSpecLink(targetSpecID string) tea.Cmd {
 // moveSpec moves a spec from one parent to another
 func (l LinkEditor) moveSpec(newParentID string) tea.Cmd {
 	return func() tea.Msg {
-		// First, remove from old parent
-		err := l.specService.RemoveChildFromParent(l.config.CurrentSpecID, l.moveOldParentID)
-		if err != nil {
-			return LinkEditorErrorMsg{Error: fmt.Sprintf("Error removing from old parent: %v", err)}
-		}
-
-		// Then, add to new parent with same link type (using "child" as default)
-		_, err = l.specService.AddChildToParent(l.config.CurrentSpecID, newParentID, "child")
-		if err != nil {
-			return LinkEditorErrorMsg{Error: fmt.Sprintf("Error adding to new parent: %v", err)}
+		if _, err := l.specService.MoveNode(l.config.CurrentSpecID, l.moveOldParentID, newParentID); err != nil {
+			return LinkEditorErrorMsg{Error: fmt.Sprintf("Error moving spec: %v", err)}
 		}
 
 		return LinkEditorCompleteMsg{}
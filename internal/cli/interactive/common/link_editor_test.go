@@ -37,7 +37,7 @@ func TestLinkEditorInitialRender(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	config := LinkEditorConfig{
@@ -61,7 +61,7 @@ func TestLinkEditorPressG(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	config := LinkEditorConfig{
@@ -88,7 +88,7 @@ func TestLinkEditorSpecSelectionMode(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	config := LinkEditorConfig{
@@ -118,7 +118,7 @@ func TestLinkEditorUnlinkGitMode(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	config := LinkEditorConfig{
@@ -148,7 +148,7 @@ func TestLinkEditorMoveSearchMode(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	config := LinkEditorConfig{
@@ -2,6 +2,7 @@ package common
 
 import (
 	"github.com/charmbracelet/lipgloss"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
 )
 
 var defaultStyle = lipgloss.NewStyle()
@@ -14,3 +15,23 @@ func HighlightStyle() lipgloss.Style {
 func ActiveNodeStyle() lipgloss.Style {
 	return HighlightStyle()
 }
+
+// ErrorStyle returns the style for error messages shown inline (red)
+func ErrorStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+}
+
+// statusColors gives each lifecycle status its own badge color
+var statusColors = map[models.Status]lipgloss.Color{
+	models.StatusDraft:       lipgloss.Color("8"),
+	models.StatusProposed:    lipgloss.Color("3"),
+	models.StatusApproved:    lipgloss.Color("6"),
+	models.StatusImplemented: lipgloss.Color("4"),
+	models.StatusVerified:    lipgloss.Color("2"),
+	models.StatusDeprecated:  lipgloss.Color("1"),
+}
+
+// StatusBadge renders a node's lifecycle status as a colored badge, such as [approved]
+func StatusBadge(status models.Status) string {
+	return lipgloss.NewStyle().Foreground(statusColors[status]).Render("[" + string(status) + "]")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/cli/interactive/common"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
 )
@@ -93,9 +94,29 @@ func (c *Coordinator) UpdateNodeCmd(nodeID, title, content, nodeType string, imp
 	}
 }
 
-func (c *Coordinator) DeleteSpecCmd(specID string) tea.Cmd {
+// PreviewDeletion plans the deletion of a spec so the confirmation dialog can show what
+// would change. Specs with children are planned with both the cascade and reparent strategies.
+func (c *Coordinator) PreviewDeletion(specID string) (common.DeletePreview, error) {
+	specService := c.app.SpecService()
+
+	plan, err := specService.PlanDeletion(specID, services.DeleteCascade)
+	if err != nil {
+		return common.DeletePreview{}, err
+	}
+	if len(plan.Children) == 0 {
+		return common.DeletePreview{Delete: plan}, nil
+	}
+
+	reparent, err := specService.PlanDeletion(specID, services.DeleteReparent)
+	if err != nil {
+		return common.DeletePreview{}, err
+	}
+	return common.DeletePreview{Delete: plan, Reparent: reparent}, nil
+}
+
+func (c *Coordinator) DeleteSpecCmd(specID string, strategy services.DeleteStrategy) tea.Cmd {
 	return func() tea.Msg {
-		if err := c.app.SpecService().DeleteSpec(specID); err != nil {
+		if _, err := c.app.SpecService().DeleteNode(specID, strategy); err != nil {
 			return OperationCompleteMsg{message: fmt.Sprintf("Error: %v. Press Enter to continue...", err)}
 		}
 		return ReturnToSpecListMsg{}
@@ -120,6 +141,15 @@ func (c *Coordinator) OrganizeNodeCmd(nodeID string) tea.Cmd {
 	}
 }
 
+func (c *Coordinator) ReorderChildCmd(parentID, childID string, offset int) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := c.app.SpecService().MoveChild(parentID, childID, offset); err != nil {
+			return OperationCompleteMsg{message: fmt.Sprintf("Error moving child: %v. Press Enter to continue...", err)}
+		}
+		return ChildReorderedMsg{}
+	}
+}
+
 func (c *Coordinator) SetSlugAndOrganizeCmd(nodeID, slug string) tea.Cmd {
 	return func() tea.Msg {
 		node, err := c.app.SpecService().ReadNode(nodeID)
@@ -37,6 +37,11 @@ func (r *MessageRouter) RouteMessage(msg tea.Msg) tea.Cmd {
 		return r.handleNavigateToNode(msg)
 	case SetCurrentNodeMsg:
 		return r.handleSetCurrentNode(msg)
+	case NodeFilesChangedMsg:
+		return r.handleNodeFilesChanged()
+	case ChildReorderedMsg:
+		// Reloading keeps the moved child selected
+		return r.handleNodeFilesChanged()
 
 	case nodes.CreateNewSpecMsg:
 		return r.handleCreateNewSpec(msg)
@@ -56,6 +61,8 @@ func (r *MessageRouter) RouteMessage(msg tea.Msg) tea.Cmd {
 		return r.handleEditSlug(msg)
 	case nodes.OrganizeSpecMsg:
 		return r.handleOrganizeSpec(msg)
+	case nodes.ReorderChildMsg:
+		return r.coordinator.ReorderChildCmd(msg.ParentSpecID, msg.SpecID, msg.Offset)
 	case nodes.ExitMsg:
 		return tea.Quit
 
@@ -154,6 +161,10 @@ func (r *MessageRouter) handleSetCurrentNode(msg SetCurrentNodeMsg) tea.Cmd {
 	return nil
 }
 
+func (r *MessageRouter) handleNodeFilesChanged() tea.Cmd {
+	return tea.Batch(r.coordinator.LoadSpecsCmd(), r.stateManager.ReloadSpecListView())
+}
+
 func (r *MessageRouter) handleCreateNewSpec(msg nodes.CreateNewSpecMsg) tea.Cmd {
 	r.stateManager.ResetInputs()
 	r.stateManager.SetParentSpecID(msg.ParentSpecID)
@@ -187,11 +198,11 @@ func (r *MessageRouter) handleEditSpec(msg nodes.EditSpecMsg) tea.Cmd {
 }
 
 func (r *MessageRouter) handleOpenMarkdown(msg nodes.OpenMarkdownMsg) tea.Cmd {
-	// Cast storage to FileStorage to access GetNodeFilePath
-	fileStorage, ok := r.coordinator.app.Storage().(*StorageAdapter).storage.(*storage.FileStorage)
+	// Only backends that store nodes as files have a markdown file to open
+	fileStorage, ok := r.coordinator.app.Storage().(*StorageAdapter).storage.(storage.NodeFileStorage)
 	if !ok {
 		return func() tea.Msg {
-			return OperationCompleteMsg{message: "Error: Cannot access file storage. Press Enter to continue..."}
+			return OperationCompleteMsg{message: "Error: Specs are not stored as markdown files. Press Enter to continue..."}
 		}
 	}
 
@@ -244,12 +255,19 @@ func (r *MessageRouter) handleDeleteSpec(msg nodes.DeleteSpecMsg) tea.Cmd {
 		}
 	}
 
+	preview, err := r.coordinator.PreviewDeletion(msg.SpecID)
+	if err != nil {
+		r.stateManager.ShowMessage(fmt.Sprintf("Error: %v. Press Enter to continue...", err))
+		return nil
+	}
+
 	config := common.ConfirmationDialogConfig{
 		Title:         "Confirm Deletion",
 		Message:       fmt.Sprintf("Are you sure you want to delete the specification '%s'?", specTitle),
 		ConfirmAction: "delete_spec",
 		TargetID:      msg.SpecID,
 		TargetTitle:   specTitle,
+		ExtraData:     preview,
 	}
 	r.stateManager.SetConfirmationDialog(common.NewDeleteConfirmationDialog(config))
 	r.stateManager.SetState(ConfirmDelete)
@@ -455,7 +473,7 @@ func (r *MessageRouter) handleLinkSelectorCancel() tea.Cmd {
 func (r *MessageRouter) handleConfirmationAccepted(msg common.ConfirmationAcceptedMsg) tea.Cmd {
 	switch msg.Action {
 	case "delete_spec":
-		return r.coordinator.DeleteSpecCmd(msg.TargetID)
+		return r.coordinator.DeleteSpecCmd(msg.TargetID, msg.Strategy)
 	case "delete_link":
 		if linkData, ok := msg.ExtraData.(common.LinkItem); ok {
 			return r.coordinator.DeleteLinkCmd(msg.TargetID, linkData.CommitID, linkData.RepoPath)
@@ -28,6 +28,12 @@ type SetCurrentNodeMsg struct {
 	node models.Node
 }
 
+// NodeFilesChangedMsg is sent when node files were changed on disk outside the explorer
+type NodeFilesChangedMsg struct{}
+
+// ChildReorderedMsg is sent when a child was moved among its parent's children
+type ChildReorderedMsg struct{}
+
 type LinkItem struct {
 	ID        string
 	CommitID  string
@@ -1,6 +1,7 @@
 package nodes
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -18,6 +19,8 @@ type NodeDetail struct {
 	node          models.Node
 	links         []*models.SpecCommitLink
 	childGrouping models.ChildGroup
+	childFilter   func(models.Node) bool
+	hiddenCount   int
 	cursor        int
 	table         table.Model
 	width         int
@@ -82,15 +85,27 @@ func (d *NodeDetail) SetSpec(node models.Node) {
 		d.links = links
 	}
 
-	d.childGrouping, err = GetOrganizedChildren(d.specService, node)
+	d.childGrouping, err = d.specService.GetOrganizedChildren(node)
 	if err != nil {
 		d.childGrouping = models.ChildGroup{}
 	}
+	d.hiddenCount = 0
+	if d.childFilter != nil {
+		d.hiddenCount = len(d.childGrouping.Remove(func(child models.Node) bool {
+			return !d.childFilter(child)
+		}))
+	}
 
 	d.updateCommitsTable()
 	d.cursor = -1
 }
 
+// SetChildFilter hides the children that don't pass the filter, from the next SetSpec on.
+// A nil filter shows every child.
+func (d *NodeDetail) SetChildFilter(filter func(models.Node) bool) {
+	d.childFilter = filter
+}
+
 func (d *NodeDetail) GetSelectedChild() models.Node {
 	return d.childGrouping.NodeAt(d.cursor)
 }
@@ -111,6 +126,18 @@ func (d *NodeDetail) ResetCursor() {
 	d.cursor = -1
 }
 
+// SelectChild moves the cursor to the child with the given ID, reporting whether the node
+// still has such a child
+func (d *NodeDetail) SelectChild(id string) bool {
+	for i := 0; i < d.childGrouping.Size(); i++ {
+		if child := d.childGrouping.NodeAt(i); child != nil && child.ID() == id {
+			d.cursor = i
+			return true
+		}
+	}
+	return false
+}
+
 func (d *NodeDetail) updateCommitsTable() {
 	if d.links == nil {
 		d.table.SetRows([]table.Row{})
@@ -159,7 +186,17 @@ func (d *NodeDetail) View() string {
 	}
 
 	var contentBuilder strings.Builder
-	contentBuilder.WriteString(fmt.Sprintf("%s\n%s\n\n%s\n\n", d.node.Title(), strings.Repeat("=", d.width), d.node.Content()))
+	contentBuilder.WriteString(fmt.Sprintf("%s %s\n%s\n\n", d.node.Title(), common.StatusBadge(d.node.Status()), strings.Repeat("=", d.width)))
+	if tags := d.node.Tags(); len(tags) > 0 {
+		contentBuilder.WriteString(fmt.Sprintf("Tags: %s\n\n", strings.Join(tags, ", ")))
+	}
+	if metadata := d.node.Metadata(); len(metadata) > 0 {
+		for _, field := range metadata {
+			contentBuilder.WriteString(fmt.Sprintf("%s: %s\n", field.Key, formatMetadataValue(field.Value)))
+		}
+		contentBuilder.WriteString("\n")
+	}
+	contentBuilder.WriteString(fmt.Sprintf("%s\n\n", d.node.Content()))
 	if len(d.links) == 0 {
 		contentBuilder.WriteString("[No linked commits found]")
 	} else {
@@ -169,7 +206,9 @@ func (d *NodeDetail) View() string {
 	contentBuilder.WriteString("\n\n")
 
 	// Display regular children section
-	if d.childGrouping.IsEmpty() {
+	if d.childGrouping.IsEmpty() && d.hiddenCount > 0 {
+		contentBuilder.WriteString(fmt.Sprintf("[No children match the filter, %d hidden]", d.hiddenCount))
+	} else if d.childGrouping.IsEmpty() {
 		contentBuilder.WriteString("[No children]")
 	} else {
 		renderer := &cliChildrenRenderer{
@@ -178,6 +217,9 @@ func (d *NodeDetail) View() string {
 			cursor: d.cursor,
 		}
 		d.childGrouping.Render(renderer)
+		if d.hiddenCount > 0 {
+			contentBuilder.WriteString(fmt.Sprintf("[%d more hidden by the filter]", d.hiddenCount))
+		}
 	}
 
 	// Use lipgloss to constrain the entire output to the component width
@@ -185,6 +227,18 @@ func (d *NodeDetail) View() string {
 	return style.Render(contentBuilder.String())
 }
 
+// formatMetadataValue shows lists and nested fields as JSON, on the same line as their key
+func formatMetadataValue(value any) string {
+	switch value.(type) {
+	case models.Metadata, []any:
+		data, err := json.Marshal(value)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
 type cliChildrenRenderer struct {
 	sb     *strings.Builder
 	width  int
@@ -202,13 +256,15 @@ func (r *cliChildrenRenderer) RenderGroupEnd(nestingLevel int) {
 
 func (r *cliChildrenRenderer) RenderNode(nestingLevel int, node models.Node) {
 	nodeTitle := node.Title()
+	badge := fmt.Sprintf(" [%s]", node.Status())
 	// account for prepended `> ` taking up the first level of indentation
 	indentStr := strings.Repeat(" ", (nestingLevel-1)*2)
 	// -1 for ellipsis, -1 for buffer, -2 for "> "
-	maxTitleWidth := r.width - len(indentStr) - 4
+	maxTitleWidth := r.width - len(indentStr) - len(badge) - 4
 	if len(nodeTitle) > maxTitleWidth && maxTitleWidth > 0 {
 		nodeTitle = nodeTitle[:maxTitleWidth] + "…"
 	}
+	nodeTitle += badge
 	if r.index == r.cursor {
 		// newline must come after formatting, or else the next line will somehow be off by the length
 		// of the entire string
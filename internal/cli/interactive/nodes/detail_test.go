@@ -2,6 +2,7 @@ package nodes
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/charmbracelet/x/exp/teatest"
@@ -51,7 +52,7 @@ func TestNodeDetailProjectRender(t *testing.T) {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
 	specService := services.NewSpecService(storage)
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 
 	combinedSvc := &testCombinedService{
 		linkService: linkService,
@@ -87,7 +88,7 @@ func TestNodeDetailSpecificationRender(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	combinedSvc := &testCombinedService{
@@ -116,3 +117,31 @@ func TestNodeDetailSpecificationRender(t *testing.T) {
 	// Wait for initial render and capture golden output (should NOT contain Implementations section)
 	waitForGoldenOutput(t, tm, []byte("No children"), "TestNodeDetailSpecificationRender.golden")
 }
+
+func TestNodeDetailShowsMetadata(t *testing.T) {
+	testDataPath := filepath.Join("..", "common", "testdata", ".zamm")
+	storage, err := storage.New(testDataPath)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	linkService := services.NewLinkService(storage, services.NewGitService())
+	specService := services.NewSpecService(storage)
+
+	spec, err := specService.ReadNode("201c7092-9367-4a97-837b-98fbbcd7168a")
+	if err != nil {
+		t.Fatalf("Failed to get test spec: %v", err)
+	}
+	spec.SetMetadata(models.Metadata{
+		{Key: "owner", Value: "alice"},
+		{Key: "tags", Value: []any{"api", "cli"}},
+	})
+
+	detail := NewNodeDetail(&testCombinedService{linkService: linkService, specService: specService}, specService)
+	detail.SetSize(80, 24)
+	detail.SetSpec(spec)
+
+	view := detail.View()
+	if !strings.Contains(view, "owner: alice") || !strings.Contains(view, `tags: ["api","cli"]`) {
+		t.Errorf("Expected the metadata fields in the detail view, got:\n%s", view)
+	}
+}
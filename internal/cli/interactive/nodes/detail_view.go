@@ -50,6 +50,11 @@ func (v *NodeDetailView) SetSpec(node models.Node) {
 	v.viewport.SetYOffset(0)
 }
 
+// SetChildFilter hides the children that don't pass the filter, from the next SetSpec on
+func (v *NodeDetailView) SetChildFilter(filter func(models.Node) bool) {
+	v.detail.SetChildFilter(filter)
+}
+
 func (v *NodeDetailView) GetSelectedChild() models.Node {
 	return v.detail.GetSelectedChild()
 }
@@ -69,6 +74,18 @@ func (v *NodeDetailView) ResetCursor() {
 	v.viewport.SetContent(v.detail.View())
 }
 
+func (v *NodeDetailView) SelectChild(id string) bool {
+	found := v.detail.SelectChild(id)
+	v.viewport.SetContent(v.detail.View())
+	return found
+}
+
+// ReloadSpec shows a fresh copy of the node on display, keeping the scroll position
+func (v *NodeDetailView) ReloadSpec(node models.Node) {
+	v.detail.SetSpec(node)
+	v.viewport.SetContent(v.detail.View())
+}
+
 func (v *NodeDetailView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	v.viewport, _ = v.viewport.Update(msg)
 	return v, nil
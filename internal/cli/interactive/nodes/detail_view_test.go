@@ -71,7 +71,7 @@ func TestNodeDetailViewInitialRender(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	combinedSvc := &testViewCombinedService{
@@ -103,7 +103,7 @@ func TestNodeDetailViewScrolling(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	combinedSvc := &testViewCombinedService{
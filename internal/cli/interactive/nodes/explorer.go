@@ -7,8 +7,10 @@ import (
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	overlay "github.com/rmhubbert/bubbletea-overlay"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/cli/interactive/common"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
@@ -17,6 +19,8 @@ import (
 type keyMap struct {
 	Up           key.Binding
 	Down         key.Binding
+	MoveUp       key.Binding
+	MoveDown     key.Binding
 	Select       key.Binding
 	Create       key.Binding
 	Edit         key.Binding
@@ -26,6 +30,8 @@ type keyMap struct {
 	Remove       key.Binding
 	Move         key.Binding
 	Organize     key.Binding
+	Filter       key.Binding
+	Search       key.Binding
 	Help         key.Binding
 	Back         key.Binding
 	Quit         key.Binding
@@ -40,6 +46,14 @@ var keys = keyMap{
 		key.WithKeys("down", "j"),
 		key.WithHelp("↓", "prev"),
 	),
+	MoveUp: key.NewBinding(
+		key.WithKeys("shift+up", "K"),
+		key.WithHelp("K", "move child up"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("shift+down", "J"),
+		key.WithHelp("J", "move child down"),
+	),
 	Select: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("↵", "select"),
@@ -80,6 +94,14 @@ var keys = keyMap{
 		key.WithKeys("o", "O"),
 		key.WithHelp("o", "organize"),
 	),
+	Filter: key.NewBinding(
+		key.WithKeys("f", "F"),
+		key.WithHelp("f", "filter"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "Q"),
 		key.WithHelp("q", "quit"),
@@ -96,11 +118,12 @@ func (k keyMap) ShortHelp() []key.Binding {
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down},
+		{k.Up, k.Down, k.MoveUp, k.MoveDown},
 		{k.Select, k.Back},
 		{k.Create, k.Edit, k.OpenMarkdown, k.Delete},
 		{k.Link, k.Remove, k.Move},
-		{k.Organize, k.Help, k.Quit},
+		{k.Search, k.Filter, k.Organize},
+		{k.Help, k.Quit},
 	}
 }
 
@@ -112,8 +135,18 @@ type NodeExplorer struct {
 	currentSpec models.Node
 	activeSpec  models.Node
 
-	linkService LinkService
-	specService services.SpecService
+	linkService  LinkService
+	specService  services.SpecService
+	queryService services.QueryService
+
+	// The filter bar hides children that don't match a query, see services.ParseQuery
+	filterInput textinput.Model
+	filtering   bool
+	filterQuery string
+	filterError string
+
+	search    *NodeSearch
+	searching bool
 
 	width  int
 	height int
@@ -133,6 +166,7 @@ func NewSpecExplorer(linkService LinkService, specService services.SpecService)
 		rightPane:   NewNodeDetailView(linkService, specService),
 		linkService: linkService,
 		specService: specService,
+		filterInput: newFilterInput(),
 		keys:        keys,
 		help:        help.New(),
 		showHelp:    false,
@@ -153,6 +187,28 @@ func NewSpecExplorer(linkService LinkService, specService services.SpecService)
 	return explorer
 }
 
+func newFilterInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "type:specification tag:security -has:children"
+	return input
+}
+
+// SetQueryService turns on the filter bar, which uses the query service to match nodes
+func (e *NodeExplorer) SetQueryService(queryService services.QueryService) {
+	e.queryService = queryService
+}
+
+// SetSearchService turns on the search overlay, which finds nodes by the words in them
+func (e *NodeExplorer) SetSearchService(searchService services.SearchService) {
+	e.search = NewNodeSearch(searchService)
+	e.search.SetWidth(e.searchWidth())
+}
+
+func (e *NodeExplorer) searchWidth() int {
+	return min(max(e.width-4, 20), 100)
+}
+
 func (e *NodeExplorer) SetSize(width, height int) {
 	e.width = width
 	e.height = height
@@ -160,6 +216,9 @@ func (e *NodeExplorer) SetSize(width, height int) {
 	e.leftPane.SetSize(paneWidth, height)
 	e.rightPane.SetSize(paneWidth, height)
 	e.help.Width = paneWidth
+	if e.search != nil {
+		e.search.SetWidth(e.searchWidth())
+	}
 }
 
 func (e *NodeExplorer) paneWidth() int {
@@ -183,9 +242,25 @@ func (e *NodeExplorer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		panic("activeSpec is nil in SpecExplorer.Update")
 	}
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && e.searching {
+		return e, e.updateSearch(keyMsg)
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && e.filtering {
+		return e, e.updateFilterInput(keyMsg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
+		case key.Matches(msg, e.keys.Search) && e.search != nil:
+			e.searching = true
+			return e, e.search.Open()
+		case key.Matches(msg, e.keys.Filter) && e.queryService != nil:
+			e.filtering = true
+			e.filterError = ""
+			e.filterInput.SetValue(e.filterQuery)
+			e.filterInput.CursorEnd()
+			return e, e.filterInput.Focus()
 		case key.Matches(msg, e.keys.Help):
 			e.showHelp = !e.showHelp
 			return e, nil
@@ -206,6 +281,16 @@ func (e *NodeExplorer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Update right pane with new active spec details (don't affect left pane cursor)
 			e.updateRightPaneOnly()
 			return e, nil
+		case key.Matches(msg, e.keys.MoveUp) || key.Matches(msg, e.keys.MoveDown):
+			selectedChild := e.leftPane.GetSelectedChild()
+			if selectedChild == nil {
+				return e, nil
+			}
+			parentID, childID, offset := e.currentSpec.ID(), selectedChild.ID(), 1
+			if key.Matches(msg, e.keys.MoveUp) {
+				offset = -1
+			}
+			return e, func() tea.Msg { return ReorderChildMsg{ParentSpecID: parentID, SpecID: childID, Offset: offset} }
 		case key.Matches(msg, e.keys.Select):
 			// Navigate to the active spec if it's different from current
 			if e.activeSpec.ID() != e.currentSpec.ID() {
@@ -307,6 +392,129 @@ func (e *NodeExplorer) navigateBack() tea.Cmd {
 	return e.setCurrentNode(parentSpec)
 }
 
+// updateSearch handles a key while the search overlay is open. Enter goes to the chosen
+// result and Esc closes the overlay.
+func (e *NodeExplorer) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		result := e.search.Selected()
+		if result == nil {
+			return nil
+		}
+		e.searching = false
+		e.search.Close()
+		return e.jumpTo(result.ID)
+	case tea.KeyEsc:
+		e.searching = false
+		e.search.Close()
+		return nil
+	}
+
+	_, cmd := e.search.Update(msg)
+	return cmd
+}
+
+// jumpTo shows a node as the selected child of its first parent, or on its own if it has
+// no parent or the filter hides it there
+func (e *NodeExplorer) jumpTo(id string) tea.Cmd {
+	node, err := e.linkService.GetNodeByID(id)
+	if err != nil || node == nil {
+		return nil
+	}
+	parent, err := e.linkService.GetParentNode(id)
+	if err != nil || parent == nil {
+		return e.setCurrentNode(node)
+	}
+
+	cmd := e.setCurrentNode(parent)
+	if !e.leftPane.SelectChild(id) {
+		return e.setCurrentNode(node)
+	}
+	e.activeSpec = e.leftPane.GetSelectedChild()
+	e.updateRightPaneOnly()
+	return cmd
+}
+
+// updateFilterInput handles a key while the filter bar has focus. Enter applies the query,
+// or clears the filter if it is empty, and Esc closes the bar without changing the filter.
+func (e *NodeExplorer) updateFilterInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if err := e.applyFilter(e.filterInput.Value()); err != nil {
+			e.filterError = err.Error()
+			return nil
+		}
+		e.filtering = false
+		e.filterInput.Blur()
+		return e.setCurrentNode(e.currentSpec)
+	case tea.KeyEsc:
+		e.filtering = false
+		e.filterError = ""
+		e.filterInput.Blur()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	e.filterInput, cmd = e.filterInput.Update(msg)
+	return cmd
+}
+
+// applyFilter runs a query and hides the children in both panes that don't match it
+func (e *NodeExplorer) applyFilter(query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		e.filterQuery = ""
+		e.leftPane.SetChildFilter(nil)
+		e.rightPane.SetChildFilter(nil)
+		return nil
+	}
+
+	nodes, err := e.queryService.Query(query)
+	if err != nil {
+		return err
+	}
+	matches := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		matches[node.ID()] = true
+	}
+	filter := func(node models.Node) bool {
+		return matches[node.ID()]
+	}
+
+	e.filterQuery = query
+	e.filterError = ""
+	e.leftPane.SetChildFilter(filter)
+	e.rightPane.SetChildFilter(filter)
+	return nil
+}
+
+// Reload re-reads the nodes on display after their files changed on disk, keeping the
+// same child selected if it is still there
+func (e *NodeExplorer) Reload() tea.Cmd {
+	current, err := e.linkService.GetNodeByID(e.currentSpec.ID())
+	if err != nil || current == nil {
+		// The node on display is gone, so start again from the root
+		return e.setCurrentNode(nil)
+	}
+
+	// The nodes that match the filter may have changed along with the files
+	if e.filterQuery != "" {
+		if err := e.applyFilter(e.filterQuery); err != nil {
+			e.filterError = err.Error()
+		}
+	}
+
+	activeID := e.activeSpec.ID()
+	e.currentSpec = current
+	e.activeSpec = current
+	e.leftPane.ReloadSpec(current)
+	if activeID != current.ID() && e.leftPane.SelectChild(activeID) {
+		e.activeSpec = e.leftPane.GetSelectedChild()
+	}
+	e.rightPane.ReloadSpec(e.activeSpec)
+	return nil
+}
+
 func (e *NodeExplorer) updateDetailsForSpec() {
 	if e.linkService == nil {
 		return
@@ -330,6 +538,15 @@ func (e *NodeExplorer) updateRightPaneOnly() {
 }
 
 func (e *NodeExplorer) View() string {
+	panes := e.panesView()
+	if !e.searching {
+		return panes
+	}
+	return overlay.New(e.search, staticView(panes), overlay.Center, overlay.Top, 0, 2).View()
+}
+
+// panesView shows the current node on the left and the active node on the right
+func (e *NodeExplorer) panesView() string {
 	// Assert that specs are never nil
 	if e.currentSpec == nil {
 		panic("currentSpec is nil in SpecExplorer.View")
@@ -339,6 +556,9 @@ func (e *NodeExplorer) View() string {
 	}
 
 	left := e.leftPane.View()
+	if bar := e.filterBarView(); bar != "" {
+		left = lipgloss.JoinVertical(lipgloss.Top, left, bar)
+	}
 	if e.showHelp {
 		left = lipgloss.JoinVertical(lipgloss.Top, left, e.help.View(e.keys))
 	}
@@ -376,6 +596,21 @@ func (e *NodeExplorer) View() string {
 	return lipgloss.JoinHorizontal(lipgloss.Left, left, border, right)
 }
 
+// filterBarView shows the query being typed, or the filter in effect
+func (e *NodeExplorer) filterBarView() string {
+	var bar string
+	switch {
+	case e.filtering:
+		bar = e.filterInput.View()
+	case e.filterQuery != "":
+		bar = fmt.Sprintf("Filter: %s (/ to change)", e.filterQuery)
+	}
+	if e.filterError != "" {
+		bar = lipgloss.JoinVertical(lipgloss.Top, bar, common.ErrorStyle().Render(e.filterError))
+	}
+	return bar
+}
+
 // generateAutoSlug creates a slug from the given title using the same logic as the spec service
 func (e *NodeExplorer) generateAutoSlug(title string) string {
 	slug := strings.ToLower(title)
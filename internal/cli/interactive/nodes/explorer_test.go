@@ -3,9 +3,11 @@ package nodes
 import (
 	"bytes"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/exp/teatest"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
@@ -70,7 +72,7 @@ func TestNodeExplorerInitialRender(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create file storage: %v", err)
 	}
-	linkService := services.NewLinkService(storage)
+	linkService := services.NewLinkService(storage, services.NewGitService())
 	specService := services.NewSpecService(storage)
 
 	combinedSvc := &testExplorerCombinedService{
@@ -87,3 +89,238 @@ func TestNodeExplorerInitialRender(t *testing.T) {
 	// Wait for initial render and capture golden output
 	waitForExplorerGoldenOutput(t, tm, []byte("Select a child specification"), "TestNodeExplorerInitialRender.golden")
 }
+
+func TestNodeExplorerReloadKeepsSelection(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	linkService := services.NewLinkService(store, services.NewGitService())
+	specService := services.NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	for _, title := range []string{"First", "Second"} {
+		child, err := specService.CreateSpec(title, title+" content")
+		if err != nil {
+			t.Fatalf("Failed to create spec: %v", err)
+		}
+		if _, err := specService.AddChildToParent(child.ID(), root.ID(), "child"); err != nil {
+			t.Fatalf("Failed to link child: %v", err)
+		}
+	}
+
+	explorer := NewSpecExplorer(&testExplorerCombinedService{linkService: linkService, specService: specService}, specService)
+	explorer.SetSize(80, 24)
+	explorer.leftPane.SelectNextChild()
+	explorer.leftPane.SelectNextChild()
+	selected := explorer.leftPane.GetSelectedChild()
+	explorer.activeSpec = selected
+
+	// Rename the selected child behind the explorer's back
+	node, err := specService.ReadNode(selected.ID())
+	if err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	node.SetTitle("Renamed")
+	if err := store.WriteNode(node); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+
+	explorer.Reload()
+	if explorer.activeSpec.ID() != selected.ID() {
+		t.Fatalf("Expected %s to stay selected, got %s", selected.ID(), explorer.activeSpec.ID())
+	}
+	if explorer.activeSpec.Title() != "Renamed" {
+		t.Errorf("Expected the selected node to be re-read, got title %q", explorer.activeSpec.Title())
+	}
+}
+
+func TestNodeExplorerFilterBar(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	linkService := services.NewLinkService(store, services.NewGitService())
+	specService := services.NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	var tagged models.Node
+	for _, title := range []string{"First", "Second"} {
+		child, err := specService.CreateSpec(title, title+" content")
+		if err != nil {
+			t.Fatalf("Failed to create spec: %v", err)
+		}
+		if _, err := specService.AddChildToParent(child.ID(), root.ID(), "child"); err != nil {
+			t.Fatalf("Failed to link child: %v", err)
+		}
+		tagged = child
+	}
+	if _, err := specService.TagNode(tagged.ID(), []string{"security"}, nil); err != nil {
+		t.Fatalf("Failed to tag node: %v", err)
+	}
+
+	explorer := NewSpecExplorer(&testExplorerCombinedService{linkService: linkService, specService: specService}, specService)
+	explorer.SetQueryService(services.NewQueryService(store, specService))
+	explorer.SetSize(80, 24)
+
+	explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("tag:security")})
+	explorer.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if explorer.filtering || explorer.filterQuery != "tag:security" {
+		t.Fatalf("Expected the filter to be applied, got query %q (error %q)", explorer.filterQuery, explorer.filterError)
+	}
+	explorer.leftPane.SelectNextChild()
+	if child := explorer.leftPane.GetSelectedChild(); child == nil || child.ID() != tagged.ID() {
+		t.Errorf("Expected only the tagged child to be listed, got %v", child)
+	}
+	explorer.leftPane.SelectNextChild()
+	if child := explorer.leftPane.GetSelectedChild(); child == nil || child.ID() != tagged.ID() {
+		t.Errorf("Expected the untagged child to be hidden, got %v", child)
+	}
+
+	// An invalid query keeps the bar open with the error, and an empty one clears the filter
+	explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	explorer.filterInput.SetValue("owner:alice")
+	explorer.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !explorer.filtering || explorer.filterError == "" {
+		t.Fatalf("Expected an invalid query to be reported")
+	}
+	explorer.filterInput.SetValue("")
+	explorer.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if explorer.filtering || explorer.filterQuery != "" {
+		t.Fatalf("Expected an empty query to clear the filter, got %q", explorer.filterQuery)
+	}
+	explorer.leftPane.SelectNextChild()
+	explorer.leftPane.SelectNextChild()
+	if child := explorer.leftPane.GetSelectedChild(); child == nil || child.ID() != tagged.ID() {
+		t.Errorf("Expected both children to be listed again, got %v", child)
+	}
+}
+
+func TestNodeExplorerSearchJumps(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	linkService := services.NewLinkService(store, services.NewGitService())
+	specService := services.NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	parent, err := specService.CreateSpec("Storage", "Where nodes are kept")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	if _, err := specService.AddChildToParent(parent.ID(), root.ID(), "child"); err != nil {
+		t.Fatalf("Failed to link child: %v", err)
+	}
+	var target models.Node
+	for _, title := range []string{"Indexes", "Merge driver"} {
+		child, err := specService.CreateSpec(title, title+" of the storage directory")
+		if err != nil {
+			t.Fatalf("Failed to create spec: %v", err)
+		}
+		if _, err := specService.AddChildToParent(child.ID(), parent.ID(), "child"); err != nil {
+			t.Fatalf("Failed to link child: %v", err)
+		}
+		target = child
+	}
+
+	explorer := NewSpecExplorer(&testExplorerCombinedService{linkService: linkService, specService: specService}, specService)
+	explorer.SetSearchService(services.NewSearchService(store))
+	explorer.SetSize(80, 24)
+
+	explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("merg")})
+	if !explorer.searching || !strings.Contains(explorer.View(), "Merge driver") {
+		t.Fatalf("Expected the search overlay to list the match")
+	}
+	explorer.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if explorer.searching {
+		t.Fatalf("Expected choosing a result to close the overlay")
+	}
+	if explorer.currentSpec.ID() != parent.ID() || explorer.activeSpec.ID() != target.ID() {
+		t.Errorf("Expected the result to be selected under its parent, got %q under %q", explorer.activeSpec.Title(), explorer.currentSpec.Title())
+	}
+
+	// Esc closes the overlay without moving
+	explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("indexes")})
+	explorer.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if explorer.searching || explorer.activeSpec.ID() != target.ID() {
+		t.Errorf("Expected Esc to leave the selection alone")
+	}
+}
+
+func TestNodeExplorerReorderKeys(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	linkService := services.NewLinkService(store, services.NewGitService())
+	specService := services.NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	var second models.Node
+	for _, title := range []string{"First", "Second"} {
+		child, err := specService.CreateSpec(title, title+" content")
+		if err != nil {
+			t.Fatalf("Failed to create spec: %v", err)
+		}
+		if _, err := specService.AddChildToParent(child.ID(), root.ID(), "child"); err != nil {
+			t.Fatalf("Failed to link child: %v", err)
+		}
+		second = child
+	}
+
+	explorer := NewSpecExplorer(&testExplorerCombinedService{linkService: linkService, specService: specService}, specService)
+	explorer.SetSize(80, 24)
+
+	// Nothing to move until a child is selected
+	if _, cmd := explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")}); cmd != nil {
+		t.Errorf("Expected no command without a selected child")
+	}
+
+	explorer.Update(tea.KeyMsg{Type: tea.KeyDown})
+	explorer.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd := explorer.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if cmd == nil {
+		t.Fatalf("Expected a command to move the selected child")
+	}
+	msg, ok := cmd().(ReorderChildMsg)
+	if !ok || msg.ParentSpecID != root.ID() || msg.SpecID != second.ID() || msg.Offset != -1 {
+		t.Fatalf("Expected the second child to be moved up, got %+v", msg)
+	}
+
+	// Once the move is saved, reloading keeps the moved child selected
+	if _, err := specService.MoveChild(msg.ParentSpecID, msg.SpecID, msg.Offset); err != nil {
+		t.Fatalf("Failed to move child: %v", err)
+	}
+	explorer.Reload()
+	if explorer.activeSpec.ID() != second.ID() {
+		t.Errorf("Expected the moved child to stay selected, got %q", explorer.activeSpec.Title())
+	}
+	explorer.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if child := explorer.leftPane.GetSelectedChild(); child == nil || child.Title() != "First" {
+		t.Errorf("Expected the first child to come after the moved one, got %v", child)
+	}
+}
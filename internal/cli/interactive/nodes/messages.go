@@ -25,6 +25,14 @@ type MoveSpecMsg struct {
 	SpecID string
 }
 
+// ReorderChildMsg asks for a child to be moved up (a negative offset) or down among the
+// children of its parent
+type ReorderChildMsg struct {
+	ParentSpecID string
+	SpecID       string
+	Offset       int
+}
+
 type OrganizeSpecMsg struct {
 	SpecID string
 }
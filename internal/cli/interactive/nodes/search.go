@@ -0,0 +1,181 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/cli/interactive/common"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+)
+
+// searchResultLimit is how many results the search overlay shows
+const searchResultLimit = 8
+
+// NodeSearch is the search overlay of the node explorer. It searches node titles and
+// contents as a query is typed, and lets one of the results be picked.
+type NodeSearch struct {
+	searchService services.SearchService
+	input         textinput.Model
+	results       []services.SearchResult
+	cursor        int
+	err           string
+	width         int
+}
+
+func NewNodeSearch(searchService services.SearchService) *NodeSearch {
+	input := textinput.New()
+	input.Prompt = "Search: "
+	input.Placeholder = "words in a title or content"
+	return &NodeSearch{
+		searchService: searchService,
+		input:         input,
+	}
+}
+
+// Open clears the previous search and focuses the query
+func (s *NodeSearch) Open() tea.Cmd {
+	s.input.SetValue("")
+	s.results = nil
+	s.cursor = 0
+	s.err = ""
+	return s.input.Focus()
+}
+
+func (s *NodeSearch) Close() {
+	s.input.Blur()
+}
+
+func (s *NodeSearch) SetWidth(width int) {
+	s.width = width
+	s.input.Width = max(width-len(s.input.Prompt)-6, 10)
+}
+
+// Selected returns the highlighted result, if there are any results
+func (s *NodeSearch) Selected() *services.SearchResult {
+	if s.cursor < 0 || s.cursor >= len(s.results) {
+		return nil
+	}
+	return &s.results[s.cursor]
+}
+
+func (s *NodeSearch) Init() tea.Cmd {
+	return nil
+}
+
+func (s *NodeSearch) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return s, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "ctrl+p":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+		return s, nil
+	case "down", "ctrl+n", "tab":
+		if s.cursor < len(s.results)-1 {
+			s.cursor++
+		}
+		return s, nil
+	}
+
+	query := s.input.Value()
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(keyMsg)
+	if s.input.Value() != query {
+		s.search()
+	}
+	return s, cmd
+}
+
+// search runs the query as it stands
+func (s *NodeSearch) search() {
+	s.cursor = 0
+	s.err = ""
+	if strings.TrimSpace(s.input.Value()) == "" {
+		s.results = nil
+		return
+	}
+
+	results, err := s.searchService.Search(s.input.Value(), searchResultLimit)
+	if err != nil {
+		s.results = nil
+		s.err = err.Error()
+		return
+	}
+	s.results = results
+}
+
+func (s *NodeSearch) View() string {
+	var sb strings.Builder
+	sb.WriteString(s.input.View())
+	sb.WriteString("\n")
+
+	lineWidth := max(s.width-6, 10)
+	switch {
+	case s.err != "":
+		sb.WriteString("\n" + common.ErrorStyle().Render(s.err) + "\n")
+	case strings.TrimSpace(s.input.Value()) != "" && len(s.results) == 0:
+		sb.WriteString("\n[No matching nodes]\n")
+	}
+	for i, result := range s.results {
+		title := renderHighlights(result.Title, result.TitleHighlights, lineWidth-len(result.Status)-5, lipgloss.NewStyle())
+		line := fmt.Sprintf("%s [%s]", title, result.Status)
+		if i == s.cursor {
+			sb.WriteString("\n" + common.ActiveNodeStyle().Render("> ") + line + "\n")
+		} else {
+			sb.WriteString("\n  " + line + "\n")
+		}
+		if result.Snippet != "" {
+			sb.WriteString("  " + renderHighlights(result.Snippet, result.Highlights, lineWidth-2, snippetStyle) + "\n")
+		}
+	}
+	sb.WriteString("\n↑/↓ choose, ↵ go to node, Esc close")
+
+	return searchBoxStyle.Width(s.width - 2).Render(sb.String())
+}
+
+var (
+	searchBoxStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder(), true).
+			BorderForeground(lipgloss.Color("6")).
+			Padding(0, 1)
+	snippetStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+)
+
+// renderHighlights cuts text down to a number of characters and shows the highlighted
+// spans in it, and the rest of it in the base style
+func renderHighlights(text string, spans []services.TextSpan, maxChars int, base lipgloss.Style) string {
+	end := len(text)
+	if maxChars > 1 && len([]rune(text)) > maxChars {
+		end = len(string([]rune(text)[:maxChars-1]))
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span.Start >= end {
+			break
+		}
+		sb.WriteString(base.Render(text[last:span.Start]))
+		sb.WriteString(common.HighlightStyle().Render(text[span.Start:min(span.End, end)]))
+		last = min(span.End, end)
+	}
+	sb.WriteString(base.Render(text[last:end]))
+	if end < len(text) {
+		sb.WriteString(base.Render("…"))
+	}
+	return sb.String()
+}
+
+// staticView shows already rendered output, for the search overlay to be drawn over
+type staticView string
+
+func (v staticView) Init() tea.Cmd                       { return nil }
+func (v staticView) Update(tea.Msg) (tea.Model, tea.Cmd) { return v, nil }
+func (v staticView) View() string                        { return string(v) }
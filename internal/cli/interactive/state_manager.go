@@ -126,6 +126,10 @@ func (s *StateManager) RefreshSpecListView() tea.Cmd {
 	return s.specListView.Refresh()
 }
 
+func (s *StateManager) ReloadSpecListView() tea.Cmd {
+	return s.specListView.Reload()
+}
+
 func (s *StateManager) GetInputTitle() string {
 	return s.inputTitle
 }
@@ -4,10 +4,11 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
 )
 
 // createLinkCommand creates the link management commands
-func (a *App) createLinkCommand(jsonOutput, quiet bool) *cobra.Command {
+func (a *App) createLinkCommand(jsonOutput, quiet *bool) *cobra.Command {
 	linkCmd := &cobra.Command{
 		Use:   "link",
 		Short: "Manage spec-commit links",
@@ -32,18 +33,18 @@ func (a *App) createLinkCommand(jsonOutput, quiet bool) *cobra.Command {
 				return err
 			}
 
-			if jsonOutput {
+			if *jsonOutput {
 				return a.outputJSON(link)
 			}
 
-			if !quiet {
+			if !*quiet {
 				fmt.Printf("Created link between spec %s and commit %s\n", specID, commitID)
 			}
 			return nil
 		},
 	}
 	createCmd.Flags().StringVar(&specID, "spec", "", "Specification ID (required)")
-	createCmd.Flags().StringVar(&commitID, "commit", "", "Commit hash (required)")
+	createCmd.Flags().StringVar(&commitID, "commit", "", "Commit hash, branch, tag or other revision (required)")
 	createCmd.Flags().StringVar(&repoPath, "repo", "", "Repository path (default: current directory)")
 	createCmd.Flags().StringVar(&label, "type", "implements", "Link type (implements or fixes)")
 	_ = createCmd.MarkFlagRequired("spec")
@@ -60,7 +61,7 @@ func (a *App) createLinkCommand(jsonOutput, quiet bool) *cobra.Command {
 				return err
 			}
 
-			if jsonOutput {
+			if *jsonOutput {
 				return a.outputJSON(links)
 			}
 
@@ -83,7 +84,7 @@ func (a *App) createLinkCommand(jsonOutput, quiet bool) *cobra.Command {
 				return err
 			}
 
-			if jsonOutput {
+			if *jsonOutput {
 				return a.outputJSON(specs)
 			}
 
@@ -105,7 +106,7 @@ func (a *App) createLinkCommand(jsonOutput, quiet bool) *cobra.Command {
 				return err
 			}
 
-			if !quiet {
+			if !*quiet {
 				fmt.Printf("Deleted link between spec %s and commit %s\n", specID, commitID)
 			}
 			return nil
@@ -117,6 +118,54 @@ func (a *App) createLinkCommand(jsonOutput, quiet bool) *cobra.Command {
 	_ = deleteCmd.MarkFlagRequired("spec")
 	_ = deleteCmd.MarkFlagRequired("commit")
 
-	linkCmd.AddCommand(createCmd, listBySpecCmd, listByCommitCmd, deleteCmd)
+	// link scan
+	var since string
+	var maxCount int
+	scanCmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Create links from commit message trailers",
+		Long: `Walk git history and link commits to the specs named in their message trailers.
+
+Recognized trailers are Implements, Updates, Fixes, Refactors, Documents and Tests,
+each followed by one or more comma-separated spec IDs or slugs, for example:
+
+    Implements: 3f1c9a2e-8d4b-4c55-9a2e-1b7f0c6d5e4a
+    Fixes: node-slug
+
+Links that already exist are skipped, so the command can be run repeatedly.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repoPath == "" {
+				repoPath = a.config.Git.DefaultRepo
+			}
+
+			revRange := "HEAD"
+			if since != "" {
+				revRange = since + "..HEAD"
+			}
+
+			result, err := a.linkService.ScanCommits(repoPath, services.LogOptions{
+				RevRange: revRange,
+				MaxCount: maxCount,
+				Reverse:  true,
+			})
+			if err != nil {
+				return err
+			}
+
+			if *jsonOutput {
+				return a.outputJSON(result)
+			}
+
+			if !*quiet {
+				return a.outputTrailerScanResult(result)
+			}
+			return nil
+		},
+	}
+	scanCmd.Flags().StringVar(&since, "since", "", "Only scan commits after this revision (default: all history)")
+	scanCmd.Flags().IntVarP(&maxCount, "max-count", "n", 0, "Only scan the most recent n commits")
+	scanCmd.Flags().StringVar(&repoPath, "repo", "", "Repository path (default: current directory)")
+
+	linkCmd.AddCommand(createCmd, listBySpecCmd, listByCommitCmd, deleteCmd, scanCmd)
 	return linkCmd
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/mcp"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
 )
 
 func (a *App) createMCPCommand() *cobra.Command {
@@ -17,16 +18,32 @@ func (a *App) createMCPCommand() *cobra.Command {
 	mcpCmd := &cobra.Command{
 		Use:   "mcp",
 		Short: "Start MCP server",
-		Long:  "Start a Model Context Protocol server that provides tools for creating child specifications.",
+		Long: `Start a Model Context Protocol server that provides tools for navigating and editing specifications, prompts for implementing, testing and reviewing them, and exposes each spec node as a resource that clients can subscribe to.
+
+With the http transport, GET /healthz reports whether the server is up. Set mcp.auth_token in the
+config file, or the ZAMM_MCP_AUTH_TOKEN environment variable, to require clients to send that token
+in an "Authorization: Bearer" header.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			server := mcp.NewServer(a.specService)
+			server := mcp.NewServer(a.specService, a.linkService, a.queryService)
+
+			// Let clients subscribe to node resources when specs live on disk
+			if fileStorage, ok := a.storage.(*storage.FileStorage); ok {
+				watcher, err := storage.NewWatcher(fileStorage)
+				if err != nil {
+					return fmt.Errorf("failed to watch spec files: %w", err)
+				}
+				defer func() { _ = watcher.Close() }()
+				server.WatchChanges(watcher)
+			}
+
+			server.RequireBearerToken(a.config.MCP.AuthToken)
 
 			sigChan := make(chan os.Signal, 1)
 			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 			errChan := make(chan error, 1)
 			go func() {
-				errChan <- server.Start(transport, address)
+				errChan <- server.Start(cmd.Context(), transport, address)
 			}()
 
 			select {
@@ -35,10 +52,13 @@ func (a *App) createMCPCommand() *cobra.Command {
 					return fmt.Errorf("MCP server error: %w", err)
 				}
 			case sig := <-sigChan:
-				fmt.Printf("\nReceived signal %v, shutting down MCP server...\n", sig)
+				fmt.Fprintf(os.Stderr, "\nReceived signal %v, shutting down MCP server...\n", sig)
 				if err := server.Stop(); err != nil {
 					return fmt.Errorf("error stopping MCP server: %w", err)
 				}
+				if err := <-errChan; err != nil {
+					return fmt.Errorf("MCP server error: %w", err)
+				}
 			}
 
 			return nil
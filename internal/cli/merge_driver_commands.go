@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// mergeDriverName is the name the merge driver is registered under in git config and
+// referred to by in .gitattributes
+const mergeDriverName = "zamm-index"
+
+// createMergeDriverCommand creates the git merge driver commands for the CSV indexes
+func (a *App) createMergeDriverCommand(quiet *bool) *cobra.Command {
+	mergeDriverCmd := &cobra.Command{
+		Use:   "merge-driver",
+		Short: "Merge the .zamm CSV indexes row by row in git",
+		Long: `Branches that both add links change spec-links.csv, commit-links.csv and node-files.csv
+in the same places, so git's line-based merge reports conflicts even though the rows
+are independent. The merge driver merges these files row by row instead, and only
+reports rows both branches changed differently, such as a node mapped to two paths.`,
+	}
+
+	// merge-driver install
+	var repoPath, binary string
+	installCmd := &cobra.Command{
+		Use:          "install",
+		Short:        "Register the merge driver in a repository's .gitattributes and git config",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repoPath == "" {
+				repoPath = a.config.Git.DefaultRepo
+			}
+
+			topLevel, err := a.gitService.TopLevel(repoPath)
+			if err != nil {
+				return err
+			}
+			storageDir, err := filepath.Abs(a.config.Storage.Path)
+			if err != nil {
+				return fmt.Errorf("failed to resolve storage path: %w", err)
+			}
+			relStorageDir, err := filepath.Rel(topLevel, storageDir)
+			if err != nil || strings.HasPrefix(relStorageDir, "..") {
+				return models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("the storage directory %s is outside the repository %s", storageDir, topLevel))
+			}
+
+			patterns := make([]string, 0, len(storage.IndexMergeFiles))
+			for _, file := range storage.IndexMergeFiles {
+				patterns = append(patterns, filepath.ToSlash(filepath.Join(relStorageDir, file)))
+			}
+			attributesPath := filepath.Join(topLevel, ".gitattributes")
+			added, err := ensureMergeAttributes(attributesPath, patterns)
+			if err != nil {
+				return err
+			}
+
+			if err := a.gitService.SetConfig(topLevel, "merge."+mergeDriverName+".name", "zamm CSV index merge"); err != nil {
+				return err
+			}
+			if err := a.gitService.SetConfig(topLevel, "merge."+mergeDriverName+".driver", mergeDriverCommand(binary)); err != nil {
+				return err
+			}
+
+			if !*quiet {
+				for _, line := range added {
+					fmt.Printf("Added to %s: %s\n", attributesPath, line)
+				}
+				fmt.Printf("Registered merge driver %s in %s\n", mergeDriverName, topLevel)
+			}
+			return nil
+		},
+	}
+	installCmd.Flags().StringVar(&repoPath, "repo", "", "Repository path (default: current directory)")
+	installCmd.Flags().StringVar(&binary, "binary", "zamm", "zamm executable git should invoke")
+
+	// merge-driver merge (invoked by git)
+	mergeCmd := &cobra.Command{
+		Use:   "merge <base> <ours> <theirs> [path]",
+		Short: "Merge a CSV index, leaving the result in <ours>",
+		Args:  cobra.RangeArgs(3, 4),
+		// Printed by git during the merge, so keep the output to the conflicts themselves
+		Hidden:       true,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			versions := make([][]byte, 0, 3)
+			for _, path := range args[:3] {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", path, err)
+				}
+				versions = append(versions, data)
+			}
+
+			result, err := storage.MergeIndex(versions[0], versions[1], versions[2])
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(args[1], result.Data, 0644); err != nil {
+				return fmt.Errorf("failed to write merge result: %w", err)
+			}
+			if len(result.Conflicts) == 0 {
+				return nil
+			}
+
+			name := "CSV index"
+			if len(args) == 4 {
+				name = args[3]
+			}
+			details := make([]string, 0, len(result.Conflicts))
+			for _, conflict := range result.Conflicts {
+				details = append(details, conflict.Message)
+			}
+			zammErr := models.NewZammError(models.ErrTypeConflict, fmt.Sprintf("%d conflicting row(s) in %s", len(result.Conflicts), name))
+			zammErr.Details = strings.Join(details, "; ")
+			return zammErr
+		},
+	}
+
+	mergeDriverCmd.AddCommand(installCmd, mergeCmd)
+	return mergeDriverCmd
+}
+
+// mergeDriverCommand is the command git runs to merge an index. Git runs it through a
+// shell after replacing the placeholders with the paths of the versions to merge.
+func mergeDriverCommand(binary string) string {
+	return shellQuote(binary) + " merge-driver merge %O %A %B %P"
+}
+
+// ensureMergeAttributes adds a line assigning the merge driver to each pattern that
+// .gitattributes doesn't already have one for, and returns the lines it added
+func ensureMergeAttributes(attributesPath string, patterns []string) ([]string, error) {
+	existing, err := os.ReadFile(attributesPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", attributesPath, err)
+	}
+
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+
+	content := string(existing)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	added := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		line := pattern + " merge=" + mergeDriverName
+		if present[line] {
+			continue
+		}
+		content += line + "\n"
+		added = append(added, line)
+	}
+	if len(added) == 0 {
+		return added, nil
+	}
+
+	if err := os.WriteFile(attributesPath, []byte(content), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", attributesPath, err)
+	}
+	return added, nil
+}
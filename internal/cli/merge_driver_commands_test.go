@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsureMergeAttributes(t *testing.T) {
+	attributesPath := filepath.Join(t.TempDir(), ".gitattributes")
+	if err := os.WriteFile(attributesPath, []byte("*.png binary"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitattributes: %v", err)
+	}
+	patterns := []string{".zamm/spec-links.csv", ".zamm/node-files.csv"}
+
+	added, err := ensureMergeAttributes(attributesPath, patterns)
+	if err != nil {
+		t.Fatalf("Failed to add attributes: %v", err)
+	}
+	if len(added) != 2 {
+		t.Errorf("Expected both patterns to be added, got %v", added)
+	}
+
+	added, err = ensureMergeAttributes(attributesPath, patterns)
+	if err != nil {
+		t.Fatalf("Failed to add attributes: %v", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("Expected a second install to add nothing, got %v", added)
+	}
+
+	data, err := os.ReadFile(attributesPath)
+	if err != nil {
+		t.Fatalf("Failed to read .gitattributes: %v", err)
+	}
+	expected := "*.png binary\n.zamm/spec-links.csv merge=zamm-index\n.zamm/node-files.csv merge=zamm-index\n"
+	if string(data) != expected {
+		t.Errorf("Expected existing attributes to be kept, got:\n%s", data)
+	}
+}
+
+func TestMergeDriverCommandQuotesBinary(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	// A binary path with a space and a quote in it, which records the arguments it gets
+	dir := filepath.Join(t.TempDir(), "My Tools", "it's")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	binary := filepath.Join(dir, "zamm")
+	argsFile := filepath.Join(t.TempDir(), "args")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho \"$@\" > \""+argsFile+"\"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake binary: %v", err)
+	}
+
+	// Git replaces the placeholders with the shell-quoted paths of the versions
+	command := strings.NewReplacer("%O", "base", "%A", "ours", "%B", "theirs", "%P", "index.csv").Replace(mergeDriverCommand(binary))
+	if output, err := exec.Command("sh", "-c", command).CombinedOutput(); err != nil {
+		t.Fatalf("Merge driver failed: %v\n%s", err, output)
+	}
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Expected the merge driver to run the binary: %v", err)
+	}
+	if strings.TrimSpace(string(args)) != "merge-driver merge base ours theirs index.csv" {
+		t.Errorf("Expected the binary to get the subcommand and paths, got %q", args)
+	}
+}
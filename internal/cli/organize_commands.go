@@ -6,7 +6,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func (a *App) createOrganizeCommand(jsonOutput, quiet bool) *cobra.Command {
+func (a *App) createOrganizeCommand(jsonOutput, quiet *bool) *cobra.Command {
 	return &cobra.Command{
 		Use:   "organize [node-id]",
 		Short: "Organize nodes into hierarchical file structure",
@@ -35,7 +35,7 @@ The command will:
 				return fmt.Errorf("failed to organize nodes: %w", err)
 			}
 
-			if !quiet {
+			if !*quiet {
 				if nodeID != "" {
 					fmt.Printf("Successfully organized node %s into hierarchical structure\n", nodeID)
 				} else {
@@ -44,7 +44,7 @@ The command will:
 				fmt.Println("Updated node-files.csv with new file paths")
 			}
 
-			if jsonOutput {
+			if *jsonOutput {
 				result := map[string]interface{}{
 					"success": true,
 					"message": "Nodes organized successfully",
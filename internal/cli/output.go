@@ -8,7 +8,9 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
 )
 
 // Output formatting helpers
@@ -49,16 +51,17 @@ func (a *App) outputNodeTable(nodes []models.Node) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "ID\tTYPE\tTITLE")
+	_, _ = fmt.Fprintln(w, "ID\tTYPE\tSTATUS\tTITLE")
 
 	for _, node := range nodes {
 		title := node.Title()
 		if len(title) > 50 {
 			title = title[:47] + "..."
 		}
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
 			node.ID(),
 			node.Type(),
+			node.Status(),
 			title,
 		)
 	}
@@ -70,11 +73,44 @@ func (a *App) outputSpecDetails(node models.Node) error {
 	fmt.Printf("ID: %s\n", node.ID())
 	fmt.Printf("Title: %s\n", node.Title())
 	fmt.Printf("Type: %s\n", node.Type())
+	fmt.Printf("Status: %s\n", node.Status())
+	if len(node.Tags()) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(node.Tags(), ", "))
+	}
 	fmt.Printf("\nContent:\n%s\n", strings.Repeat("-", 40))
 	fmt.Printf("%s\n", node.Content())
 	return nil
 }
 
+func (a *App) outputDeletionPlan(plan *services.DeletionPlan, dryRun bool) {
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+
+	fmt.Printf("%s %d node(s):\n", verb, len(plan.DeletedNodes))
+	for _, node := range plan.DeletedNodes {
+		fmt.Printf("  %s  %s\n", node.ID, node.Title)
+	}
+
+	if len(plan.AddedSpecLinks) > 0 {
+		fmt.Printf("Children moved to new parents: %d link(s)\n", len(plan.AddedSpecLinks))
+		for _, link := range plan.AddedSpecLinks {
+			fmt.Printf("  %s -> %s\n", link.FromSpecID, link.ToSpecID)
+		}
+	}
+
+	fmt.Printf("Hierarchy links removed: %d\n", len(plan.RemovedSpecLinks))
+	fmt.Printf("Commit links removed: %d\n", len(plan.RemovedCommitLinks))
+
+	if len(plan.UpdatedNodes) > 0 {
+		fmt.Println("Child links regenerated for:")
+		for _, node := range plan.UpdatedNodes {
+			fmt.Printf("  %s  %s\n", node.ID, node.Title)
+		}
+	}
+}
+
 func (a *App) outputLinkTable(links []*models.SpecCommitLink) error {
 	if len(links) == 0 {
 		fmt.Println("No links found")
@@ -95,3 +131,335 @@ func (a *App) outputLinkTable(links []*models.SpecCommitLink) error {
 
 	return w.Flush()
 }
+
+func (a *App) outputTrailerScanResult(result *services.TrailerScanResult) error {
+	fmt.Printf("Scanned %d commits: %d links created, %d already existed\n",
+		result.CommitsScanned, len(result.Created), len(result.Existing))
+
+	if len(result.Created) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "COMMIT\tSPEC\tTYPE")
+		for _, link := range result.Created {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", link.CommitID[:12]+"...", link.SpecID, link.LinkLabel)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(result.Unresolved) > 0 {
+		fmt.Printf("\nUnresolved trailers:\n")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "COMMIT\tTRAILER\tREASON")
+		for _, trailer := range result.Unresolved {
+			_, _ = fmt.Fprintf(w, "%s\t%s: %s\t%s\n", trailer.CommitID[:12]+"...", trailer.Label, trailer.Reference, trailer.Reason)
+		}
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// linkLabelAbbreviations gives the short column headings used for link labels
+var linkLabelAbbreviations = map[string]string{
+	"implements": "IMPL",
+	"updates":    "UPDATE",
+	"fixes":      "FIX",
+	"refactors":  "CLEAN",
+	"documents":  "DOC",
+	"tests":      "TEST",
+}
+
+func (a *App) outputCoverageTable(report *services.CoverageReport) error {
+	if len(report.Specs) == 0 {
+		fmt.Println("No specifications found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := []string{"SPEC"}
+	for _, label := range services.SpecTrailerLabels {
+		header = append(header, linkLabelAbbreviations[label])
+	}
+	header = append(header, "STATUS", "COVERED")
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for _, spec := range report.Specs {
+		title := spec.Title
+		if len(title) > 50 {
+			title = title[:47] + "..."
+		}
+
+		row := []string{strings.Repeat("  ", max(spec.Depth-1, 0)) + title}
+		for _, label := range services.SpecTrailerLabels {
+			row = append(row, fmt.Sprintf("%d", spec.TotalLinks[label]))
+		}
+		row = append(row, string(spec.Status))
+		if spec.Covered {
+			row = append(row, "yes")
+		} else {
+			row = append(row, "no")
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d of %d specifications covered\n", report.CoveredSpecs, report.TotalSpecs)
+	return nil
+}
+
+func (a *App) outputCoverageMarkdown(report *services.CoverageReport) error {
+	var sb strings.Builder
+
+	sb.WriteString("# Spec Coverage\n\n")
+	if report.TotalSpecs == 0 {
+		sb.WriteString("No specifications found.\n")
+		fmt.Print(sb.String())
+		return nil
+	}
+
+	fmt.Fprintf(&sb, "**%d of %d** specifications (%.0f%%) have linked commits.\n\n",
+		report.CoveredSpecs, report.TotalSpecs, 100*float64(report.CoveredSpecs)/float64(report.TotalSpecs))
+
+	sb.WriteString("| Spec |")
+	for _, label := range services.SpecTrailerLabels {
+		fmt.Fprintf(&sb, " %s |", strings.ToUpper(label[:1])+label[1:])
+	}
+	sb.WriteString("\n|---|")
+	for range services.SpecTrailerLabels {
+		sb.WriteString(":---:|")
+	}
+	sb.WriteString("\n")
+
+	for _, spec := range report.Specs {
+		fmt.Fprintf(&sb, "| %s%s |", strings.Repeat("&nbsp;&nbsp;", max(spec.Depth-1, 0)), spec.Title)
+		for _, label := range services.SpecTrailerLabels {
+			if count := spec.TotalLinks[label]; count > 0 {
+				fmt.Fprintf(&sb, " %d |", count)
+			} else {
+				sb.WriteString(" – |")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	var uncovered []services.SpecCoverage
+	for _, spec := range report.Specs {
+		if !spec.Covered {
+			uncovered = append(uncovered, spec)
+		}
+	}
+	if len(uncovered) > 0 {
+		sb.WriteString("\n## Uncovered Specifications\n\n")
+		for _, spec := range uncovered {
+			fmt.Fprintf(&sb, "- %s (`%s`)\n", spec.Title, spec.SpecID)
+		}
+	}
+
+	fmt.Print(sb.String())
+	return nil
+}
+
+func (a *App) outputDriftTable(report *services.DriftReport, all bool) error {
+	if len(report.Specs) == 0 {
+		if all {
+			fmt.Println("No specifications found")
+		} else {
+			fmt.Println("No stale specifications found")
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tTITLE\tSTATUS\tIMPLEMENTED IN\tCHANGED IN")
+
+	for _, spec := range report.Specs {
+		title := spec.Title
+		if len(title) > 50 {
+			title = title[:47] + "..."
+		}
+
+		implementedIn := "-"
+		if spec.ImplementedIn != "" {
+			implementedIn = spec.ImplementedIn[:min(12, len(spec.ImplementedIn))]
+		}
+
+		changedIn := make([]string, 0, len(spec.ChangedIn)+1)
+		for _, commitID := range spec.ChangedIn {
+			changedIn = append(changedIn, commitID[:min(12, len(commitID))])
+		}
+		if spec.UncommittedChanges {
+			changedIn = append(changedIn, "(uncommitted)")
+		}
+		if len(changedIn) == 0 {
+			changedIn = append(changedIn, "-")
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			spec.SpecID, title, spec.Status, implementedIn, strings.Join(changedIn, ", "))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d of %d specifications changed since they were last implemented\n", report.StaleSpecs, report.TotalSpecs)
+	return nil
+}
+
+func (a *App) outputDoctorReport(report *services.DoctorReport, fixed bool) error {
+	if len(report.Issues) == 0 {
+		fmt.Println("No issues found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ISSUE\tNODE\tDETAILS\tREPAIR")
+
+	for _, issue := range report.Issues {
+		nodeID := issue.NodeID
+		if nodeID == "" {
+			nodeID = "-"
+		}
+
+		repair := "manual"
+		if issue.Fixed {
+			repair = "fixed: " + issue.Fix
+		} else if issue.Fixable {
+			repair = "--fix will " + issue.Fix
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", issue.Kind, nodeID, issue.Message, repair)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if fixed {
+		fmt.Printf("\nFound %d issues, fixed %d\n", len(report.Issues), report.Fixed)
+	} else {
+		fmt.Printf("\nFound %d issues\n", len(report.Issues))
+	}
+	return nil
+}
+
+func (a *App) outputMigrationStatus(status *services.MigrationStatus) {
+	fmt.Printf("Schema version: %d (latest: %d)\n", status.CurrentVersion, status.LatestVersion)
+	if status.CurrentVersion > status.LatestVersion {
+		fmt.Println("The store was written by a newer version of zamm, upgrade zamm to modify it")
+		return
+	}
+	if len(status.Pending) == 0 {
+		fmt.Println("All migrations are up to date.")
+		return
+	}
+
+	fmt.Printf("%d pending migration(s):\n", len(status.Pending))
+	for _, migration := range status.Pending {
+		fmt.Printf("  %d  %s: %s\n", migration.Version, migration.Name, migration.Description)
+	}
+}
+
+func (a *App) outputMigrationReport(report *services.MigrationReport) {
+	if len(report.Migrations) == 0 {
+		fmt.Println("All migrations are up to date.")
+		return
+	}
+
+	for _, migration := range report.Migrations {
+		fmt.Printf("[%s] %s\n", migration.Name, migration.Description)
+		if len(migration.Changes) == 0 {
+			fmt.Println("  nothing to change")
+		}
+		for _, change := range migration.Changes {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+
+	if report.DryRun {
+		fmt.Printf("\nWould migrate from schema version %d to %d\n", report.FromVersion, report.ToVersion)
+	} else {
+		fmt.Printf("\nMigrated from schema version %d to %d\n", report.FromVersion, report.ToVersion)
+	}
+}
+
+func (a *App) outputImportPlan(plan *services.ImportPlan, dryRun bool) {
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+
+	fmt.Printf("%s %d file(s) under %s:\n", verb, plan.Count(), plan.ParentTitle)
+	var printFiles func(files []*services.ImportedFile, depth int)
+	printFiles = func(files []*services.ImportedFile, depth int) {
+		for _, file := range files {
+			indent := strings.Repeat("  ", depth+1)
+			switch {
+			case file.Existing:
+				fmt.Printf("%s%s  (%s, existing node %s)\n", indent, file.Title, file.Path, file.NodeID)
+			case file.NodeID != "":
+				fmt.Printf("%s%s  %s  (%s)\n", indent, file.NodeID, file.Title, file.Path)
+			default:
+				fmt.Printf("%s%s  (%s)\n", indent, file.Title, file.Path)
+			}
+			printFiles(file.Children, depth+1)
+		}
+	}
+	printFiles(plan.Files, 0)
+
+	if len(plan.Skipped) > 0 {
+		fmt.Printf("Skipped %d file(s):\n", len(plan.Skipped))
+		for _, skipped := range plan.Skipped {
+			fmt.Printf("  %s %s\n", skipped.Path, skipped.Reason)
+		}
+	}
+	for _, warning := range plan.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+}
+
+func (a *App) outputWatchResult(result *services.WatchResult) {
+	if result.Node == nil {
+		return
+	}
+	fmt.Printf("Reloaded %s  %s\n", result.Node.ID(), result.Node.Title())
+	for _, parent := range result.RegeneratedParents {
+		fmt.Printf("  regenerated child links of %s  %s\n", parent.ID(), parent.Title())
+	}
+}
+
+func (a *App) outputSearchResults(results []services.SearchResult) {
+	if len(results) == 0 {
+		fmt.Println("No matching nodes found")
+		return
+	}
+
+	for i, result := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s [%s]  %s\n", highlightSpans(result.Title, result.TitleHighlights), result.Status, result.ID)
+		if result.Snippet != "" {
+			fmt.Printf("  %s\n", highlightSpans(result.Snippet, result.Highlights))
+		}
+	}
+}
+
+// highlightSpans shows the given spans of text in bold, when writing to a terminal
+func highlightSpans(text string, spans []services.TextSpan) string {
+	bold := lipgloss.NewStyle().Bold(true)
+	var sb strings.Builder
+	last := 0
+	for _, span := range spans {
+		sb.WriteString(text[last:span.Start])
+		sb.WriteString(bold.Render(text[span.Start:span.End]))
+		last = span.End
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
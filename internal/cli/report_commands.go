@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// createReportCommand creates the reporting commands
+func (a *App) createReportCommand(jsonOutput *bool) *cobra.Command {
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate traceability reports",
+		Long:  "Generate reports about how specifications relate to the commits that implement them.",
+	}
+
+	// report coverage
+	var markdown bool
+	var statusNames []string
+	coverageCmd := &cobra.Command{
+		Use:   "coverage",
+		Short: "Show which specifications have linked commits",
+		Long: `Walk the specification hierarchy from the root node and report, for every
+specification, how many commits of each link type are linked to it or to any
+of its descendants. Specifications without any such commits are uncovered.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statuses, err := parseStatusFilter(statusNames)
+			if err != nil {
+				return err
+			}
+
+			report, err := a.reportService.CoverageReport()
+			if err != nil {
+				return err
+			}
+			if len(statuses) > 0 {
+				report = report.FilterByStatus(statuses)
+			}
+
+			if *jsonOutput {
+				return a.outputJSON(report)
+			}
+
+			if markdown {
+				return a.outputCoverageMarkdown(report)
+			}
+
+			return a.outputCoverageTable(report)
+		},
+	}
+	coverageCmd.Flags().BoolVar(&markdown, "markdown", false, "Output a Markdown summary")
+	coverageCmd.Flags().StringSliceVar(&statusNames, "status", nil, "Only report specifications with these statuses")
+
+	reportCmd.AddCommand(coverageCmd)
+	return reportCmd
+}
@@ -20,16 +20,26 @@ func (a *App) CreateRootCommand() *cobra.Command {
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Quiet output")
 
 	// Add subcommands
-	rootCmd.AddCommand(a.createSpecCommand(jsonOutput, quiet))
-	rootCmd.AddCommand(a.createLinkCommand(jsonOutput, quiet))
-	rootCmd.AddCommand(a.createOrganizeCommand(jsonOutput, quiet))
+	rootCmd.AddCommand(a.createSpecCommand(&jsonOutput, &quiet))
+	rootCmd.AddCommand(a.createLinkCommand(&jsonOutput, &quiet))
+	rootCmd.AddCommand(a.createOrganizeCommand(&jsonOutput, &quiet))
+	rootCmd.AddCommand(a.createImportCommand(&jsonOutput, &quiet))
+	rootCmd.AddCommand(a.createSearchCommand(&jsonOutput))
 	rootCmd.AddCommand(a.createInitCommand())
-	rootCmd.AddCommand(a.createStatusCommand(jsonOutput))
+	rootCmd.AddCommand(a.createStatusCommand(&jsonOutput))
 	rootCmd.AddCommand(a.createVersionCommand())
 	rootCmd.AddCommand(a.createInteractiveCommand())
-	rootCmd.AddCommand(a.createMigrateCommand())
+	rootCmd.AddCommand(a.createMigrateCommand(&jsonOutput))
 	rootCmd.AddCommand(a.createRedirectCommand())
 	rootCmd.AddCommand(a.createMCPCommand())
+	rootCmd.AddCommand(a.createHooksCommand(&quiet))
+	rootCmd.AddCommand(a.createMergeDriverCommand(&quiet))
+	rootCmd.AddCommand(a.createReportCommand(&jsonOutput))
+	rootCmd.AddCommand(a.createDriftCommand(&jsonOutput))
+	rootCmd.AddCommand(a.createCheckCommand(&jsonOutput))
+	rootCmd.AddCommand(a.createDoctorCommand(&jsonOutput))
+	rootCmd.AddCommand(a.createStorageCommand(&quiet))
+	rootCmd.AddCommand(a.createWatchCommand(&quiet))
 
 	return rootCmd
 }
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultSearchLimit caps the results of zamm search when no limit is given
+const defaultSearchLimit = 20
+
+// createSearchCommand creates the command that finds nodes by the words in them
+func (a *App) createSearchCommand(jsonOutput *bool) *cobra.Command {
+	var limit int
+	searchCmd := &cobra.Command{
+		Use:   "search <terms>...",
+		Short: "Find nodes by the words in their titles and content",
+		Long: `Find the nodes whose title or content contains every one of the terms, best matches
+first. Matches are ranked with BM25, which favors words that few nodes use, words that
+appear often in a node, and words in its title. The last term also matches words it is
+the start of, so "zamm search stor" finds storage. Each result shows a snippet of the
+node's content with the matching words highlighted.
+
+To select nodes by type, tag, status or place in the hierarchy instead, use
+zamm spec list --query.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := a.searchService.Search(strings.Join(args, " "), limit)
+			if err != nil {
+				return err
+			}
+
+			if *jsonOutput {
+				return a.outputJSON(results)
+			}
+			a.outputSearchResults(results)
+			return nil
+		},
+	}
+	searchCmd.Flags().IntVar(&limit, "limit", defaultSearchLimit, "Maximum number of results to show, or 0 for all of them")
+
+	return searchCmd
+}
@@ -2,12 +2,16 @@ package cli
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
 )
 
 // createSpecCommand creates the spec management commands
-func (a *App) createSpecCommand(jsonOutput, quiet bool) *cobra.Command {
+func (a *App) createSpecCommand(jsonOutput, quiet *bool) *cobra.Command {
 	specCmd := &cobra.Command{
 		Use:   "spec",
 		Short: "Manage specifications",
@@ -25,11 +29,11 @@ func (a *App) createSpecCommand(jsonOutput, quiet bool) *cobra.Command {
 				return err
 			}
 
-			if jsonOutput {
+			if *jsonOutput {
 				return a.outputJSON(spec)
 			}
 
-			if !quiet {
+			if !*quiet {
 				fmt.Printf("Created spec: %s\n", spec.ID())
 				fmt.Printf("Title: %s\n", spec.Title())
 			}
@@ -42,22 +46,53 @@ func (a *App) createSpecCommand(jsonOutput, quiet bool) *cobra.Command {
 	_ = createCmd.MarkFlagRequired("content")
 
 	// spec list
+	var listStatuses []string
+	var listQuery string
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all nodes",
+		Long: `List every node, or only the nodes that match a query. A query's terms must all
+match, and a term can have several comma-separated values, any of which may match:
+  type:specification      nodes of a type: specification, project or implementation
+  tag:security            nodes with a tag
+  status:approved         nodes with a lifecycle status
+  under:architecture/storage
+                          nodes anywhere below the node with this slug path or ID
+  has:commits             nodes with linked commits, children, parents or tags
+  "free text"             nodes whose title, slug or content contain the text
+
+Put - in front of a term to negate it, for example -has:children to list leaf nodes.`,
+		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			nodes, err := a.specService.ListNodes()
+			statuses, err := parseStatusFilter(listStatuses)
 			if err != nil {
 				return err
 			}
 
-			if jsonOutput {
+			var nodes []models.Node
+			if listQuery != "" {
+				nodes, err = a.queryService.Query(listQuery)
+			} else {
+				nodes, err = a.specService.ListNodes()
+			}
+			if err != nil {
+				return err
+			}
+			if len(statuses) > 0 {
+				nodes = slices.DeleteFunc(nodes, func(node models.Node) bool {
+					return !slices.Contains(statuses, node.Status())
+				})
+			}
+
+			if *jsonOutput {
 				return a.outputJSON(nodes)
 			}
 
 			return a.outputNodeTable(nodes)
 		},
 	}
+	listCmd.Flags().StringSliceVar(&listStatuses, "status", nil, "Only list nodes with these statuses")
+	listCmd.Flags().StringVar(&listQuery, "query", "", "Only list nodes that match this query")
 
 	// spec show
 	showCmd := &cobra.Command{
@@ -70,7 +105,7 @@ func (a *App) createSpecCommand(jsonOutput, quiet bool) *cobra.Command {
 				return err
 			}
 
-			if jsonOutput {
+			if *jsonOutput {
 				return a.outputJSON(spec)
 			}
 
@@ -89,11 +124,11 @@ func (a *App) createSpecCommand(jsonOutput, quiet bool) *cobra.Command {
 				return err
 			}
 
-			if jsonOutput {
+			if *jsonOutput {
 				return a.outputJSON(spec)
 			}
 
-			if !quiet {
+			if !*quiet {
 				fmt.Printf("Updated spec: %s\n", spec.ID())
 			}
 			return nil
@@ -103,22 +138,141 @@ func (a *App) createSpecCommand(jsonOutput, quiet bool) *cobra.Command {
 	updateCmd.Flags().StringVar(&content, "content", "", "New specification content")
 
 	// spec delete
+	var strategy string
+	var dryRun bool
 	deleteCmd := &cobra.Command{
 		Use:   "delete <spec-id>",
 		Short: "Delete a specification",
-		Args:  cobra.ExactArgs(1),
+		Long: `Delete a node along with its rows in node-files.csv, its hierarchy links and its
+commit links, then regenerate the child links of its parents.
+
+A node with children is only deleted when a strategy for them is chosen:
+  refuse    fail if the node has children (default)
+  cascade   also delete every descendant that has no other parent
+  reparent  move the node's children to the node's parents
+
+Use --dry-run to preview everything that would change.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := a.specService.DeleteSpec(args[0]); err != nil {
+			deleteStrategy, err := services.ParseDeleteStrategy(strategy)
+			if err != nil {
 				return err
 			}
 
-			if !quiet {
-				fmt.Printf("Deleted spec: %s\n", args[0])
+			var plan *services.DeletionPlan
+			if dryRun {
+				plan, err = a.specService.PlanDeletion(args[0], deleteStrategy)
+			} else {
+				plan, err = a.specService.DeleteNode(args[0], deleteStrategy)
+			}
+			if err != nil {
+				return err
+			}
+
+			if *jsonOutput {
+				return a.outputJSON(plan)
+			}
+
+			if !*quiet {
+				a.outputDeletionPlan(plan, dryRun)
 			}
 			return nil
 		},
 	}
+	deleteCmd.Flags().StringVar(&strategy, "strategy", string(services.DeleteRefuse), "What to do with the node's children: refuse, cascade or reparent")
+	deleteCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be deleted without deleting anything")
+
+	// spec status
+	statusCmd := &cobra.Command{
+		Use:   "status <spec-id> <status>",
+		Short: "Move a specification to another lifecycle status",
+		Long: `Move a node to another lifecycle status. Nodes are draft until given a status, and move
+through these statuses:
+  draft        proposed or deprecated
+  proposed     approved, back to draft, or deprecated
+  approved     implemented, back to proposed, or deprecated
+  implemented  verified, back to approved, or deprecated
+  verified     back to implemented, or deprecated
+  deprecated   back to draft
 
-	specCmd.AddCommand(createCmd, listCmd, showCmd, updateCmd, deleteCmd)
+A node can only be implemented once a commit is linked to it as implements.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := models.ParseStatus(args[1])
+			if err != nil {
+				return err
+			}
+
+			change, err := a.statusService.SetStatus(args[0], status)
+			if err != nil {
+				return err
+			}
+
+			if *jsonOutput {
+				return a.outputJSON(change)
+			}
+			if !*quiet {
+				if change.From == change.To {
+					fmt.Printf("%s is already %s\n", change.NodeID, change.To)
+				} else {
+					fmt.Printf("Moved %s from %s to %s\n", change.NodeID, change.From, change.To)
+				}
+			}
+			return nil
+		},
+	}
+
+	// spec tag
+	var removeTags bool
+	tagCmd := &cobra.Command{
+		Use:   "tag <spec-id> <tag>...",
+		Short: "Add tags to a specification, or remove them",
+		Long: `Add tags to a node, or remove them with --remove. Tags are free-form labels, such as
+security or performance, for selecting nodes with zamm spec list --query 'tag:security'.
+They cannot contain spaces, commas or quotes.`,
+		Args:         cobra.MinimumNArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var node models.Node
+			var err error
+			if removeTags {
+				node, err = a.specService.TagNode(args[0], nil, args[1:])
+			} else {
+				node, err = a.specService.TagNode(args[0], args[1:], nil)
+			}
+			if err != nil {
+				return err
+			}
+
+			if *jsonOutput {
+				return a.outputJSON(node)
+			}
+			if !*quiet {
+				if len(node.Tags()) == 0 {
+					fmt.Printf("%s has no tags\n", node.ID())
+				} else {
+					fmt.Printf("%s is tagged %s\n", node.ID(), strings.Join(node.Tags(), ", "))
+				}
+			}
+			return nil
+		},
+	}
+	tagCmd.Flags().BoolVar(&removeTags, "remove", false, "Remove the tags instead of adding them")
+
+	specCmd.AddCommand(createCmd, listCmd, showCmd, updateCmd, deleteCmd, statusCmd, tagCmd)
 	return specCmd
 }
+
+// parseStatusFilter validates the values of a --status flag. No values match every status.
+func parseStatusFilter(names []string) ([]models.Status, error) {
+	statuses := make([]models.Status, 0, len(names))
+	for _, name := range names {
+		status, err := models.ParseStatus(name)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// createStorageCommand creates the commands for managing storage backends
+func (a *App) createStorageCommand(quiet *bool) *cobra.Command {
+	storageCmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Manage the storage backend",
+		Long: `Specs are stored either as markdown files with CSV indexes (storage.backend: file, the
+default) or in a SQLite database in the storage directory (storage.backend: sqlite).`,
+	}
+
+	var exportBackend string
+	exportCmd := &cobra.Command{
+		Use:   "export <directory>",
+		Short: "Copy the configured store into a new store of another backend",
+		Long: `Copy every node, link and the root ID of the configured store into the store of the
+given backend in <directory>, which must not hold any nodes yet. The backend defaults
+to whichever one isn't configured. Nodes exported to file storage are written to
+.zamm/nodes; run zamm organize there to move them into docs/.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dst, err := storage.Open(a.otherBackend(exportBackend), args[0])
+			if err != nil {
+				return err
+			}
+			defer closeStorage(dst)
+
+			if err := storage.Copy(dst, a.storage); err != nil {
+				return err
+			}
+			if !*quiet {
+				fmt.Printf("Exported store to %s\n", args[0])
+			}
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVar(&exportBackend, "backend", "", "Backend to export to (file or sqlite)")
+
+	var importBackend string
+	importCmd := &cobra.Command{
+		Use:   "import <directory>",
+		Short: "Copy a store of another backend into the configured store",
+		Long: `Copy every node, link and the root ID of the store of the given backend in <directory>
+into the configured store, which must not hold any nodes yet. The backend defaults
+to whichever one isn't configured.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, err := storage.Open(a.otherBackend(importBackend), args[0])
+			if err != nil {
+				return err
+			}
+			defer closeStorage(src)
+
+			if err := storage.Copy(a.storage, src); err != nil {
+				return err
+			}
+			if !*quiet {
+				fmt.Printf("Imported store from %s\n", args[0])
+			}
+			return nil
+		},
+	}
+	importCmd.Flags().StringVar(&importBackend, "backend", "", "Backend to import from (file or sqlite)")
+
+	migrateCmd := &cobra.Command{
+		Use:          "migrate",
+		Short:        "Bring the SQLite database schema up to date",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sqliteStorage, ok := a.storage.(*storage.SQLiteStorage)
+			if !ok {
+				fmt.Println("File storage has no database schema to migrate")
+				return nil
+			}
+
+			applied, err := sqliteStorage.Migrate()
+			if err != nil {
+				return err
+			}
+			version, err := sqliteStorage.MigrationVersion()
+			if err != nil {
+				return err
+			}
+			if !*quiet {
+				fmt.Printf("Applied %d migration(s), database is at migration %d\n", applied, version)
+			}
+			return nil
+		},
+	}
+
+	storageCmd.AddCommand(exportCmd)
+	storageCmd.AddCommand(importCmd)
+	storageCmd.AddCommand(migrateCmd)
+	return storageCmd
+}
+
+// otherBackend returns the backend to convert to or from: the one given, or else the one
+// that isn't configured
+func (a *App) otherBackend(backend string) string {
+	if backend != "" {
+		return backend
+	}
+	if a.config.Storage.Backend == storage.BackendSQLite {
+		return storage.BackendFile
+	}
+	return storage.BackendSQLite
+}
+
+func closeStorage(store storage.Storage) {
+	if closer, ok := store.(io.Closer); ok {
+		_ = closer.Close() // Explicitly ignore error, nothing is left to flush
+	}
+}
@@ -41,7 +41,7 @@ func (a *App) createInitCommand() *cobra.Command {
 }
 
 // createStatusCommand creates the status command
-func (a *App) createStatusCommand(jsonOutput bool) *cobra.Command {
+func (a *App) createStatusCommand(jsonOutput *bool) *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Show system status and statistics",
@@ -49,7 +49,7 @@ func (a *App) createStatusCommand(jsonOutput bool) *cobra.Command {
 			nodes, err := a.specService.ListNodes()
 			if err != nil {
 				// If storage doesn't exist, show uninitialized status
-				if jsonOutput {
+				if *jsonOutput {
 					status := map[string]interface{}{
 						"config_path":  a.config.Storage.Path,
 						"storage_path": a.config.Storage.Path,
@@ -75,7 +75,7 @@ func (a *App) createStatusCommand(jsonOutput bool) *cobra.Command {
 				"initialized":  true,
 			}
 
-			if jsonOutput {
+			if *jsonOutput {
 				return a.outputJSON(status)
 			}
 
@@ -99,32 +99,55 @@ func (a *App) createVersionCommand() *cobra.Command {
 	}
 }
 
-// createMigrateCommand creates the generic migration command
-func (a *App) createMigrateCommand() *cobra.Command {
-	return &cobra.Command{
+// createMigrateCommand creates the command that brings the store format up to date
+func (a *App) createMigrateCommand(jsonOutput *bool) *cobra.Command {
+	var dryRun bool
+	migrateCmd := &cobra.Command{
 		Use:   "migrate",
-		Short: "Run system migrations for node format and mappings",
+		Short: "Upgrade the store to the format this version of zamm writes",
+		Long: `Apply the migrations the store hasn't had yet, in order, recording the schema version
+each one brings the store to in project_metadata.json. Each migration either applies
+completely or not at all. With --dry-run, list what each pending migration would
+change without changing anything. Stores written by a newer zamm can be read but not
+modified, so upgrade zamm instead.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			migrationsRun := 0
+			report, err := a.migrationService.Up(dryRun)
+			if err != nil {
+				return err
+			}
 
-			// Run title-to-heading migration
-			if err := a.migrateTitlesToHeadings(); err != nil {
+			if *jsonOutput {
+				return a.outputJSON(report)
+			}
+			a.outputMigrationReport(report)
+			return nil
+		},
+	}
+	migrateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the changes pending migrations would make without applying them")
+
+	statusCmd := &cobra.Command{
+		Use:          "status",
+		Short:        "Show the store's schema version and pending migrations",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := a.migrationService.Status()
+			if err != nil {
 				return err
 			}
-			fmt.Printf("[title-to-heading] Migrated node titles to level 1 headings.\n")
-			migrationsRun++
 
-			if migrationsRun == 0 {
-				fmt.Println("All migrations are up to date.")
+			if *jsonOutput {
+				return a.outputJSON(status)
 			}
+			a.outputMigrationStatus(status)
 			return nil
 		},
 	}
-}
+	migrateCmd.AddCommand(statusCmd)
 
-// migrateTitlesToHeadings migrates all node files to use level 1 headings for titles
-func (a *App) migrateTitlesToHeadings() error {
-	return fmt.Errorf("there is currently nothing to migrate")
+	return migrateCmd
 }
 
 // createRedirectCommand creates the redirect command
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// createWatchCommand creates the command that keeps the store in step with hand edits
+func (a *App) createWatchCommand(quiet *bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch",
+		Short: "Watch node files for hand edits and keep child links up to date",
+		Long: `Watch the markdown files of every node, wherever node-files.csv places them, until
+interrupted. When a file is edited by hand, it is re-read and the child links sections
+of its parents are regenerated, so that they show its new title. Files that can't be
+parsed, for instance while an editor is part way through saving them, are reported
+and picked up again on the next save.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fileStorage, ok := a.storage.(*storage.FileStorage)
+			if !ok {
+				return models.NewZammError(models.ErrTypeValidation, "zamm watch needs specs stored as files, which the configured storage backend doesn't do")
+			}
+
+			watcher, err := storage.NewWatcher(fileStorage)
+			if err != nil {
+				return fmt.Errorf("failed to watch spec files: %w", err)
+			}
+			defer func() { _ = watcher.Close() }()
+			watchService := services.NewWatchService(fileStorage, a.specService)
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(sigChan)
+
+			if !*quiet {
+				fmt.Println("Watching node files for changes, press Ctrl+C to stop")
+			}
+			for {
+				select {
+				case change, ok := <-watcher.Changes():
+					if !ok {
+						return nil
+					}
+					result, err := watchService.HandleChange(change)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "zamm: %v\n", err)
+						continue
+					}
+					if !*quiet {
+						a.outputWatchResult(result)
+					}
+				case err := <-watcher.Errors():
+					fmt.Fprintf(os.Stderr, "zamm: %v\n", err)
+				case <-sigChan:
+					return nil
+				}
+			}
+		},
+	}
+}
@@ -17,11 +17,14 @@ type Config struct {
 	Logging LoggingConfig `mapstructure:"logging"`
 	CLI     CLIConfig     `mapstructure:"cli"`
 	LLM     LLMConfig     `mapstructure:"llm"`
+	MCP     MCPConfig     `mapstructure:"mcp"`
 }
 
 // StorageConfig holds storage-related configuration
 type StorageConfig struct {
 	Path string `mapstructure:"path"`
+	// Backend is "file" for markdown files with CSV indexes, or "sqlite"
+	Backend string `mapstructure:"backend"`
 }
 
 // GitConfig holds git-related configuration
@@ -46,6 +49,12 @@ type LLMConfig struct {
 	AnthropicAPIKey string `mapstructure:"anthropic_api_key"`
 }
 
+// MCPConfig holds configuration for the MCP server
+type MCPConfig struct {
+	// AuthToken, when set, must be sent as a bearer token to the HTTP transport
+	AuthToken string `mapstructure:"auth_token"`
+}
+
 // LocalMetadata represents the structure of local-metadata.json
 type LocalMetadata struct {
 	DataRedirect string `json:"data-redirect,omitempty"`
@@ -121,6 +130,8 @@ func Load() (*Config, error) {
 
 	// Bind specific environment variables
 	_ = viper.BindEnv("llm.anthropic_api_key", "ANTHROPIC_API_KEY")
+	_ = viper.BindEnv("mcp.auth_token", "ZAMM_MCP_AUTH_TOKEN")
+	_ = viper.BindEnv("storage.backend", "ZAMM_STORAGE_BACKEND")
 
 	// Handle environment variable overrides
 	if configPath := os.Getenv("ZAMM_CONFIG_PATH"); configPath != "" {
@@ -164,6 +175,7 @@ func Load() (*Config, error) {
 func setDefaults(zammDir string) {
 	// Storage defaults
 	viper.SetDefault("storage.path", zammDir)
+	viper.SetDefault("storage.backend", "file")
 
 	// Git defaults
 	viper.SetDefault("git.default_repo", ".")
@@ -183,6 +195,9 @@ func setDefaults(zammDir string) {
 
 	// LLM defaults
 	viper.SetDefault("llm.anthropic_api_key", "")
+
+	// MCP defaults
+	viper.SetDefault("mcp.auth_token", "")
 }
 
 // expandPaths expands ~ and relative paths in configuration
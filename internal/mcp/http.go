@@ -0,0 +1,255 @@
+package mcp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	sessionIDHeader = "Mcp-Session-Id"
+
+	// shutdownTimeout bounds how long in-flight requests get to finish once the server stops
+	shutdownTimeout = 10 * time.Second
+)
+
+// serveHTTP serves MCP over streamable HTTP, along with a /healthz endpoint, until ctx is cancelled
+func (s *Server) serveHTTP(ctx context.Context, server *mcp.Server, address string) error {
+	sessions := newSessionHandler(server, s.subscriptions)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.Handle("/", requireBearerToken(s.authToken, sessions))
+
+	httpServer := &http.Server{
+		Addr:              address,
+		Handler:           logRequests(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		sessions.closeAll()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down MCP HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Event streams stay open for the life of a session and would keep Shutdown waiting,
+	// so sessions are closed once the requests already being handled have finished
+	drainErr := sessions.drain(shutdownCtx)
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+	if err := <-errChan; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return drainErr
+}
+
+func handleHealthz(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"status":"ok"}` + "\n"))
+}
+
+// requireBearerToken rejects requests without the expected bearer token. An empty
+// token lets every request through.
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		provided, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="zamm"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// logRequests logs the method, path, status and duration of every request
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+		log.Printf("%s %s %d %s", req.Method, req.URL.Path, recorder.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// statusRecorder remembers the status code written to a response. It passes flushes
+// through, since event streams rely on them.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// sessionHandler serves the streamable HTTP transport like mcp.StreamableHTTPHandler,
+// except that each session's transport is wrapped to support resource subscriptions
+type sessionHandler struct {
+	server        *mcp.Server
+	subscriptions *subscriptions
+
+	mu       sync.Mutex
+	sessions map[string]*mcp.StreamableServerTransport
+	draining bool
+	requests sync.WaitGroup // POST requests being handled
+}
+
+func newSessionHandler(server *mcp.Server, subscriptions *subscriptions) *sessionHandler {
+	return &sessionHandler{
+		server:        server,
+		subscriptions: subscriptions,
+		sessions:      make(map[string]*mcp.StreamableServerTransport),
+	}
+}
+
+func (h *sessionHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var jsonOK, streamOK bool
+	for _, accept := range strings.Split(strings.Join(req.Header.Values("Accept"), ","), ",") {
+		switch strings.TrimSpace(accept) {
+		case "application/json":
+			jsonOK = true
+		case "text/event-stream":
+			streamOK = true
+		}
+	}
+	if req.Method == http.MethodGet && !streamOK {
+		http.Error(w, "Accept must contain 'text/event-stream' for GET requests", http.StatusBadRequest)
+		return
+	}
+	if req.Method == http.MethodPost && (!jsonOK || !streamOK) {
+		http.Error(w, "Accept must contain both 'application/json' and 'text/event-stream'", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	if h.draining {
+		h.mu.Unlock()
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if req.Method == http.MethodPost {
+		h.requests.Add(1)
+		defer h.requests.Done()
+	}
+	h.mu.Unlock()
+
+	var session *mcp.StreamableServerTransport
+	if id := req.Header.Get(sessionIDHeader); id != "" {
+		h.mu.Lock()
+		session = h.sessions[id]
+		h.mu.Unlock()
+		if session == nil {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	switch req.Method {
+	case http.MethodDelete:
+		if session == nil {
+			http.Error(w, "DELETE requires an Mcp-Session-Id header", http.StatusBadRequest)
+			return
+		}
+		h.mu.Lock()
+		delete(h.sessions, session.SessionID())
+		h.mu.Unlock()
+		_ = session.Close()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodGet, http.MethodPost:
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if session == nil {
+		session = mcp.NewStreamableServerTransport(uuid.NewString())
+		if _, err := h.server.Connect(req.Context(), h.subscriptions.wrap(session)); err != nil {
+			http.Error(w, "failed connection", http.StatusInternalServerError)
+			return
+		}
+		h.mu.Lock()
+		h.sessions[session.SessionID()] = session
+		h.mu.Unlock()
+	}
+
+	session.ServeHTTP(w, req)
+}
+
+// drain stops accepting requests, waits for POST requests being handled to finish and
+// then closes every session, which ends their event streams
+func (h *sessionHandler) drain(ctx context.Context) error {
+	h.mu.Lock()
+	h.draining = true
+	h.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		h.requests.Wait()
+		close(finished)
+	}()
+
+	var err error
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		err = fmt.Errorf("gave up waiting for in-flight requests: %w", ctx.Err())
+	}
+
+	h.closeAll()
+	return err
+}
+
+func (h *sessionHandler) closeAll() {
+	h.mu.Lock()
+	sessions := h.sessions
+	h.sessions = make(map[string]*mcp.StreamableServerTransport)
+	h.mu.Unlock()
+
+	for _, session := range sessions {
+		_ = session.Close()
+	}
+}
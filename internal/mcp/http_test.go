@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireBearerToken(t *testing.T) {
+	handler := requireBearerToken("secret", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, tc := range []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"MissingHeader", "", http.StatusUnauthorized},
+		{"WrongToken", "Bearer wrong", http.StatusUnauthorized},
+		{"WrongScheme", "Basic secret", http.StatusUnauthorized},
+		{"ValidToken", "Bearer secret", http.StatusNoContent},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.status, recorder.Code)
+		})
+	}
+
+	open := requireBearerToken("", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	recorder := httptest.NewRecorder()
+	open.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code, "an empty token should disable auth")
+}
+
+func TestStatusRecorder(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	status := &statusRecorder{ResponseWriter: recorder, status: http.StatusOK}
+
+	var w http.ResponseWriter = status
+	_, ok := w.(http.Flusher)
+	assert.True(t, ok, "event streams need the recorder to support flushing")
+
+	status.WriteHeader(http.StatusAccepted)
+	status.WriteHeader(http.StatusInternalServerError)
+	assert.Equal(t, http.StatusAccepted, status.status)
+}
+
+func TestHTTPServerGracefulShutdown(t *testing.T) {
+	server, _ := setupTestServer(t)
+	server.RequireBearerToken("secret")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Start(context.Background(), "http", address)
+	}()
+
+	baseURL := "http://" + address
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(baseURL + "/healthz")
+		if err != nil {
+			return false
+		}
+		defer func() { _ = resp.Body.Close() }()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	resp, err := http.Post(baseURL+"/", "application/json", nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Open a session with a long-lived event stream, which must not hold up shutdown
+	_, sessionID := postMessageWithToken(t, baseURL, "secret")
+	require.NotEmpty(t, sessionID)
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(sessionIDHeader, sessionID)
+	streamDone := make(chan int, 1)
+	go func() {
+		// Headers aren't sent until the stream has an event or ends
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			streamDone <- 0
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		streamDone <- resp.StatusCode
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, server.Stop())
+	select {
+	case err := <-errChan:
+		assert.NoError(t, err)
+	case <-time.After(shutdownTimeout):
+		t.Fatal("Start did not return after Stop")
+	}
+
+	select {
+	case status := <-streamDone:
+		assert.Equal(t, http.StatusGone, status, "the event stream should end with its session")
+	case <-time.After(time.Second):
+		t.Fatal("event stream was not closed")
+	}
+
+	_, err = http.Get(baseURL + "/healthz")
+	assert.Error(t, err, "server should no longer accept connections")
+}
+
+func TestStopWithoutStart(t *testing.T) {
+	server, _ := setupTestServer(t)
+	assert.NoError(t, server.Stop())
+}
+
+// postMessageWithToken initializes a session through the bearer token check
+func postMessageWithToken(t *testing.T, url, token string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test-client","version":"1.0"}}}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, resp.Header.Get(sessionIDHeader)
+}
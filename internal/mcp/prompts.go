@@ -0,0 +1,181 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+var specIDArgument = &mcp.PromptArgument{
+	Name:        "spec_id",
+	Title:       "Spec ID",
+	Description: "ID of the spec to work from",
+	Required:    true,
+}
+
+// addPrompts registers the spec-driven workflow prompts on the MCP server
+func (s *Server) addPrompts(server *mcp.Server) {
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "implement_spec",
+		Description: "Implement a spec, given its content, the content of its ancestors and the commits already linked to it",
+		Arguments:   []*mcp.PromptArgument{specIDArgument},
+	}, s.ImplementSpecPrompt)
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "write_tests_for_spec",
+		Description: "Write tests covering the behavior a spec describes",
+		Arguments:   []*mcp.PromptArgument{specIDArgument},
+	}, s.WriteTestsForSpecPrompt)
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "review_commit_against_spec",
+		Description: "Review a commit for whether it does what a spec asks for",
+		Arguments: []*mcp.PromptArgument{
+			{
+				Name:        "commit",
+				Title:       "Commit",
+				Description: "Hash or other git revision of the commit to review",
+				Required:    true,
+			},
+			specIDArgument,
+		},
+	}, s.ReviewCommitAgainstSpecPrompt)
+}
+
+func (s *Server) ImplementSpecPrompt(ctx context.Context, ss *mcp.ServerSession, params *mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+	spec, specContext, err := s.specPromptContext(params.Arguments["spec_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Implement the specification below in this repository. ")
+	sb.WriteString("The parent specifications give the context it fits into, and any linked commits show work already done towards it, so build on them rather than starting over.\n\n")
+	sb.WriteString(specContext)
+	fmt.Fprintf(&sb, "\nOnce your change is committed, link the commit to the spec with the link_commit tool, using spec ID `%s` and the label \"implements\".\n", spec.ID())
+
+	return promptResult(fmt.Sprintf("Implement spec %q", spec.Title()), sb.String()), nil
+}
+
+func (s *Server) WriteTestsForSpecPrompt(ctx context.Context, ss *mcp.ServerSession, params *mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+	spec, specContext, err := s.specPromptContext(params.Arguments["spec_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Write tests that verify the behavior described by the specification below. ")
+	sb.WriteString("Use the linked commits to find the code that implements it, follow the testing conventions already used in this repository, ")
+	sb.WriteString("and cover every requirement the spec states, including edge cases and error conditions it mentions.\n\n")
+	sb.WriteString(specContext)
+
+	return promptResult(fmt.Sprintf("Write tests for spec %q", spec.Title()), sb.String()), nil
+}
+
+func (s *Server) ReviewCommitAgainstSpecPrompt(ctx context.Context, ss *mcp.ServerSession, params *mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+	commit := strings.TrimSpace(params.Arguments["commit"])
+	if commit == "" {
+		return nil, models.NewZammError(models.ErrTypeValidation, "commit is required")
+	}
+
+	spec, specContext, err := s.specPromptContext(params.Arguments["spec_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Review commit `%s` against the specification below. ", commit)
+	sb.WriteString("Inspect the commit's changes with git, then report:\n\n")
+	sb.WriteString("1. Requirements of the spec that the commit implements correctly\n")
+	sb.WriteString("2. Requirements it misses or gets wrong\n")
+	sb.WriteString("3. Changes that go beyond what the spec asks for\n\n")
+	sb.WriteString(specContext)
+
+	return promptResult(fmt.Sprintf("Review commit %s against spec %q", commit, spec.Title()), sb.String()), nil
+}
+
+// specPromptContext renders a spec together with its ancestors and linked commits as markdown
+func (s *Server) specPromptContext(specID string) (models.Node, string, error) {
+	if specID == "" {
+		return nil, "", models.NewZammError(models.ErrTypeValidation, "spec_id is required")
+	}
+
+	spec, err := s.specService.ReadNode(specID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ancestors, err := s.ancestors(spec.ID())
+	if err != nil {
+		return nil, "", err
+	}
+
+	commits, err := s.linkService.GetCommitsForSpec(spec.ID())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get commits for spec %s: %w", spec.ID(), err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Specification: %s\n\nID: `%s`\n\n%s\n", spec.Title(), spec.ID(), strings.TrimSpace(spec.Content()))
+
+	if len(ancestors) > 0 {
+		sb.WriteString("\n## Parent specifications\n\nFrom the closest parent up to the root:\n")
+		for _, ancestor := range ancestors {
+			fmt.Fprintf(&sb, "\n### %s\n\nID: `%s`\n", ancestor.Title(), ancestor.ID())
+			if content := strings.TrimSpace(ancestor.Content()); content != "" {
+				fmt.Fprintf(&sb, "\n%s\n", content)
+			}
+		}
+	}
+
+	sb.WriteString("\n## Linked commits\n\n")
+	if len(commits) == 0 {
+		sb.WriteString("No commits are linked to this spec yet.\n")
+	}
+	for _, link := range commits {
+		fmt.Fprintf(&sb, "- `%s` (%s) in %s\n", link.CommitID, link.LinkLabel, link.RepoPath)
+	}
+
+	return spec, sb.String(), nil
+}
+
+// ancestors returns every node above the given one, breadth-first so that closer
+// ancestors come first. Nodes reachable through several parents are listed once.
+func (s *Server) ancestors(nodeID string) ([]models.Node, error) {
+	var ancestors []models.Node
+	visited := map[string]bool{nodeID: true}
+	queue := []string{nodeID}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		parents, err := s.specService.GetParents(current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parents for node %s: %w", current, err)
+		}
+		for _, parent := range parents {
+			if visited[parent.ID()] {
+				continue
+			}
+			visited[parent.ID()] = true
+			ancestors = append(ancestors, parent)
+			queue = append(queue, parent.ID())
+		}
+	}
+
+	return ancestors, nil
+}
+
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages: []*mcp.PromptMessage{
+			{
+				Role:    "user",
+				Content: &mcp.TextContent{Text: text},
+			},
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecPrompts(t *testing.T) {
+	server, specService := setupTestServer(t)
+	ctx := context.Background()
+
+	root, err := specService.GetRootNode()
+	require.NoError(t, err)
+	parent, err := specService.CreateSpec("Storage Layer", "Specs are persisted as markdown files")
+	require.NoError(t, err)
+	_, err = specService.AddChildToParent(parent.ID(), root.ID(), "child")
+	require.NoError(t, err)
+	spec, err := specService.CreateSpec("Atomic Writes", "Writes must never leave a partial file behind")
+	require.NoError(t, err)
+	_, err = specService.AddChildToParent(spec.ID(), parent.ID(), "child")
+	require.NoError(t, err)
+
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	mcpServer, err := server.newMCPServer()
+	require.NoError(t, err)
+	_, err = mcpServer.Connect(ctx, serverTransport)
+	require.NoError(t, err)
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, nil)
+	session, err := client.Connect(ctx, clientTransport)
+	require.NoError(t, err)
+	defer func() { _ = session.Close() }()
+
+	prompts, err := session.ListPrompts(ctx, nil)
+	require.NoError(t, err)
+	var names []string
+	for _, prompt := range prompts.Prompts {
+		names = append(names, prompt.Name)
+	}
+	assert.ElementsMatch(t, []string{"implement_spec", "write_tests_for_spec", "review_commit_against_spec"}, names)
+
+	result, err := session.GetPrompt(ctx, &mcp.GetPromptParams{
+		Name:      "implement_spec",
+		Arguments: map[string]string{"spec_id": spec.ID()},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Messages, 1)
+	text, ok := result.Messages[0].Content.(*mcp.TextContent)
+	require.True(t, ok, "Expected TextContent")
+	assert.Contains(t, text.Text, "Writes must never leave a partial file behind")
+	assert.Contains(t, text.Text, "Specs are persisted as markdown files")
+	assert.Contains(t, text.Text, "No commits are linked to this spec yet.")
+	assert.Less(t, strings.Index(text.Text, "### Storage Layer"), strings.Index(text.Text, "### "+root.Title()),
+		"closer ancestors should come first")
+
+	_, err = session.GetPrompt(ctx, &mcp.GetPromptParams{
+		Name:      "review_commit_against_spec",
+		Arguments: map[string]string{"spec_id": spec.ID()},
+	})
+	assert.Error(t, err, "commit should be required")
+
+	_, err = session.GetPrompt(ctx, &mcp.GetPromptParams{
+		Name:      "write_tests_for_spec",
+		Arguments: map[string]string{"spec_id": "nonexistent-id"},
+	})
+	assert.Error(t, err)
+}
+
+func TestReviewCommitPromptIncludesLinkedCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "Initial commit"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	server, specService := setupTestServer(t)
+	spec, err := specService.CreateSpec("Linked Spec", "Linked content")
+	require.NoError(t, err)
+	link, err := server.linkService.LinkSpecToCommit(spec.ID(), "HEAD", repoDir, "implements")
+	require.NoError(t, err)
+
+	result, err := server.ReviewCommitAgainstSpecPrompt(context.Background(), nil, &mcp.GetPromptParams{
+		Name:      "review_commit_against_spec",
+		Arguments: map[string]string{"commit": "abc123", "spec_id": spec.ID()},
+	})
+	require.NoError(t, err)
+	text := result.Messages[0].Content.(*mcp.TextContent).Text
+	assert.Contains(t, text, "Review commit `abc123`")
+	assert.Contains(t, text, "`"+link.CommitID+"` (implements)")
+}
@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+const (
+	nodeURIPrefix    = "zamm://node/"
+	slugURIPrefix    = "zamm://slug/"
+	markdownMIMEType = "text/markdown"
+)
+
+func nodeURI(nodeID string) string {
+	return nodeURIPrefix + nodeID
+}
+
+func slugURI(slugPath string) string {
+	return slugURIPrefix + slugPath
+}
+
+// addResources registers every node as a resource, along with templates for looking
+// nodes up by ID or by slug path
+func (s *Server) addResources(server *mcp.Server) error {
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "node",
+		Title:       "Node by ID",
+		URITemplate: nodeURIPrefix + "{id}",
+		Description: "A spec node's title and content as markdown",
+		MIMEType:    markdownMIMEType,
+	}, s.ReadNodeResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "node-by-slug",
+		Title:       "Node by slug path",
+		URITemplate: slugURIPrefix + "{+path}",
+		Description: "A spec node looked up by the slugs leading to it from the root, e.g. zamm://slug/storage/file-format",
+		MIMEType:    markdownMIMEType,
+	}, s.ReadNodeResource)
+
+	return s.syncResources(server)
+}
+
+// syncResources brings the list of node resources in line with the nodes in storage.
+// Only resources that were added, renamed or removed are touched, since each change
+// notifies connected clients that the resource list changed.
+func (s *Server) syncResources(server *mcp.Server) error {
+	nodes, err := s.specService.ListNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	s.resourcesMu.Lock()
+	defer s.resourcesMu.Unlock()
+
+	current := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		uri := nodeURI(node.ID())
+		current[uri] = node.Title()
+		if title, ok := s.resourceTitles[uri]; ok && title == node.Title() {
+			continue
+		}
+
+		server.AddResource(&mcp.Resource{
+			URI:         uri,
+			Name:        node.ID(),
+			Title:       node.Title(),
+			Description: fmt.Sprintf("%s node %q", node.Type(), node.Title()),
+			MIMEType:    markdownMIMEType,
+		}, s.ReadNodeResource)
+	}
+
+	var removed []string
+	for uri := range s.resourceTitles {
+		if _, ok := current[uri]; !ok {
+			removed = append(removed, uri)
+		}
+	}
+	if len(removed) > 0 {
+		server.RemoveResources(removed...)
+	}
+
+	s.resourceTitles = current
+	return nil
+}
+
+// ReadNodeResource returns the markdown body of the node a zamm:// URI refers to
+func (s *Server) ReadNodeResource(ctx context.Context, ss *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+	node, err := s.resolveNodeURI(params.URI)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(params.URI)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{
+				URI:      params.URI,
+				MIMEType: markdownMIMEType,
+				Text:     nodeMarkdown(node),
+			},
+		},
+	}, nil
+}
+
+func (s *Server) resolveNodeURI(uri string) (models.Node, error) {
+	if nodeID, ok := strings.CutPrefix(uri, nodeURIPrefix); ok {
+		return s.specService.ReadNode(nodeID)
+	}
+	if slugPath, ok := strings.CutPrefix(uri, slugURIPrefix); ok {
+		slugPath, err := url.PathUnescape(slugPath)
+		if err != nil {
+			return nil, err
+		}
+		return s.specService.FindNodeBySlugPath(slugPath)
+	}
+	return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("unsupported resource URI: %s", uri))
+}
+
+// nodeURIs returns every URI under which a node is published
+func (s *Server) nodeURIs(node models.Node) []string {
+	uris := []string{nodeURI(node.ID())}
+	slugPath, err := s.specService.GetNodeSlugPath(node)
+	if err != nil || slugPath == "" {
+		return uris
+	}
+	// Orphaned nodes and nodes whose slug collides with a sibling's can't be reached by slug
+	if found, err := s.specService.FindNodeBySlugPath(slugPath); err == nil && found.ID() == node.ID() {
+		uris = append(uris, slugURI(slugPath))
+	}
+	return uris
+}
+
+// forwardChanges notifies subscribed clients about changed nodes until the watcher is closed
+func (s *Server) forwardChanges(ctx context.Context, server *mcp.Server, watcher *storage.Watcher) {
+	for change := range watcher.Changes() {
+		switch change.Kind {
+		case storage.NodeFileChanged:
+			node, err := s.specService.ReadNode(change.NodeID)
+			if err != nil {
+				// The node was deleted, so subscribers should re-read it and it should leave the list
+				s.subscriptions.notifyUpdated(ctx, nodeURI(change.NodeID))
+				_ = s.syncResources(server)
+				continue
+			}
+			for _, uri := range s.nodeURIs(node) {
+				s.subscriptions.notifyUpdated(ctx, uri)
+			}
+			_ = s.syncResources(server)
+		case storage.MetadataChanged:
+			// New nodes, moves and hierarchy changes affect the resource list and slug paths
+			_ = s.syncResources(server)
+		}
+	}
+}
+
+func nodeMarkdown(node models.Node) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", node.Title())
+	if content := strings.TrimSpace(node.Content()); content != "" {
+		fmt.Fprintf(&sb, "\n%s\n", content)
+	}
+	return sb.String()
+}
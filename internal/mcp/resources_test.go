@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+)
+
+func TestNodeResources(t *testing.T) {
+	server, specService := setupTestServer(t)
+	ctx := context.Background()
+
+	root, err := specService.GetRootNode()
+	require.NoError(t, err)
+	parent, err := specService.CreateSpec("Storage Layer", "How specs are stored")
+	require.NoError(t, err)
+	_, err = specService.AddChildToParent(parent.ID(), root.ID(), "child")
+	require.NoError(t, err)
+	child, err := specService.CreateSpec("File Format", "Markdown with frontmatter")
+	require.NoError(t, err)
+	_, err = specService.AddChildToParent(child.ID(), parent.ID(), "child")
+	require.NoError(t, err)
+
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	mcpServer, err := server.newMCPServer()
+	require.NoError(t, err)
+	_, err = mcpServer.Connect(ctx, serverTransport)
+	require.NoError(t, err)
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, nil)
+	session, err := client.Connect(ctx, clientTransport)
+	require.NoError(t, err)
+	defer func() { _ = session.Close() }()
+
+	resources, err := session.ListResources(ctx, nil)
+	require.NoError(t, err)
+	uris := make(map[string]string)
+	for _, resource := range resources.Resources {
+		uris[resource.URI] = resource.Title
+	}
+	assert.Equal(t, "Storage Layer", uris[nodeURI(parent.ID())])
+	assert.Equal(t, "File Format", uris[nodeURI(child.ID())])
+
+	byID, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: nodeURI(child.ID())})
+	require.NoError(t, err)
+	require.Len(t, byID.Contents, 1)
+	assert.Equal(t, "# File Format\n\nMarkdown with frontmatter\n", byID.Contents[0].Text)
+
+	bySlug, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: slugURI("storage-layer/file-format")})
+	require.NoError(t, err)
+	require.Len(t, bySlug.Contents, 1)
+	assert.Equal(t, byID.Contents[0].Text, bySlug.Contents[0].Text)
+
+	_, err = session.ReadResource(ctx, &mcp.ReadResourceParams{URI: slugURI("storage-layer/missing")})
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{nodeURI(child.ID()), slugURI("storage-layer/file-format")}, server.nodeURIs(child))
+}
+
+func TestSyncResources(t *testing.T) {
+	server, specService := setupTestServer(t)
+
+	mcpServer, err := server.newMCPServer()
+	require.NoError(t, err)
+
+	spec, err := specService.CreateSpec("Original Title", "Content")
+	require.NoError(t, err)
+	require.NoError(t, server.syncResources(mcpServer))
+	assert.Equal(t, "Original Title", server.resourceTitles[nodeURI(spec.ID())])
+
+	_, err = specService.UpdateSpec(spec.ID(), "Renamed Title", "Content")
+	require.NoError(t, err)
+	require.NoError(t, server.syncResources(mcpServer))
+	assert.Equal(t, "Renamed Title", server.resourceTitles[nodeURI(spec.ID())])
+
+	_, err = specService.DeleteNode(spec.ID(), services.DeleteRefuse)
+	require.NoError(t, err)
+	require.NoError(t, server.syncResources(mcpServer))
+	assert.NotContains(t, server.resourceTitles, nodeURI(spec.ID()))
+}
+
+func TestResourceSubscriptionsOverHTTP(t *testing.T) {
+	server, specService := setupTestServer(t)
+
+	spec, err := specService.CreateSpec("Watched Spec", "Content")
+	require.NoError(t, err)
+
+	mcpServer, err := server.newMCPServer()
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(newSessionHandler(mcpServer, server.subscriptions))
+	defer httpServer.Close()
+
+	initialize, sessionID := postMessage(t, httpServer.URL, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test-client","version":"1.0"}}}`)
+	require.NotEmpty(t, sessionID)
+	var initResult struct {
+		Capabilities struct {
+			Resources struct {
+				Subscribe bool `json:"subscribe"`
+			} `json:"resources"`
+		} `json:"capabilities"`
+	}
+	require.NoError(t, json.Unmarshal(initialize["result"], &initResult))
+	assert.True(t, initResult.Capabilities.Resources.Subscribe, "subscribe capability should be advertised")
+
+	postMessage(t, httpServer.URL, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized","params":{}}`)
+
+	uri := nodeURI(spec.ID())
+	subscribe, _ := postMessage(t, httpServer.URL, sessionID, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"`+uri+`"}}`)
+	assert.JSONEq(t, `{}`, string(subscribe["result"]))
+
+	server.subscriptions.notifyUpdated(context.Background(), nodeURI("other-node"))
+	server.subscriptions.notifyUpdated(context.Background(), uri)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(sessionIDHeader, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	notification := readEvent(t, bufio.NewScanner(resp.Body))
+	assert.JSONEq(t, `"notifications/resources/updated"`, string(notification["method"]))
+	assert.JSONEq(t, `{"uri":"`+uri+`"}`, string(notification["params"]))
+}
+
+// postMessage posts a JSON-RPC message and returns the first message in the response
+// stream, if any, along with the session ID
+func postMessage(t *testing.T, url, sessionID, body string) (map[string]json.RawMessage, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusAccepted {
+		return nil, resp.Header.Get(sessionIDHeader)
+	}
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return readEvent(t, bufio.NewScanner(resp.Body)), resp.Header.Get(sessionIDHeader)
+}
+
+// readEvent reads the next server-sent event and decodes its JSON-RPC message
+func readEvent(t *testing.T, scanner *bufio.Scanner) map[string]json.RawMessage {
+	t.Helper()
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var msg map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal([]byte(data), &msg))
+		return msg
+	}
+	require.NoError(t, scanner.Err())
+	t.Fatal("event stream ended without a message")
+	return nil
+}
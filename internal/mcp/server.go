@@ -2,14 +2,15 @@ package mcp
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"sync"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
 )
 
 type CreateChildSpecArgs struct {
@@ -27,100 +28,134 @@ type CreateChildSpecResult struct {
 }
 
 type Server struct {
-	specService services.SpecService
-	mcpServer   *mcp.Server
+	specService   services.SpecService
+	linkService   services.LinkService
+	queryService  services.QueryService
+	mcpServer     *mcp.Server
+	watcher       *storage.Watcher
+	subscriptions *subscriptions
+	authToken     string
+
+	resourcesMu    sync.Mutex
+	resourceTitles map[string]string // node resource URI -> title
+
+	// runMu guards the state of a running server, which Stop uses to shut it down
+	runMu   sync.Mutex
+	cancel  context.CancelFunc
+	stopped chan struct{}
 }
 
-func NewServer(specService services.SpecService) *Server {
+func NewServer(specService services.SpecService, linkService services.LinkService, queryService services.QueryService) *Server {
 	return &Server{
-		specService: specService,
+		specService:    specService,
+		linkService:    linkService,
+		queryService:   queryService,
+		subscriptions:  newSubscriptions(),
+		resourceTitles: make(map[string]string),
 	}
 }
 
+// WatchChanges makes the server notify clients subscribed to node resources whenever
+// the watcher reports that a node's file changed
+func (s *Server) WatchChanges(watcher *storage.Watcher) {
+	s.watcher = watcher
+}
+
+// RequireBearerToken makes the HTTP transport reject MCP requests that don't carry
+// the given token in an "Authorization: Bearer" header. An empty token disables auth.
+func (s *Server) RequireBearerToken(token string) {
+	s.authToken = token
+}
+
 func (s *Server) CreateChildSpec(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[CreateChildSpecArgs]) (*mcp.CallToolResultFor[CreateChildSpecResult], error) {
 	args := params.Arguments
 
 	parentNode, err := s.specService.ReadNode(args.ParentID)
 	if err != nil {
-		return &mcp.CallToolResultFor[CreateChildSpecResult]{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Error: Parent spec with ID '%s' not found: %v", args.ParentID, err)},
-			},
-		}, nil
+		return toolError[CreateChildSpecResult]("Error: Parent spec with ID '%s' not found: %v", args.ParentID, err)
 	}
 
 	childSpec, err := s.specService.CreateSpec(args.Title, args.Content)
 	if err != nil {
-		return &mcp.CallToolResultFor[CreateChildSpecResult]{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Error creating child spec: %v", err)},
-			},
-		}, nil
+		return toolError[CreateChildSpecResult]("Error creating child spec: %v", err)
 	}
 
 	_, err = s.specService.AddChildToParent(childSpec.ID(), args.ParentID, "child")
 	if err != nil {
-		return &mcp.CallToolResultFor[CreateChildSpecResult]{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Error linking child to parent: %v", err)},
-			},
-		}, nil
+		return toolError[CreateChildSpecResult]("Error linking child to parent: %v", err)
 	}
 
-	result := CreateChildSpecResult{
+	return toolResult(CreateChildSpecResult{
 		ChildID:  childSpec.ID(),
 		ParentID: args.ParentID,
 		Title:    childSpec.Title(),
 		Content:  childSpec.Content(),
 		Message:  fmt.Sprintf("Successfully created child spec '%s' under parent '%s'", childSpec.Title(), parentNode.Title()),
+	})
+}
+
+// newMCPServer creates the MCP server with every zamm tool, prompt and resource registered
+func (s *Server) newMCPServer() (*mcp.Server, error) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "zamm-spec-server"}, nil)
+	s.addTools(server)
+	s.addPrompts(server)
+	if err := s.addResources(server); err != nil {
+		return nil, err
 	}
+	return server, nil
+}
 
-	resultJSON, err := json.Marshal(result)
+// Start runs the server on the given transport until ctx is cancelled, Stop is called
+// or the transport fails. The HTTP transport shuts down gracefully, letting in-flight
+// requests finish before closing sessions.
+func (s *Server) Start(ctx context.Context, transport string, address string) error {
+	server, err := s.newMCPServer()
 	if err != nil {
-		return &mcp.CallToolResultFor[CreateChildSpecResult]{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Error marshaling result: %v", err)},
-			},
-		}, nil
+		return err
 	}
+	s.mcpServer = server
 
-	return &mcp.CallToolResultFor[CreateChildSpecResult]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: string(resultJSON)},
-		},
-	}, nil
-}
-
-func (s *Server) Start(transport string, address string) error {
-	server := mcp.NewServer(&mcp.Implementation{Name: "zamm-spec-server"}, nil)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopped := make(chan struct{})
+	defer close(stopped)
 
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "create_child_spec",
-		Description: "Create a new specification as a child of an existing specification",
-	}, s.CreateChildSpec)
+	s.runMu.Lock()
+	s.cancel = cancel
+	s.stopped = stopped
+	s.runMu.Unlock()
 
-	s.mcpServer = server
+	if s.watcher != nil {
+		go s.forwardChanges(ctx, server, s.watcher)
+	}
 
 	switch transport {
 	case "stdio":
 		log.Println("Starting MCP server with stdio transport")
 		stdioTransport := mcp.NewStdioTransport()
 		loggingTransport := mcp.NewLoggingTransport(stdioTransport, os.Stderr)
-		if err := server.Run(context.Background(), loggingTransport); err != nil {
+		if err := server.Run(ctx, s.subscriptions.wrap(loggingTransport)); err != nil && !errors.Is(err, context.Canceled) {
 			return fmt.Errorf("server failed: %v", err)
 		}
 		return nil
 	case "http":
 		log.Printf("Starting MCP server with HTTP transport on %s", address)
-		handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
-			return server
-		}, nil)
-		return http.ListenAndServe(address, handler)
+		return s.serveHTTP(ctx, server, address)
 	default:
 		return fmt.Errorf("unsupported transport type: %s", transport)
 	}
 }
 
+// Stop shuts down a running server and waits for Start to return
 func (s *Server) Stop() error {
+	s.runMu.Lock()
+	cancel, stopped := s.cancel, s.stopped
+	s.runMu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-stopped
 	return nil
 }
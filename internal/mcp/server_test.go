@@ -33,7 +33,7 @@ func setupTestStorage(t *testing.T) (storage.Storage, string) {
 func TestCreateChildSpec_Success(t *testing.T) {
 	store, _ := setupTestStorage(t)
 	specService := services.NewSpecService(store)
-	server := NewServer(specService)
+	server := NewServer(specService, services.NewLinkService(store, services.NewGitService()), services.NewQueryService(store, specService))
 
 	parentSpec, err := specService.CreateSpec("Parent Spec", "Parent content")
 	require.NoError(t, err)
@@ -94,7 +94,7 @@ func TestCreateChildSpec_Success(t *testing.T) {
 func TestCreateChildSpec_InvalidParentID(t *testing.T) {
 	store, _ := setupTestStorage(t)
 	specService := services.NewSpecService(store)
-	server := NewServer(specService)
+	server := NewServer(specService, services.NewLinkService(store, services.NewGitService()), services.NewQueryService(store, specService))
 
 	args := CreateChildSpecArgs{
 		ParentID: "nonexistent-id",
@@ -123,7 +123,7 @@ func TestCreateChildSpec_InvalidParentID(t *testing.T) {
 func TestCreateChildSpec_EmptyTitle(t *testing.T) {
 	store, _ := setupTestStorage(t)
 	specService := services.NewSpecService(store)
-	server := NewServer(specService)
+	server := NewServer(specService, services.NewLinkService(store, services.NewGitService()), services.NewQueryService(store, specService))
 
 	parentSpec, err := specService.CreateSpec("Parent Spec", "Parent content")
 	require.NoError(t, err)
@@ -156,7 +156,7 @@ func TestNewServer(t *testing.T) {
 	store, _ := setupTestStorage(t)
 	specService := services.NewSpecService(store)
 
-	server := NewServer(specService)
+	server := NewServer(specService, services.NewLinkService(store, services.NewGitService()), services.NewQueryService(store, specService))
 
 	assert.NotNil(t, server)
 	assert.Equal(t, specService, server.specService)
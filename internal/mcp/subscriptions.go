@@ -0,0 +1,209 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	methodInitialize            = "initialize"
+	methodSubscribe             = "resources/subscribe"
+	methodUnsubscribe           = "resources/unsubscribe"
+	notificationResourceUpdated = "notifications/resources/updated"
+)
+
+// subscriptions implements resource subscriptions, which the MCP SDK does not support
+// yet. Each connection is wrapped so that resources/subscribe and resources/unsubscribe
+// requests are answered before they reach the SDK, the subscribe capability is
+// advertised in the initialize response, and resource-updated notifications are written
+// directly to subscribed connections.
+type subscriptions struct {
+	mu    sync.Mutex
+	conns map[*subscriptionConn]struct{}
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		conns: make(map[*subscriptionConn]struct{}),
+	}
+}
+
+// wrap returns a transport whose connections take part in resource subscriptions
+func (s *subscriptions) wrap(transport mcp.Transport) mcp.Transport {
+	return &subscriptionTransport{transport: transport, subscriptions: s}
+}
+
+// notifyUpdated sends a resource-updated notification to every connection subscribed to uri
+func (s *subscriptions) notifyUpdated(ctx context.Context, uri string) {
+	s.mu.Lock()
+	conns := make([]*subscriptionConn, 0, len(s.conns))
+	for conn := range s.conns {
+		if conn.isSubscribed(uri) {
+			conns = append(conns, conn)
+		}
+	}
+	s.mu.Unlock()
+
+	params, err := json.Marshal(map[string]string{"uri": uri})
+	if err != nil {
+		return
+	}
+	for _, conn := range conns {
+		// A connection that fails to write is closing; the SDK will notice on its own
+		_ = conn.Write(ctx, &jsonrpc.Request{Method: notificationResourceUpdated, Params: params})
+	}
+}
+
+func (s *subscriptions) add(conn *subscriptionConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns[conn] = struct{}{}
+}
+
+func (s *subscriptions) remove(conn *subscriptionConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+}
+
+type subscriptionTransport struct {
+	transport     mcp.Transport
+	subscriptions *subscriptions
+}
+
+func (t *subscriptionTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := &subscriptionConn{
+		Connection:    conn,
+		subscriptions: t.subscriptions,
+		uris:          make(map[string]bool),
+	}
+	t.subscriptions.add(wrapped)
+	return wrapped, nil
+}
+
+// subscriptionConn is a connection that handles subscription requests itself
+type subscriptionConn struct {
+	mcp.Connection
+	subscriptions *subscriptions
+
+	// writeMu serializes our own writes with the SDK's
+	writeMu sync.Mutex
+
+	mu           sync.Mutex
+	uris         map[string]bool
+	initializeID jsonrpc.ID
+}
+
+func (c *subscriptionConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	for {
+		msg, err := c.Connection.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		req, ok := msg.(*jsonrpc.Request)
+		if !ok {
+			return msg, nil
+		}
+
+		switch req.Method {
+		case methodInitialize:
+			c.mu.Lock()
+			c.initializeID = req.ID
+			c.mu.Unlock()
+			return msg, nil
+		case methodSubscribe, methodUnsubscribe:
+			if err := c.handleSubscription(ctx, req); err != nil {
+				return nil, err
+			}
+		default:
+			return msg, nil
+		}
+	}
+}
+
+func (c *subscriptionConn) Write(ctx context.Context, msg jsonrpc.Message) error {
+	if resp, ok := msg.(*jsonrpc.Response); ok && resp.Error == nil {
+		c.mu.Lock()
+		isInitialize := c.initializeID.IsValid() && resp.ID == c.initializeID
+		c.mu.Unlock()
+		if isInitialize {
+			if result, err := advertiseSubscribe(resp.Result); err == nil {
+				resp = &jsonrpc.Response{ID: resp.ID, Result: result}
+				msg = resp
+			}
+		}
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.Connection.Write(ctx, msg)
+}
+
+func (c *subscriptionConn) Close() error {
+	c.subscriptions.remove(c)
+	return c.Connection.Close()
+}
+
+func (c *subscriptionConn) isSubscribed(uri string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.uris[uri]
+}
+
+// handleSubscription records a (un)subscribe request and writes its response
+func (c *subscriptionConn) handleSubscription(ctx context.Context, req *jsonrpc.Request) error {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	var result json.RawMessage
+	var respErr error
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		respErr = fmt.Errorf("invalid %s params: uri is required", req.Method)
+	} else {
+		c.mu.Lock()
+		if req.Method == methodSubscribe {
+			c.uris[params.URI] = true
+		} else {
+			delete(c.uris, params.URI)
+		}
+		c.mu.Unlock()
+		result = json.RawMessage("{}")
+	}
+
+	if !req.IsCall() {
+		return nil
+	}
+	return c.Write(ctx, &jsonrpc.Response{ID: req.ID, Result: result, Error: respErr})
+}
+
+// advertiseSubscribe sets capabilities.resources.subscribe in an initialize result
+func advertiseSubscribe(result json.RawMessage) (json.RawMessage, error) {
+	var decoded map[string]any
+	if err := json.Unmarshal(result, &decoded); err != nil {
+		return nil, err
+	}
+
+	capabilities, ok := decoded["capabilities"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("initialize result has no capabilities")
+	}
+	resources, ok := capabilities["resources"].(map[string]any)
+	if !ok {
+		resources = make(map[string]any)
+		capabilities["resources"] = resources
+	}
+	resources["subscribe"] = true
+
+	return json.Marshal(decoded)
+}
@@ -0,0 +1,394 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// NodeSummary identifies a node in listings
+type NodeSummary struct {
+	ID     string   `json:"id"`
+	Type   string   `json:"type"`
+	Title  string   `json:"title"`
+	Slug   string   `json:"slug,omitempty"`
+	Status string   `json:"status"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// NodeDetail is a node along with its full content
+type NodeDetail struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Slug    string `json:"slug,omitempty"`
+	Content string `json:"content"`
+	IsRoot  bool   `json:"is_root"`
+}
+
+// CommitLink is a commit linked to a spec
+type CommitLink struct {
+	CommitID  string `json:"commit_id"`
+	RepoPath  string `json:"repo_path"`
+	LinkLabel string `json:"link_label"`
+}
+
+type GetNodeArgs struct {
+	ID string `json:"id" jsonschema:"ID of the node to read"`
+}
+
+type GetNodeResult struct {
+	Node NodeDetail `json:"node"`
+}
+
+type GetRootArgs struct{}
+
+type ListChildrenArgs struct {
+	ID string `json:"id" jsonschema:"ID of the node whose children to list"`
+}
+
+type ListChildrenResult struct {
+	ParentID string        `json:"parent_id"`
+	Children []NodeSummary `json:"children"`
+}
+
+type GetParentsArgs struct {
+	ID string `json:"id" jsonschema:"ID of the node whose parents to list"`
+}
+
+type GetParentsResult struct {
+	NodeID  string        `json:"node_id"`
+	Parents []NodeSummary `json:"parents"`
+}
+
+type SearchSpecsArgs struct {
+	Query string `json:"query" jsonschema:"Query whose terms must all match, e.g. type:specification tag:security status:approved,implemented under:architecture/storage has:commits -has:children \"free text\". Keys are type, tag, status, under (slug path or ID) and has (commits, children, parents or tags); a leading - negates a term, commas separate alternatives and words without a key must appear in the title, slug or content (case-insensitive)"`
+	Limit int    `json:"limit,omitempty" jsonschema:"Maximum number of results to return (default 20)"`
+}
+
+type SearchSpecsResult struct {
+	Query   string        `json:"query"`
+	Total   int           `json:"total"`
+	Results []NodeSummary `json:"results"`
+}
+
+type UpdateSpecArgs struct {
+	ID      string `json:"id" jsonschema:"ID of the spec to update"`
+	Title   string `json:"title,omitempty" jsonschema:"New title; leave empty to keep the current title"`
+	Content string `json:"content,omitempty" jsonschema:"New content; leave empty to keep the current content"`
+}
+
+type UpdateSpecResult struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Message string `json:"message"`
+}
+
+type MoveNodeArgs struct {
+	ID          string `json:"id" jsonschema:"ID of the node to move"`
+	OldParentID string `json:"old_parent_id" jsonschema:"ID of the parent to move the node away from"`
+	NewParentID string `json:"new_parent_id" jsonschema:"ID of the parent to move the node under"`
+}
+
+type MoveNodeResult struct {
+	ID          string `json:"id"`
+	OldParentID string `json:"old_parent_id"`
+	NewParentID string `json:"new_parent_id"`
+	LinkLabel   string `json:"link_label"`
+	Message     string `json:"message"`
+}
+
+type LinkCommitArgs struct {
+	SpecID    string `json:"spec_id" jsonschema:"ID of the spec the commit relates to"`
+	CommitID  string `json:"commit_id" jsonschema:"Commit hash, branch, tag or other revision"`
+	RepoPath  string `json:"repo_path,omitempty" jsonschema:"Path of the git repository (default: the server's working directory)"`
+	LinkLabel string `json:"link_label,omitempty" jsonschema:"How the commit relates to the spec: implements, updates, fixes, refactors, documents or tests (default implements)"`
+}
+
+type LinkCommitResult struct {
+	SpecID    string `json:"spec_id"`
+	CommitID  string `json:"commit_id"`
+	RepoPath  string `json:"repo_path"`
+	LinkLabel string `json:"link_label"`
+	Message   string `json:"message"`
+}
+
+type ListCommitsForSpecArgs struct {
+	SpecID string `json:"spec_id" jsonschema:"ID of the spec whose linked commits to list"`
+}
+
+type ListCommitsForSpecResult struct {
+	SpecID  string       `json:"spec_id"`
+	Commits []CommitLink `json:"commits"`
+}
+
+// defaultSearchLimit caps search_specs results when no limit is given
+const defaultSearchLimit = 20
+
+// addTools registers every spec tool on the MCP server
+func (s *Server) addTools(server *mcp.Server) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "create_child_spec",
+		Description: "Create a new specification as a child of an existing specification",
+	}, s.CreateChildSpec)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_root",
+		Description: "Get the root node of the specification hierarchy",
+	}, s.GetRoot)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_node",
+		Description: "Get a node's title and full content by ID",
+	}, s.GetNode)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_children",
+		Description: "List the direct children of a node",
+	}, s.ListChildren)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_parents",
+		Description: "List the direct parents of a node",
+	}, s.GetParents)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "search_specs",
+		Description: "Find nodes with a query over their type, tags, status, place in the hierarchy, links and text",
+	}, s.SearchSpecs)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "update_spec",
+		Description: "Update the title and/or content of an existing specification",
+	}, s.UpdateSpec)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "move_node",
+		Description: "Move a node from one parent to another, keeping the label of its original link",
+	}, s.MoveNode)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "link_commit",
+		Description: "Link a git commit to the specification it implements, updates, fixes, refactors, documents or tests",
+	}, s.LinkCommit)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_commits_for_spec",
+		Description: "List the git commits linked to a specification",
+	}, s.ListCommitsForSpec)
+}
+
+func (s *Server) GetRoot(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[GetRootArgs]) (*mcp.CallToolResultFor[GetNodeResult], error) {
+	root, err := s.specService.GetRootNode()
+	if err != nil {
+		return toolError[GetNodeResult]("Error getting root node: %v", err)
+	}
+
+	return toolResult(GetNodeResult{Node: s.nodeDetail(root)})
+}
+
+func (s *Server) GetNode(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[GetNodeArgs]) (*mcp.CallToolResultFor[GetNodeResult], error) {
+	node, err := s.specService.ReadNode(params.Arguments.ID)
+	if err != nil {
+		return toolError[GetNodeResult]("Error: Node with ID '%s' not found: %v", params.Arguments.ID, err)
+	}
+
+	return toolResult(GetNodeResult{Node: s.nodeDetail(node)})
+}
+
+func (s *Server) ListChildren(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[ListChildrenArgs]) (*mcp.CallToolResultFor[ListChildrenResult], error) {
+	children, err := s.specService.GetChildren(params.Arguments.ID)
+	if err != nil {
+		return toolError[ListChildrenResult]("Error listing children of '%s': %v", params.Arguments.ID, err)
+	}
+
+	return toolResult(ListChildrenResult{
+		ParentID: params.Arguments.ID,
+		Children: nodeSummaries(children),
+	})
+}
+
+func (s *Server) GetParents(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[GetParentsArgs]) (*mcp.CallToolResultFor[GetParentsResult], error) {
+	parents, err := s.specService.GetParents(params.Arguments.ID)
+	if err != nil {
+		return toolError[GetParentsResult]("Error listing parents of '%s': %v", params.Arguments.ID, err)
+	}
+
+	return toolResult(GetParentsResult{
+		NodeID:  params.Arguments.ID,
+		Parents: nodeSummaries(parents),
+	})
+}
+
+func (s *Server) SearchSpecs(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[SearchSpecsArgs]) (*mcp.CallToolResultFor[SearchSpecsResult], error) {
+	args := params.Arguments
+
+	if strings.TrimSpace(args.Query) == "" {
+		return toolError[SearchSpecsResult]("Error searching specs: search query cannot be empty")
+	}
+	specs, err := s.queryService.Query(args.Query)
+	if err != nil {
+		return toolError[SearchSpecsResult]("Error searching specs: %v", err)
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	result := SearchSpecsResult{
+		Query:   args.Query,
+		Total:   len(specs),
+		Results: make([]NodeSummary, 0, min(limit, len(specs))),
+	}
+	for _, spec := range specs[:min(limit, len(specs))] {
+		result.Results = append(result.Results, nodeSummary(spec))
+	}
+
+	return toolResult(result)
+}
+
+func (s *Server) UpdateSpec(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[UpdateSpecArgs]) (*mcp.CallToolResultFor[UpdateSpecResult], error) {
+	args := params.Arguments
+
+	existing, err := s.specService.ReadNode(args.ID)
+	if err != nil {
+		return toolError[UpdateSpecResult]("Error: Spec with ID '%s' not found: %v", args.ID, err)
+	}
+
+	title, content := args.Title, args.Content
+	if title == "" {
+		title = existing.Title()
+	}
+	if content == "" {
+		content = existing.Content()
+	}
+
+	spec, err := s.specService.UpdateSpec(args.ID, title, content)
+	if err != nil {
+		return toolError[UpdateSpecResult]("Error updating spec: %v", err)
+	}
+
+	return toolResult(UpdateSpecResult{
+		ID:      spec.ID(),
+		Title:   spec.Title(),
+		Content: spec.Content(),
+		Message: fmt.Sprintf("Successfully updated spec '%s'", spec.Title()),
+	})
+}
+
+func (s *Server) MoveNode(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[MoveNodeArgs]) (*mcp.CallToolResultFor[MoveNodeResult], error) {
+	args := params.Arguments
+
+	link, err := s.specService.MoveNode(args.ID, args.OldParentID, args.NewParentID)
+	if err != nil {
+		return toolError[MoveNodeResult]("Error moving node: %v", err)
+	}
+
+	return toolResult(MoveNodeResult{
+		ID:          args.ID,
+		OldParentID: args.OldParentID,
+		NewParentID: args.NewParentID,
+		LinkLabel:   link.LinkLabel,
+		Message:     fmt.Sprintf("Successfully moved node '%s' under '%s'", args.ID, args.NewParentID),
+	})
+}
+
+func (s *Server) LinkCommit(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[LinkCommitArgs]) (*mcp.CallToolResultFor[LinkCommitResult], error) {
+	args := params.Arguments
+
+	repoPath := args.RepoPath
+	if repoPath == "" {
+		repoPath = "."
+	}
+	label := args.LinkLabel
+	if label == "" {
+		label = "implements"
+	}
+
+	link, err := s.linkService.LinkSpecToCommit(args.SpecID, args.CommitID, repoPath, label)
+	if err != nil {
+		return toolError[LinkCommitResult]("Error linking commit: %v", err)
+	}
+
+	return toolResult(LinkCommitResult{
+		SpecID:    link.SpecID,
+		CommitID:  link.CommitID,
+		RepoPath:  link.RepoPath,
+		LinkLabel: link.LinkLabel,
+		Message:   fmt.Sprintf("Successfully linked commit %s to spec '%s'", link.CommitID, link.SpecID),
+	})
+}
+
+func (s *Server) ListCommitsForSpec(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[ListCommitsForSpecArgs]) (*mcp.CallToolResultFor[ListCommitsForSpecResult], error) {
+	links, err := s.linkService.GetCommitsForSpec(params.Arguments.SpecID)
+	if err != nil {
+		return toolError[ListCommitsForSpecResult]("Error listing commits for spec '%s': %v", params.Arguments.SpecID, err)
+	}
+
+	result := ListCommitsForSpecResult{
+		SpecID:  params.Arguments.SpecID,
+		Commits: make([]CommitLink, 0, len(links)),
+	}
+	for _, link := range links {
+		result.Commits = append(result.Commits, CommitLink{
+			CommitID:  link.CommitID,
+			RepoPath:  link.RepoPath,
+			LinkLabel: link.LinkLabel,
+		})
+	}
+
+	return toolResult(result)
+}
+
+func (s *Server) nodeDetail(node models.Node) NodeDetail {
+	return NodeDetail{
+		ID:      node.ID(),
+		Type:    node.Type(),
+		Title:   node.Title(),
+		Slug:    node.Slug(),
+		Content: node.Content(),
+		IsRoot:  s.specService.IsRootNode(node),
+	}
+}
+
+func nodeSummary(node models.Node) NodeSummary {
+	return NodeSummary{
+		ID:     node.ID(),
+		Type:   node.Type(),
+		Title:  node.Title(),
+		Slug:   node.Slug(),
+		Status: string(node.Status()),
+		Tags:   node.Tags(),
+	}
+}
+
+func nodeSummaries(nodes []models.Node) []NodeSummary {
+	summaries := make([]NodeSummary, 0, len(nodes))
+	for _, node := range nodes {
+		summaries = append(summaries, nodeSummary(node))
+	}
+	return summaries
+}
+
+// toolResult returns a successful tool result carrying the result both as structured
+// content and as JSON text for clients that only read text content
+func toolResult[Out any](result Out) (*mcp.CallToolResultFor[Out], error) {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return toolError[Out]("Error marshaling result: %v", err)
+	}
+
+	return &mcp.CallToolResultFor[Out]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(resultJSON)},
+		},
+		StructuredContent: result,
+	}, nil
+}
+
+// toolError reports a failed tool call inside the result so that the calling agent can see it
+func toolError[Out any](format string, args ...any) (*mcp.CallToolResultFor[Out], error) {
+	return &mcp.CallToolResultFor[Out]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf(format, args...)},
+		},
+		IsError: true,
+	}, nil
+}
@@ -0,0 +1,240 @@
+package mcp
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/services"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func setupTestServer(t *testing.T) (*Server, services.SpecService) {
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+	specService := services.NewSpecService(store)
+	require.NoError(t, specService.InitializeRootSpec())
+	linkService := services.NewLinkService(store, services.NewGitService())
+	return NewServer(specService, linkService, services.NewQueryService(store, specService)), specService
+}
+
+func TestToolsRegistered(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	ctx := context.Background()
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	mcpServer, err := server.newMCPServer()
+	require.NoError(t, err)
+	_, err = mcpServer.Connect(ctx, serverTransport)
+	require.NoError(t, err)
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, nil)
+	session, err := client.Connect(ctx, clientTransport)
+	require.NoError(t, err)
+	defer func() { _ = session.Close() }()
+
+	result, err := session.ListTools(ctx, nil)
+	require.NoError(t, err)
+
+	tools := make(map[string]*mcp.Tool)
+	for _, tool := range result.Tools {
+		tools[tool.Name] = tool
+	}
+
+	for _, name := range []string{
+		"create_child_spec", "get_root", "get_node", "list_children", "get_parents",
+		"search_specs", "update_spec", "move_node", "link_commit", "list_commits_for_spec",
+	} {
+		tool, ok := tools[name]
+		if assert.True(t, ok, "tool %s should be registered", name) {
+			assert.NotNil(t, tool.InputSchema, "tool %s should have an input schema", name)
+			assert.NotNil(t, tool.OutputSchema, "tool %s should have an output schema", name)
+		}
+	}
+
+	callResult, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "get_root", Arguments: map[string]any{}})
+	require.NoError(t, err)
+	assert.False(t, callResult.IsError)
+	assert.NotNil(t, callResult.StructuredContent)
+}
+
+func TestNavigationTools(t *testing.T) {
+	server, specService := setupTestServer(t)
+	ctx := context.Background()
+
+	root, err := specService.GetRootNode()
+	require.NoError(t, err)
+	parent, err := specService.CreateSpec("Parent Spec", "Parent content")
+	require.NoError(t, err)
+	_, err = specService.AddChildToParent(parent.ID(), root.ID(), "child")
+	require.NoError(t, err)
+	child, err := specService.CreateSpec("Child Spec", "Child content")
+	require.NoError(t, err)
+	_, err = specService.AddChildToParent(child.ID(), parent.ID(), "child")
+	require.NoError(t, err)
+
+	rootResult, err := server.GetRoot(ctx, nil, &mcp.CallToolParamsFor[GetRootArgs]{})
+	require.NoError(t, err)
+	require.False(t, rootResult.IsError)
+	assert.Equal(t, root.ID(), rootResult.StructuredContent.Node.ID)
+	assert.True(t, rootResult.StructuredContent.Node.IsRoot)
+
+	nodeResult, err := server.GetNode(ctx, nil, &mcp.CallToolParamsFor[GetNodeArgs]{
+		Arguments: GetNodeArgs{ID: child.ID()},
+	})
+	require.NoError(t, err)
+	require.False(t, nodeResult.IsError)
+	assert.Equal(t, "Child Spec", nodeResult.StructuredContent.Node.Title)
+	assert.Equal(t, "Child content", nodeResult.StructuredContent.Node.Content)
+	assert.False(t, nodeResult.StructuredContent.Node.IsRoot)
+
+	childrenResult, err := server.ListChildren(ctx, nil, &mcp.CallToolParamsFor[ListChildrenArgs]{
+		Arguments: ListChildrenArgs{ID: parent.ID()},
+	})
+	require.NoError(t, err)
+	require.Len(t, childrenResult.StructuredContent.Children, 1)
+	assert.Equal(t, child.ID(), childrenResult.StructuredContent.Children[0].ID)
+
+	parentsResult, err := server.GetParents(ctx, nil, &mcp.CallToolParamsFor[GetParentsArgs]{
+		Arguments: GetParentsArgs{ID: child.ID()},
+	})
+	require.NoError(t, err)
+	require.Len(t, parentsResult.StructuredContent.Parents, 1)
+	assert.Equal(t, parent.ID(), parentsResult.StructuredContent.Parents[0].ID)
+
+	missingResult, err := server.GetNode(ctx, nil, &mcp.CallToolParamsFor[GetNodeArgs]{
+		Arguments: GetNodeArgs{ID: "nonexistent-id"},
+	})
+	require.NoError(t, err)
+	assert.True(t, missingResult.IsError)
+	require.Len(t, missingResult.Content, 1)
+	textContent, ok := missingResult.Content[0].(*mcp.TextContent)
+	require.True(t, ok, "Expected TextContent")
+	assert.Contains(t, textContent.Text, "Error: Node with ID 'nonexistent-id' not found")
+}
+
+func TestSearchSpecsTool(t *testing.T) {
+	server, specService := setupTestServer(t)
+	ctx := context.Background()
+
+	var specIDs []string
+	for _, title := range []string{"Widget alpha", "Widget beta", "Widget gamma"} {
+		spec, err := specService.CreateSpec(title, "A widget")
+		require.NoError(t, err)
+		specIDs = append(specIDs, spec.ID())
+	}
+	_, err := specService.TagNode(specIDs[1], []string{"security"}, nil)
+	require.NoError(t, err)
+
+	result, err := server.SearchSpecs(ctx, nil, &mcp.CallToolParamsFor[SearchSpecsArgs]{
+		Arguments: SearchSpecsArgs{Query: "widget", Limit: 2},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, 3, result.StructuredContent.Total)
+	assert.Len(t, result.StructuredContent.Results, 2)
+
+	tagged, err := server.SearchSpecs(ctx, nil, &mcp.CallToolParamsFor[SearchSpecsArgs]{
+		Arguments: SearchSpecsArgs{Query: "type:specification tag:security widget"},
+	})
+	require.NoError(t, err)
+	require.False(t, tagged.IsError)
+	require.Len(t, tagged.StructuredContent.Results, 1)
+	assert.Equal(t, specIDs[1], tagged.StructuredContent.Results[0].ID)
+	assert.Equal(t, []string{"security"}, tagged.StructuredContent.Results[0].Tags)
+
+	invalid, err := server.SearchSpecs(ctx, nil, &mcp.CallToolParamsFor[SearchSpecsArgs]{
+		Arguments: SearchSpecsArgs{Query: "owner:alice"},
+	})
+	require.NoError(t, err)
+	assert.True(t, invalid.IsError)
+}
+
+func TestUpdateSpecTool(t *testing.T) {
+	server, specService := setupTestServer(t)
+	ctx := context.Background()
+
+	spec, err := specService.CreateSpec("Original Title", "Original content")
+	require.NoError(t, err)
+
+	result, err := server.UpdateSpec(ctx, nil, &mcp.CallToolParamsFor[UpdateSpecArgs]{
+		Arguments: UpdateSpecArgs{ID: spec.ID(), Content: "Updated content"},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, "Original Title", result.StructuredContent.Title)
+	assert.Equal(t, "Updated content", result.StructuredContent.Content)
+
+	updated, err := specService.ReadNode(spec.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "Original Title", updated.Title())
+	assert.Equal(t, "Updated content", updated.Content())
+}
+
+func TestMoveNodeTool(t *testing.T) {
+	server, specService := setupTestServer(t)
+	ctx := context.Background()
+
+	oldParent, err := specService.CreateSpec("Old Parent", "Old parent content")
+	require.NoError(t, err)
+	newParent, err := specService.CreateSpec("New Parent", "New parent content")
+	require.NoError(t, err)
+	child, err := specService.CreateSpec("Child", "Child content")
+	require.NoError(t, err)
+	_, err = specService.AddChildToParent(child.ID(), oldParent.ID(), "child")
+	require.NoError(t, err)
+
+	result, err := server.MoveNode(ctx, nil, &mcp.CallToolParamsFor[MoveNodeArgs]{
+		Arguments: MoveNodeArgs{ID: child.ID(), OldParentID: oldParent.ID(), NewParentID: newParent.ID()},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, "child", result.StructuredContent.LinkLabel)
+
+	children, err := specService.GetChildren(newParent.ID())
+	require.NoError(t, err)
+	require.Len(t, children, 1)
+	assert.Equal(t, child.ID(), children[0].ID())
+}
+
+func TestCommitTools(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "Initial commit"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	head, err := exec.Command("git", "-C", repoDir, "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+
+	server, specService := setupTestServer(t)
+	ctx := context.Background()
+
+	spec, err := specService.CreateSpec("Linked Spec", "Linked content")
+	require.NoError(t, err)
+
+	linkResult, err := server.LinkCommit(ctx, nil, &mcp.CallToolParamsFor[LinkCommitArgs]{
+		Arguments: LinkCommitArgs{SpecID: spec.ID(), CommitID: "HEAD", RepoPath: repoDir},
+	})
+	require.NoError(t, err)
+	require.False(t, linkResult.IsError)
+	assert.Equal(t, strings.TrimSpace(string(head)), linkResult.StructuredContent.CommitID)
+	assert.Equal(t, "implements", linkResult.StructuredContent.LinkLabel)
+
+	listResult, err := server.ListCommitsForSpec(ctx, nil, &mcp.CallToolParamsFor[ListCommitsForSpecArgs]{
+		Arguments: ListCommitsForSpecArgs{SpecID: spec.ID()},
+	})
+	require.NoError(t, err)
+	require.Len(t, listResult.StructuredContent.Commits, 1)
+	assert.Equal(t, linkResult.StructuredContent.CommitID, listResult.StructuredContent.Commits[0].CommitID)
+}
@@ -1,23 +1,58 @@
 package models
 
+import (
+	"slices"
+	"sort"
+)
+
+// ChildGroup is a node's children as they are listed: the groups first, in order, then
+// the children that aren't in any group
 type ChildGroup struct {
+	Label          string
 	Children       []Node
-	Groups         map[string]*ChildGroup
+	Groups         []*ChildGroup
 	UngroupedLabel string
 }
 
+// ChildOrder is the order a node's children are listed in, by ID, and the order of the
+// groups they are organized into, by label. Children and groups it doesn't mention are
+// listed after the ones it does.
+type ChildOrder struct {
+	Children []string `json:"children,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+func (o ChildOrder) IsEmpty() bool {
+	return len(o.Children) == 0 && len(o.Groups) == 0
+}
+
 func (cg *ChildGroup) Contains(node Node) bool {
+	return cg.find(node.ID()) != nil
+}
+
+// find returns the group that lists a child directly, if any
+func (cg *ChildGroup) find(id string) *ChildGroup {
 	for _, child := range cg.Children {
-		if child.ID() == node.ID() {
-			return true
+		if child.ID() == id {
+			return cg
+		}
+	}
+	for _, group := range cg.Groups {
+		if found := group.find(id); found != nil {
+			return found
 		}
 	}
+	return nil
+}
+
+// Group returns the subgroup with the given label, if there is one
+func (cg *ChildGroup) Group(label string) *ChildGroup {
 	for _, group := range cg.Groups {
-		if group.Contains(node) {
-			return true
+		if group.Label == label {
+			return group
 		}
 	}
-	return false
+	return nil
 }
 
 func (cg *ChildGroup) Size() int {
@@ -68,12 +103,14 @@ func (cg *ChildGroup) AppendUnmatched(nodes []Node) {
 func (cg *ChildGroup) Remove(predicate func(Node) bool) []Node {
 	var removed []Node
 	removed, cg.Children = partitionNodes(cg.Children, predicate)
-	for label, group := range cg.Groups {
+	groups := make([]*ChildGroup, 0, len(cg.Groups))
+	for _, group := range cg.Groups {
 		removed = append(removed, group.Remove(predicate)...)
-		if len(group.Children) == 0 && len(group.Groups) == 0 {
-			delete(cg.Groups, label)
+		if !group.IsEmpty() {
+			groups = append(groups, group)
 		}
 	}
+	cg.Groups = groups
 	return removed
 }
 
@@ -90,16 +127,83 @@ func partitionNodes(nodes []Node, predicate func(Node) bool) ([]Node, []Node) {
 	return matching, unmatching
 }
 
+// Regroup moves the children that pass the filter into the group with the given label,
+// which is added after the existing groups if there isn't one yet
 func (cg *ChildGroup) Regroup(label string, filter func(Node) bool) {
 	removed := cg.Remove(filter)
-	if len(removed) > 0 {
-		if cg.Groups == nil {
-			cg.Groups = make(map[string]*ChildGroup)
+	if len(removed) == 0 {
+		return
+	}
+	if group := cg.Group(label); group != nil {
+		group.Children = append(group.Children, removed...)
+		return
+	}
+	cg.Groups = append(cg.Groups, &ChildGroup{Label: label, Children: removed})
+}
+
+// Arrange sorts the children and groups into the given order, at every level. Whatever
+// the order doesn't mention keeps its place relative to the rest after the ones it does.
+func (cg *ChildGroup) Arrange(order ChildOrder) {
+	cg.Children = slices.Clone(cg.Children)
+	sortByPosition(cg.Children, order.Children, Node.ID)
+	cg.Groups = slices.Clone(cg.Groups)
+	sortByPosition(cg.Groups, order.Groups, func(group *ChildGroup) string { return group.Label })
+	for _, group := range cg.Groups {
+		group.Arrange(order)
+	}
+}
+
+func sortByPosition[T any](items []T, order []string, key func(T) string) {
+	position := func(item T) int {
+		if i := slices.Index(order, key(item)); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return position(items[i]) < position(items[j])
+	})
+}
+
+// Order records the order the children and groups are listed in, for Arrange
+func (cg *ChildGroup) Order() ChildOrder {
+	order := ChildOrder{Children: make([]string, 0, cg.Size())}
+	for _, node := range cg.AllNodes() {
+		order.Children = append(order.Children, node.ID())
+	}
+	cg.appendGroupLabels(&order)
+	return order
+}
+
+func (cg *ChildGroup) appendGroupLabels(order *ChildOrder) {
+	for _, group := range cg.Groups {
+		if !slices.Contains(order.Groups, group.Label) {
+			order.Groups = append(order.Groups, group.Label)
 		}
-		cg.Groups[label] = &ChildGroup{Children: removed}
+		group.appendGroupLabels(order)
 	}
 }
 
+// Move moves a child up (a negative offset) or down among the children of its group. It
+// reports whether the child moved, which it doesn't if it would leave its group.
+func (cg *ChildGroup) Move(id string, offset int) bool {
+	group := cg.find(id)
+	if group == nil || offset == 0 {
+		return false
+	}
+	from := slices.IndexFunc(group.Children, func(child Node) bool { return child.ID() == id })
+	to := from + offset
+	if to < 0 || to >= len(group.Children) {
+		return false
+	}
+
+	children := slices.Clone(group.Children)
+	child := children[from]
+	children = slices.Delete(children, from, from+1)
+	group.Children = slices.Insert(children, to, child)
+	return true
+}
+
 func (cg *ChildGroup) Render(renderer ChildGroupRenderer) {
 	cg.recursivelyRender(0, renderer)
 }
@@ -107,8 +211,8 @@ func (cg *ChildGroup) Render(renderer ChildGroupRenderer) {
 func (cg *ChildGroup) recursivelyRender(nestingLevel int, renderer ChildGroupRenderer) {
 	renderUngroupedEnclosure := cg.UngroupedLabel != "" && len(cg.Children) > 0
 
-	for groupLabel, subGroup := range cg.Groups {
-		renderer.RenderGroupStart(nestingLevel, groupLabel)
+	for _, subGroup := range cg.Groups {
+		renderer.RenderGroupStart(nestingLevel, subGroup.Label)
 		subGroup.recursivelyRender(nestingLevel+1, renderer)
 		renderer.RenderGroupEnd(nestingLevel)
 	}
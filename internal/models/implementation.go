@@ -6,6 +6,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// implementationFields are the frontmatter fields only implementations are read from
+var implementationFields = []string{"repo_url", "branch", "folder_path"}
+
 type implementationJSON struct {
 	nodeBaseJSON
 	RepoURL    *string `json:"repo_url,omitempty"`
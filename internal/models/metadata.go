@@ -0,0 +1,145 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// MetadataField is a frontmatter field that zamm itself doesn't use
+type MetadataField struct {
+	Key   string
+	Value any
+}
+
+// Metadata holds the custom frontmatter fields of a node, such as an owner or a ticket,
+// in the order they were written. Nested objects are Metadata too, so that their order
+// survives as well. It marshals to a JSON object.
+type Metadata []MetadataField
+
+// Get returns the value of a field
+func (m Metadata) Get(key string) (any, bool) {
+	for _, field := range m {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set changes the value of a field, adding it at the end if there is none
+func (m *Metadata) Set(key string, value any) {
+	for i, field := range *m {
+		if field.Key == key {
+			(*m)[i].Value = value
+			return
+		}
+	}
+	*m = append(*m, MetadataField{Key: key, Value: value})
+}
+
+// Delete removes a field
+func (m *Metadata) Delete(key string) {
+	for i, field := range *m {
+		if field.Key == key {
+			*m = append((*m)[:i:i], (*m)[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata field %s: %w", field.Key, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	value, err := decodeMetadataValue(decoder)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		*m = nil
+		return nil
+	}
+	metadata, ok := value.(Metadata)
+	if !ok {
+		return fmt.Errorf("metadata must be a JSON object")
+	}
+	*m = metadata
+	return nil
+}
+
+// decodeMetadataValue decodes the next JSON value, keeping the order of object keys
+func decodeMetadataValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		switch token {
+		case '{':
+			metadata := make(Metadata, 0)
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyToken.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid metadata key %v", keyToken)
+				}
+				value, err := decodeMetadataValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				metadata = append(metadata, MetadataField{Key: key, Value: value})
+			}
+			_, err := decoder.Token() // Closing brace
+			return metadata, err
+		case '[':
+			values := make([]any, 0)
+			for decoder.More() {
+				value, err := decodeMetadataValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			_, err := decoder.Token() // Closing bracket
+			return values, err
+		}
+		return nil, fmt.Errorf("unexpected %v in metadata", token)
+	case json.Number:
+		// Whole numbers stay integers, so that they are written back the way they were read
+		if i, err := token.Int64(); err == nil {
+			return i, nil
+		}
+		return token.Float64()
+	default:
+		return token, nil
+	}
+}
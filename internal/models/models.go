@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"slices"
 
 	"github.com/google/uuid"
 )
@@ -21,7 +22,23 @@ type nodeBaseJSON struct {
 	Content       string      `json:"content"`
 	Type          string      `json:"type"`
 	Slug          *string     `json:"slug,omitempty"`
+	Status        Status      `json:"status,omitempty"`
+	Tags          []string    `json:"tags,omitempty"`
 	ChildGrouping *ChildGroup `json:"child_grouping,omitempty"`
+	ChildOrder    *ChildOrder `json:"child_order,omitempty"`
+	Metadata      Metadata    `json:"metadata,omitempty"`
+}
+
+// nodeFields are the frontmatter fields that every node is read from
+var nodeFields = []string{"id", "title", "content", "type", "slug", "status", "tags", "child_grouping", "child_order"}
+
+// IsNodeField reports whether zamm reads a frontmatter field into nodes of the given type.
+// Any other field is kept in the node's Metadata.
+func IsNodeField(nodeType, key string) bool {
+	if slices.Contains(nodeFields, key) {
+		return true
+	}
+	return nodeType == "implementation" && slices.Contains(implementationFields, key)
 }
 
 // NodeBase represents the base structure for all nodes in the system
@@ -31,7 +48,11 @@ type NodeBase struct {
 	content       string
 	nodeType      string
 	slug          *string
+	status        Status
+	tags          []string
 	childGrouping *ChildGroup
+	childOrder    *ChildOrder
+	metadata      Metadata
 }
 
 func (n *NodeBase) asBaseJsonStruct() nodeBaseJSON {
@@ -41,7 +62,11 @@ func (n *NodeBase) asBaseJsonStruct() nodeBaseJSON {
 		Content:       n.content,
 		Type:          n.nodeType,
 		Slug:          n.slug,
+		Status:        n.status,
+		Tags:          n.tags,
 		ChildGrouping: n.childGrouping,
+		ChildOrder:    n.childOrder,
+		Metadata:      n.metadata,
 	}
 }
 
@@ -51,7 +76,11 @@ func (n *NodeBase) fromBaseJsonStruct(jsonStruct nodeBaseJSON) {
 	n.content = jsonStruct.Content
 	n.nodeType = jsonStruct.Type
 	n.slug = jsonStruct.Slug
+	n.status = jsonStruct.Status
+	n.tags = jsonStruct.Tags
 	n.childGrouping = jsonStruct.ChildGrouping
+	n.childOrder = jsonStruct.ChildOrder
+	n.metadata = jsonStruct.Metadata
 }
 
 func (n *NodeBase) MarshalJSON() ([]byte, error) {
@@ -85,8 +114,24 @@ type Node interface {
 	Slug() string
 	SetSlug(string)
 
+	// Status is the node's lifecycle status, draft until one is set
+	Status() Status
+	SetStatus(Status)
+
+	// Tags are free-form labels, such as security, for selecting nodes in queries
+	Tags() []string
+	SetTags([]string)
+
 	GetChildGrouping() ChildGroup
 	SetChildGrouping(ChildGroup)
+
+	// ChildOrder is the order the node's children are listed in, if one was chosen
+	ChildOrder() ChildOrder
+	SetChildOrder(ChildOrder)
+
+	// Metadata holds the custom frontmatter fields zamm doesn't use itself
+	Metadata() Metadata
+	SetMetadata(Metadata)
 }
 
 // Implement Node interface for NodeBase
@@ -103,6 +148,21 @@ func (n *NodeBase) Slug() string {
 	}
 	return *n.slug
 }
+func (n *NodeBase) Status() Status {
+	if n.status == "" {
+		return StatusDraft
+	}
+	return n.status
+}
+func (n *NodeBase) SetStatus(status Status) {
+	n.status = status
+}
+func (n *NodeBase) Tags() []string {
+	return n.tags
+}
+func (n *NodeBase) SetTags(tags []string) {
+	n.tags = tags
+}
 func (n *NodeBase) SetTitle(title string) {
 	n.title = title
 }
@@ -124,6 +184,29 @@ func (n *NodeBase) SetChildGrouping(grouping ChildGroup) {
 	n.childGrouping = &grouping
 }
 
+func (n *NodeBase) ChildOrder() ChildOrder {
+	if n.childOrder == nil {
+		return ChildOrder{}
+	}
+	return *n.childOrder
+}
+
+func (n *NodeBase) SetChildOrder(order ChildOrder) {
+	if order.IsEmpty() {
+		n.childOrder = nil
+		return
+	}
+	n.childOrder = &order
+}
+
+func (n *NodeBase) Metadata() Metadata {
+	return n.metadata
+}
+
+func (n *NodeBase) SetMetadata(metadata Metadata) {
+	n.metadata = metadata
+}
+
 // Spec represents a specification node in the system
 type Spec struct {
 	NodeBase
@@ -196,7 +279,8 @@ type SpecSpecLink struct {
 
 // ProjectMetadata represents project-level metadata and configuration
 type ProjectMetadata struct {
-	RootSpecID *string `json:"root_spec_id"` // Nullable foreign key to specs
+	RootSpecID    *string `json:"root_spec_id"`   // Nullable foreign key to specs
+	SchemaVersion int     `json:"schema_version"` // Version of the store format, see zamm migrate
 }
 
 // ErrorType represents different categories of errors in the system
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is where a node is in its lifecycle, from first draft to deprecation
+type Status string
+
+const (
+	StatusDraft       Status = "draft"
+	StatusProposed    Status = "proposed"
+	StatusApproved    Status = "approved"
+	StatusImplemented Status = "implemented"
+	StatusVerified    Status = "verified"
+	StatusDeprecated  Status = "deprecated"
+)
+
+// Statuses lists every status in lifecycle order
+var Statuses = []Status{StatusDraft, StatusProposed, StatusApproved, StatusImplemented, StatusVerified, StatusDeprecated}
+
+// ParseStatus validates the name of a status
+func ParseStatus(name string) (Status, error) {
+	for _, status := range Statuses {
+		if string(status) == name {
+			return status, nil
+		}
+	}
+
+	names := make([]string, 0, len(Statuses))
+	for _, status := range Statuses {
+		names = append(names, string(status))
+	}
+	return "", NewZammError(ErrTypeValidation, fmt.Sprintf("unknown status %q, expected one of %s", name, strings.Join(names, ", ")))
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// CycleNode is a node on a cycle in the spec hierarchy
+type CycleNode struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+// SpecCycle is a chain of child-to-parent links that leads back to where it started.
+// The first node is repeated at the end.
+type SpecCycle struct {
+	Nodes []CycleNode `json:"nodes"`
+}
+
+// String describes the cycle by node titles, falling back to IDs for nodes that can't be read
+func (c SpecCycle) String() string {
+	names := make([]string, 0, len(c.Nodes))
+	for _, node := range c.Nodes {
+		if node.Title != "" {
+			names = append(names, node.Title)
+		} else {
+			names = append(names, node.ID)
+		}
+	}
+	return strings.Join(names, " -> ")
+}
+
+// parentGraph maps each node ID to the IDs of its parents, in a stable order
+type parentGraph map[string][]string
+
+func newParentGraph(links []*models.SpecSpecLink) parentGraph {
+	graph := make(parentGraph)
+	for _, link := range links {
+		graph[link.FromSpecID] = append(graph[link.FromSpecID], link.ToSpecID)
+		if _, ok := graph[link.ToSpecID]; !ok {
+			graph[link.ToSpecID] = nil
+		}
+	}
+	for id, parents := range graph {
+		sort.Strings(parents)
+		graph[id] = compactStrings(parents)
+	}
+	return graph
+}
+
+func compactStrings(sorted []string) []string {
+	result := sorted[:0]
+	for i, value := range sorted {
+		if i == 0 || value != sorted[i-1] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// pathToAncestor follows parent links from one node to another, returning the IDs
+// along the way including both ends, or nil if the target isn't an ancestor. When
+// within is non-nil, only nodes in it are visited.
+func (g parentGraph) pathToAncestor(fromID, toID string, within map[string]bool) []string {
+	visited := make(map[string]bool)
+
+	var walk func(id string) []string
+	walk = func(id string) []string {
+		if id == toID {
+			return []string{id}
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+
+		for _, parentID := range g[id] {
+			if within != nil && !within[parentID] {
+				continue
+			}
+			if path := walk(parentID); path != nil {
+				return append([]string{id}, path...)
+			}
+		}
+		return nil
+	}
+
+	return walk(fromID)
+}
+
+// stronglyConnected returns the groups of nodes that can all reach each other through
+// parent links, using Tarjan's algorithm. Every cycle lies within a single group.
+func (g parentGraph) stronglyConnected() [][]string {
+	ids := make([]string, 0, len(g))
+	for id := range g {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		lowLink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, parentID := range g[id] {
+			if _, seen := index[parentID]; !seen {
+				visit(parentID)
+				lowLink[id] = min(lowLink[id], lowLink[parentID])
+			} else if onStack[parentID] {
+				lowLink[id] = min(lowLink[id], index[parentID])
+			}
+		}
+
+		if lowLink[id] == index[id] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == id {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, id := range ids {
+		if _, seen := index[id]; !seen {
+			visit(id)
+		}
+	}
+
+	return components
+}
+
+// FindCycles reports one cycle for every group of nodes in spec-links.csv whose parent
+// links loop back on themselves
+func (s *specService) FindCycles() ([]SpecCycle, error) {
+	links, err := s.storage.ListSpecSpecLinks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spec links: %w", err)
+	}
+
+	graph := newParentGraph(links)
+	cycles := make([]SpecCycle, 0)
+	for _, component := range graph.stronglyConnected() {
+		within := make(map[string]bool, len(component))
+		for _, id := range component {
+			within[id] = true
+		}
+
+		// Start from the lowest ID so that the same cycle is always reported the same way
+		start := component[0]
+		for _, parentID := range graph[start] {
+			if !within[parentID] {
+				continue
+			}
+			if path := graph.pathToAncestor(parentID, start, within); path != nil {
+				cycles = append(cycles, s.describeCycle(append([]string{start}, path...)))
+				break
+			}
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i].Nodes[0].ID < cycles[j].Nodes[0].ID
+	})
+
+	return cycles, nil
+}
+
+// checkForCycle returns a conflict error if linking the child to the parent would
+// make the child one of its own ancestors
+func (s *specService) checkForCycle(childID, parentID string) error {
+	links, err := s.storage.ListSpecSpecLinks()
+	if err != nil {
+		return fmt.Errorf("failed to list spec links: %w", err)
+	}
+
+	path := newParentGraph(links).pathToAncestor(parentID, childID, nil)
+	if path == nil {
+		return nil
+	}
+
+	zammErr := models.NewZammError(models.ErrTypeConflict, "link would create a cycle in the spec hierarchy")
+	zammErr.Details = s.describeCycle(append([]string{childID}, path...)).String()
+	return zammErr
+}
+
+// ancestorCycleError is the conflict error for a walk up the first parent of each node
+// that came back to a node it already passed. visited lists the nodes walked through, in
+// order, and repeated is the one reached a second time.
+func (s *specService) ancestorCycleError(visited []string, repeated string) error {
+	start := slices.Index(visited, repeated)
+	ids := append(slices.Clone(visited[start:]), repeated)
+
+	zammErr := models.NewZammError(models.ErrTypeConflict, "the spec hierarchy contains a cycle")
+	zammErr.Details = s.describeCycle(ids).String() + "; run `zamm check cycles` to list every cycle"
+	return zammErr
+}
+
+func (s *specService) describeCycle(ids []string) SpecCycle {
+	cycle := SpecCycle{Nodes: make([]CycleNode, 0, len(ids))}
+	for _, id := range ids {
+		node := CycleNode{ID: id}
+		if n, err := s.storage.ReadNode(id); err == nil {
+			node.Title = n.Title()
+		}
+		cycle.Nodes = append(cycle.Nodes, node)
+	}
+	return cycle
+}
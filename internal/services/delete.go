@@ -0,0 +1,291 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// DeleteStrategy decides what happens to the children of a deleted node
+type DeleteStrategy string
+
+const (
+	// DeleteRefuse only deletes nodes without children
+	DeleteRefuse DeleteStrategy = "refuse"
+	// DeleteCascade also deletes every descendant that has no parent outside the deleted subtree
+	DeleteCascade DeleteStrategy = "cascade"
+	// DeleteReparent links the node's children to the node's own parents before deleting it
+	DeleteReparent DeleteStrategy = "reparent"
+)
+
+// DeleteStrategies lists the valid deletion strategies
+var DeleteStrategies = []DeleteStrategy{DeleteRefuse, DeleteCascade, DeleteReparent}
+
+// NodeSummary identifies a node affected by an operation
+type NodeSummary struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+}
+
+func summarizeNode(node models.Node) NodeSummary {
+	return NodeSummary{ID: node.ID(), Title: node.Title(), Type: node.Type()}
+}
+
+// DeletionPlan lists everything that deleting a node changes. The node being deleted
+// comes first in DeletedNodes.
+type DeletionPlan struct {
+	NodeID             string                   `json:"node_id"`
+	Strategy           DeleteStrategy           `json:"strategy"`
+	Children           []NodeSummary            `json:"children"`
+	DeletedNodes       []NodeSummary            `json:"deleted_nodes"`
+	RemovedSpecLinks   []*models.SpecSpecLink   `json:"removed_spec_links"`
+	AddedSpecLinks     []*models.SpecSpecLink   `json:"added_spec_links"`
+	RemovedCommitLinks []*models.SpecCommitLink `json:"removed_commit_links"`
+	UpdatedNodes       []NodeSummary            `json:"updated_nodes"`
+}
+
+// ParseDeleteStrategy validates the name of a deletion strategy
+func ParseDeleteStrategy(name string) (DeleteStrategy, error) {
+	for _, strategy := range DeleteStrategies {
+		if string(strategy) == name {
+			return strategy, nil
+		}
+	}
+
+	names := make([]string, 0, len(DeleteStrategies))
+	for _, strategy := range DeleteStrategies {
+		names = append(names, string(strategy))
+	}
+	return "", models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("unknown delete strategy %q, expected one of %s", name, strings.Join(names, ", ")))
+}
+
+// PlanDeletion works out what deleting a node with the given strategy would change,
+// without changing anything
+func (s *specService) PlanDeletion(id string, strategy DeleteStrategy) (*DeletionPlan, error) {
+	if id == "" {
+		return nil, models.NewZammError(models.ErrTypeValidation, "node ID cannot be empty")
+	}
+	if _, err := ParseDeleteStrategy(string(strategy)); err != nil {
+		return nil, err
+	}
+
+	node, err := s.storage.ReadNode(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.IsRootNode(node) {
+		return nil, models.NewZammError(models.ErrTypeValidation, "cannot delete the root node")
+	}
+
+	specLinks, err := s.storage.ListSpecSpecLinks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spec links: %w", err)
+	}
+	commitLinks, err := s.storage.ListSpecCommitLinks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commit links: %w", err)
+	}
+
+	children, err := s.GetChildren(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children for node %s: %w", id, err)
+	}
+
+	plan := &DeletionPlan{
+		NodeID:             id,
+		Strategy:           strategy,
+		Children:           make([]NodeSummary, 0, len(children)),
+		DeletedNodes:       []NodeSummary{summarizeNode(node)},
+		RemovedSpecLinks:   make([]*models.SpecSpecLink, 0),
+		AddedSpecLinks:     make([]*models.SpecSpecLink, 0),
+		RemovedCommitLinks: make([]*models.SpecCommitLink, 0),
+		UpdatedNodes:       make([]NodeSummary, 0),
+	}
+	for _, child := range children {
+		plan.Children = append(plan.Children, summarizeNode(child))
+	}
+
+	if len(children) > 0 && strategy == DeleteRefuse {
+		titles := make([]string, 0, len(children))
+		for _, child := range children {
+			titles = append(titles, child.Title())
+		}
+		zammErr := models.NewZammError(models.ErrTypeConflict, fmt.Sprintf("node has %d children; choose the cascade or reparent strategy to delete it", len(children)))
+		zammErr.Details = strings.Join(titles, ", ")
+		return nil, zammErr
+	}
+
+	deleted := map[string]bool{id: true}
+	if strategy == DeleteCascade {
+		for _, descendant := range s.cascadeDeletions(id, specLinks) {
+			deleted[descendant.ID()] = true
+			plan.DeletedNodes = append(plan.DeletedNodes, summarizeNode(descendant))
+		}
+	}
+
+	for _, link := range specLinks {
+		if deleted[link.FromSpecID] || deleted[link.ToSpecID] {
+			plan.RemovedSpecLinks = append(plan.RemovedSpecLinks, link)
+		}
+	}
+
+	if strategy == DeleteReparent {
+		existing := make(map[[2]string]bool, len(specLinks))
+		for _, link := range specLinks {
+			existing[[2]string{link.FromSpecID, link.ToSpecID}] = true
+		}
+
+		for _, parentLink := range specLinks {
+			if parentLink.FromSpecID != id {
+				continue
+			}
+			for _, childLink := range specLinks {
+				if childLink.ToSpecID != id {
+					continue
+				}
+				key := [2]string{childLink.FromSpecID, parentLink.ToSpecID}
+				if existing[key] || childLink.FromSpecID == parentLink.ToSpecID {
+					continue
+				}
+				existing[key] = true
+				plan.AddedSpecLinks = append(plan.AddedSpecLinks, &models.SpecSpecLink{
+					FromSpecID: childLink.FromSpecID,
+					ToSpecID:   parentLink.ToSpecID,
+					LinkLabel:  childLink.LinkLabel,
+				})
+			}
+		}
+	}
+
+	for _, link := range commitLinks {
+		if deleted[link.SpecID] {
+			plan.RemovedCommitLinks = append(plan.RemovedCommitLinks, link)
+		}
+	}
+
+	// Surviving parents of deleted nodes need their child links regenerated
+	updated := make(map[string]bool)
+	for _, link := range plan.RemovedSpecLinks {
+		if !deleted[link.FromSpecID] || deleted[link.ToSpecID] || updated[link.ToSpecID] {
+			continue
+		}
+		updated[link.ToSpecID] = true
+		parent, err := s.storage.ReadNode(link.ToSpecID)
+		if err != nil {
+			continue // Dangling links are left for zamm doctor to report
+		}
+		plan.UpdatedNodes = append(plan.UpdatedNodes, summarizeNode(parent))
+	}
+
+	return plan, nil
+}
+
+// cascadeDeletions returns the descendants of a node that would be left without any
+// parent if the node and its subtree were deleted. Descendants that are also linked
+// elsewhere in the hierarchy are kept.
+func (s *specService) cascadeDeletions(id string, specLinks []*models.SpecSpecLink) []models.Node {
+	graph := newParentGraph(specLinks)
+	childIDs := make(map[string][]string)
+	for _, link := range specLinks {
+		childIDs[link.ToSpecID] = append(childIDs[link.ToSpecID], link.FromSpecID)
+	}
+
+	deleted := map[string]bool{id: true}
+	var order []string
+	for changed := true; changed; {
+		changed = false
+		for _, parentID := range append([]string{id}, order...) {
+			for _, childID := range childIDs[parentID] {
+				if deleted[childID] {
+					continue
+				}
+				orphaned := true
+				for _, otherParentID := range graph[childID] {
+					if !deleted[otherParentID] {
+						orphaned = false
+						break
+					}
+				}
+				if orphaned {
+					deleted[childID] = true
+					order = append(order, childID)
+					changed = true
+				}
+			}
+		}
+	}
+
+	descendants := make([]models.Node, 0, len(order))
+	for _, descendantID := range order {
+		node, err := s.storage.ReadNode(descendantID)
+		if err != nil {
+			continue // Links to missing nodes are still removed along with the subtree
+		}
+		descendants = append(descendants, node)
+	}
+	return descendants
+}
+
+// DeleteNode deletes a node using the given strategy for its children. It removes the
+// deleted nodes' files, their rows in every index and their links, then regenerates the
+// child links of the nodes left behind. If any step fails, every file is restored. The
+// plan is worked out again inside the transaction, so it is never applied to a hierarchy
+// that changed after it was previewed.
+func (s *specService) DeleteNode(id string, strategy DeleteStrategy) (*DeletionPlan, error) {
+	var plan *DeletionPlan
+	err := s.storage.Transaction(func(tx storage.Storage) error {
+		s := &specService{storage: tx}
+		var err error
+		if plan, err = s.PlanDeletion(id, strategy); err != nil {
+			return err
+		}
+
+		for _, link := range plan.AddedSpecLinks {
+			if err := s.storage.CreateSpecSpecLink(link); err != nil {
+				return fmt.Errorf("failed to link %s to %s: %w", link.FromSpecID, link.ToSpecID, err)
+			}
+		}
+
+		for _, link := range plan.RemovedSpecLinks {
+			if err := s.storage.DeleteSpecSpecLink(link.FromSpecID, link.ToSpecID); err != nil && !isNotFound(err) {
+				return fmt.Errorf("failed to remove link from %s to %s: %w", link.FromSpecID, link.ToSpecID, err)
+			}
+		}
+
+		for _, link := range plan.RemovedCommitLinks {
+			if err := s.storage.DeleteSpecCommitLinkByFields(link.SpecID, link.CommitID, link.RepoPath); err != nil && !isNotFound(err) {
+				return fmt.Errorf("failed to remove commit link for %s: %w", link.SpecID, err)
+			}
+		}
+
+		for _, node := range plan.DeletedNodes {
+			if err := s.storage.DeleteNode(node.ID); err != nil && !isNotFound(err) {
+				return fmt.Errorf("failed to delete node %s: %w", node.ID, err)
+			}
+		}
+
+		for _, summary := range plan.UpdatedNodes {
+			node, err := s.storage.ReadNode(summary.ID)
+			if err != nil {
+				return err
+			}
+			if err := s.resaveNodeWithChildren(node); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func isNotFound(err error) bool {
+	zammErr, ok := err.(*models.ZammError)
+	return ok && zammErr.Type == models.ErrTypeNotFound
+}
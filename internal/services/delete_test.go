@@ -0,0 +1,234 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// deletionFixture builds root -> parent -> {child, shared -> grandchild}, where shared
+// is also a child of other, and links a commit to child
+type deletionFixture struct {
+	store      *storage.FileStorage
+	service    SpecService
+	root       models.Node
+	parent     *models.Spec
+	child      *models.Spec
+	shared     *models.Spec
+	grandchild *models.Spec
+	other      *models.Spec
+	parentFile string
+}
+
+func setupDeletionFixture(t *testing.T) *deletionFixture {
+	t.Helper()
+
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	service := NewSpecService(store)
+	if err := service.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := service.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+
+	f := &deletionFixture{store: store, service: service, root: root}
+	create := func(title string, parentIDs ...string) *models.Spec {
+		t.Helper()
+		spec, err := service.CreateSpec(title, title+" content")
+		if err != nil {
+			t.Fatalf("Failed to create spec %s: %v", title, err)
+		}
+		for _, parentID := range parentIDs {
+			if _, err := service.AddChildToParent(spec.ID(), parentID, "child"); err != nil {
+				t.Fatalf("Failed to link %s: %v", title, err)
+			}
+		}
+		return spec
+	}
+
+	f.parent = create("Parent", root.ID())
+	f.other = create("Other", root.ID())
+	f.child = create("Child", f.parent.ID())
+	f.shared = create("Shared", f.parent.ID(), f.other.ID())
+	f.grandchild = create("Grandchild", f.shared.ID())
+
+	if err := store.CreateSpecCommitLink(&models.SpecCommitLink{SpecID: f.child.ID(), CommitID: "abc123", RepoPath: ".", LinkLabel: "implements"}); err != nil {
+		t.Fatalf("Failed to create commit link: %v", err)
+	}
+
+	if err := service.OrganizeNodes(""); err != nil {
+		t.Fatalf("Failed to organize nodes: %v", err)
+	}
+	f.parentFile = store.GetNodeFilePath(f.parent.ID())
+	return f
+}
+
+func (f *deletionFixture) assertDeleted(t *testing.T, ids ...string) {
+	t.Helper()
+
+	nodeFiles, err := f.store.GetAllNodeFileLinks()
+	if err != nil {
+		t.Fatalf("Failed to read node files: %v", err)
+	}
+	specLinks, err := f.store.ListSpecSpecLinks()
+	if err != nil {
+		t.Fatalf("Failed to list spec links: %v", err)
+	}
+	commitLinks, err := f.store.ListSpecCommitLinks()
+	if err != nil {
+		t.Fatalf("Failed to list commit links: %v", err)
+	}
+
+	for _, id := range ids {
+		if _, err := f.store.ReadNode(id); err == nil {
+			t.Errorf("Expected node %s to be deleted", id)
+		}
+		if _, ok := nodeFiles[id]; ok {
+			t.Errorf("Expected node %s to be removed from node-files.csv", id)
+		}
+		for _, link := range specLinks {
+			if link.FromSpecID == id || link.ToSpecID == id {
+				t.Errorf("Expected spec link %+v to be removed", link)
+			}
+		}
+		for _, link := range commitLinks {
+			if link.SpecID == id {
+				t.Errorf("Expected commit link %+v to be removed", link)
+			}
+		}
+	}
+}
+
+func (f *deletionFixture) rootMarkdown(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(f.store.GetNodeFilePath(f.root.ID()))
+	if err != nil {
+		t.Fatalf("Failed to read root markdown: %v", err)
+	}
+	return string(data)
+}
+
+func TestDeleteNode(t *testing.T) {
+	t.Run("RefuseWithChildren", func(t *testing.T) {
+		f := setupDeletionFixture(t)
+
+		_, err := f.service.DeleteNode(f.parent.ID(), DeleteRefuse)
+		zammErr, ok := err.(*models.ZammError)
+		if !ok || zammErr.Type != models.ErrTypeConflict {
+			t.Fatalf("Expected conflict error, got %v", err)
+		}
+		if _, err := f.store.ReadNode(f.parent.ID()); err != nil {
+			t.Errorf("Expected parent to survive a refused deletion: %v", err)
+		}
+	})
+
+	t.Run("RefuseRoot", func(t *testing.T) {
+		f := setupDeletionFixture(t)
+
+		if _, err := f.service.DeleteNode(f.root.ID(), DeleteCascade); err == nil {
+			t.Error("Expected deleting the root node to fail")
+		}
+	})
+
+	t.Run("Leaf", func(t *testing.T) {
+		f := setupDeletionFixture(t)
+
+		plan, err := f.service.DeleteNode(f.child.ID(), DeleteRefuse)
+		if err != nil {
+			t.Fatalf("Failed to delete leaf: %v", err)
+		}
+		if len(plan.RemovedCommitLinks) != 1 || len(plan.RemovedSpecLinks) != 1 {
+			t.Errorf("Expected one spec link and one commit link removed, got %+v", plan)
+		}
+		f.assertDeleted(t, f.child.ID())
+
+		data, err := os.ReadFile(f.parentFile)
+		if err != nil {
+			t.Fatalf("Failed to read parent markdown: %v", err)
+		}
+		if strings.Contains(string(data), "[Child]") {
+			t.Errorf("Expected parent's child links to drop the deleted child:\n%s", data)
+		}
+	})
+
+	t.Run("PlanDoesNotDelete", func(t *testing.T) {
+		f := setupDeletionFixture(t)
+
+		plan, err := f.service.PlanDeletion(f.parent.ID(), DeleteCascade)
+		if err != nil {
+			t.Fatalf("Failed to plan deletion: %v", err)
+		}
+		if len(plan.DeletedNodes) != 2 {
+			t.Errorf("Expected parent and child to be planned for deletion, got %+v", plan.DeletedNodes)
+		}
+		if _, err := f.store.ReadNode(f.child.ID()); err != nil {
+			t.Errorf("Expected planning not to delete anything: %v", err)
+		}
+	})
+
+	t.Run("Cascade", func(t *testing.T) {
+		f := setupDeletionFixture(t)
+
+		plan, err := f.service.DeleteNode(f.parent.ID(), DeleteCascade)
+		if err != nil {
+			t.Fatalf("Failed to cascade delete: %v", err)
+		}
+		if len(plan.DeletedNodes) != 2 {
+			t.Errorf("Expected parent and child to be deleted, got %+v", plan.DeletedNodes)
+		}
+		f.assertDeleted(t, f.parent.ID(), f.child.ID())
+
+		// Shared still has another parent, so it and its own child survive
+		parents, err := f.service.GetParents(f.shared.ID())
+		if err != nil {
+			t.Fatalf("Failed to get parents: %v", err)
+		}
+		if len(parents) != 1 || parents[0].ID() != f.other.ID() {
+			t.Errorf("Expected shared spec to keep only its other parent, got %v", parents)
+		}
+		if _, err := f.store.ReadNode(f.grandchild.ID()); err != nil {
+			t.Errorf("Expected grandchild to survive: %v", err)
+		}
+
+		if markdown := f.rootMarkdown(t); strings.Contains(markdown, "[Parent]") {
+			t.Errorf("Expected root's child links to drop the deleted parent:\n%s", markdown)
+		}
+	})
+
+	t.Run("Reparent", func(t *testing.T) {
+		f := setupDeletionFixture(t)
+
+		plan, err := f.service.DeleteNode(f.parent.ID(), DeleteReparent)
+		if err != nil {
+			t.Fatalf("Failed to reparent delete: %v", err)
+		}
+		if len(plan.DeletedNodes) != 1 || len(plan.AddedSpecLinks) != 2 {
+			t.Errorf("Expected only the parent deleted and two children moved, got %+v", plan)
+		}
+		f.assertDeleted(t, f.parent.ID())
+
+		children, err := f.service.GetChildren(f.root.ID())
+		if err != nil {
+			t.Fatalf("Failed to get children: %v", err)
+		}
+		titles := make(map[string]bool)
+		for _, child := range children {
+			titles[child.Title()] = true
+		}
+		if !titles["Child"] || !titles["Shared"] || !titles["Other"] || titles["Parent"] {
+			t.Errorf("Expected children to move under the root, got %v", titles)
+		}
+
+		if markdown := f.rootMarkdown(t); !strings.Contains(markdown, "[Child]") || strings.Contains(markdown, "[Parent]") {
+			t.Errorf("Expected root's child links to list the moved children:\n%s", markdown)
+		}
+	})
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// IssueKind identifies a kind of inconsistency in the .zamm store
+type IssueKind string
+
+const (
+	// IssueMissingFile means node-files.csv maps a node to a file that doesn't exist
+	IssueMissingFile IssueKind = "missing_file"
+	// IssueUnreadableFile means a mapped file exists but can't be parsed as a node
+	IssueUnreadableFile IssueKind = "unreadable_file"
+	// IssueUnmappedFile means a markdown file has a frontmatter ID that node-files.csv doesn't map to it
+	IssueUnmappedFile IssueKind = "unmapped_file"
+	// IssueDuplicateID means several markdown files share the same frontmatter ID
+	IssueDuplicateID IssueKind = "duplicate_id"
+	// IssueDanglingLink means a spec or commit link refers to a node that doesn't exist
+	IssueDanglingLink IssueKind = "dangling_link"
+	// IssueMissingRoot means the root ID in project_metadata.json doesn't resolve to a node
+	IssueMissingRoot IssueKind = "missing_root"
+	// IssueStaleChildren means a node's child links section doesn't match its children
+	IssueStaleChildren IssueKind = "stale_children"
+	// IssueCycle means the spec hierarchy loops back on itself
+	IssueCycle IssueKind = "cycle"
+)
+
+// DoctorIssue is a single inconsistency found in the .zamm store
+type DoctorIssue struct {
+	Kind    IssueKind `json:"kind"`
+	NodeID  string    `json:"node_id,omitempty"`
+	Message string    `json:"message"`
+	Fixable bool      `json:"fixable"`
+	Fixed   bool      `json:"fixed"`
+	Fix     string    `json:"fix,omitempty"`
+}
+
+// DoctorReport lists the inconsistencies found in the .zamm store, and any repairs made
+type DoctorReport struct {
+	Issues []DoctorIssue `json:"issues"`
+	Fixed  int           `json:"fixed"`
+}
+
+// Unresolved returns the number of issues that remain after any repairs
+func (r *DoctorReport) Unresolved() int {
+	return len(r.Issues) - r.Fixed
+}
+
+// DoctorService interface defines consistency checks over the .zamm store
+type DoctorService interface {
+	Diagnose(fix bool) (*DoctorReport, error)
+}
+
+// doctorService implements the DoctorService interface
+type doctorService struct {
+	storage     storage.Storage
+	specService SpecService
+}
+
+// NewDoctorService creates a new DoctorService instance
+func NewDoctorService(storage storage.Storage, specService SpecService) DoctorService {
+	return &doctorService{
+		storage:     storage,
+		specService: specService,
+	}
+}
+
+// doctorRun holds the state of a single Diagnose call
+type doctorRun struct {
+	service     *doctorService
+	fileStorage *storage.FileStorage
+	fix         bool
+	report      *DoctorReport
+}
+
+// Diagnose checks the node file mappings, links, root ID and child link sections of the
+// store. With fix set, it repairs what it can as it goes, so that later checks see the
+// repaired store.
+func (s *doctorService) Diagnose(fix bool) (*DoctorReport, error) {
+	fileStorage, ok := s.storage.(*storage.FileStorage)
+	if !ok {
+		return nil, models.NewZammError(models.ErrTypeValidation, "zamm doctor checks the file storage backend only")
+	}
+
+	run := &doctorRun{
+		service:     s,
+		fileStorage: fileStorage,
+		fix:         fix,
+		report:      &DoctorReport{Issues: make([]DoctorIssue, 0)},
+	}
+
+	checks := []func() error{
+		run.checkNodeFiles,
+		run.checkRoot,
+		run.checkSpecLinks,
+		run.checkCommitLinks,
+		run.checkCycles,
+		run.checkChildSections,
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return nil, err
+		}
+	}
+
+	return run.report, nil
+}
+
+// record adds an issue to the report. When repair is non-nil and fixing is enabled, it
+// is called to fix the issue.
+func (r *doctorRun) record(issue DoctorIssue, repair func() error) error {
+	issue.Fixable = repair != nil
+	if r.fix && repair != nil {
+		// Repairing an earlier issue can take care of this one too, such as when several
+		// dangling rows are removed together
+		if err := repair(); err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to fix %s issue: %w", issue.Kind, err)
+		}
+		issue.Fixed = true
+		r.report.Fixed++
+	}
+	r.report.Issues = append(r.report.Issues, issue)
+	return nil
+}
+
+// checkNodeFiles compares node-files.csv against the markdown files actually on disk
+func (r *doctorRun) checkNodeFiles() error {
+	mappings, err := r.fileStorage.GetAllNodeFileLinks()
+	if err != nil {
+		return fmt.Errorf("failed to read node file mappings: %w", err)
+	}
+	found, err := r.fileStorage.FindNodeFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, nodeID := range slices.Sorted(maps.Keys(mappings)) {
+		path := mappings[nodeID]
+		data, err := os.ReadFile(r.fileStorage.ResolveNodeFilePath(path))
+		if os.IsNotExist(err) {
+			issue := DoctorIssue{
+				Kind:    IssueMissingFile,
+				NodeID:  nodeID,
+				Message: fmt.Sprintf("mapped to %s, which does not exist", path),
+			}
+			if candidates := found[nodeID]; len(candidates) == 1 {
+				issue.Fix = "map to " + candidates[0]
+				if err := r.record(issue, func() error {
+					return r.fileStorage.SetNodeFilePath(nodeID, candidates[0])
+				}); err != nil {
+					return err
+				}
+			} else if len(candidates) == 0 {
+				issue.Fix = "remove mapping"
+				if err := r.record(issue, func() error {
+					return r.fileStorage.RemoveNodeFilePath(nodeID)
+				}); err != nil {
+					return err
+				}
+			} else if err := r.record(issue, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		node, err := storage.ParseNode(data)
+		if err != nil {
+			if err := r.record(DoctorIssue{
+				Kind:    IssueUnreadableFile,
+				NodeID:  nodeID,
+				Message: fmt.Sprintf("%s: %v", path, err),
+			}, nil); err != nil {
+				return err
+			}
+		} else if node.ID() != nodeID {
+			if err := r.record(DoctorIssue{
+				Kind:    IssueUnreadableFile,
+				NodeID:  nodeID,
+				Message: fmt.Sprintf("%s has ID %s in its frontmatter", path, node.ID()),
+			}, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, nodeID := range slices.Sorted(maps.Keys(found)) {
+		paths := found[nodeID]
+		if len(paths) > 1 {
+			if err := r.record(DoctorIssue{
+				Kind:    IssueDuplicateID,
+				NodeID:  nodeID,
+				Message: "found in " + strings.Join(paths, ", "),
+			}, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, mapped := mappings[nodeID]; mapped {
+			continue
+		}
+		if err := r.record(DoctorIssue{
+			Kind:    IssueUnmappedFile,
+			NodeID:  nodeID,
+			Message: paths[0] + " is not in node-files.csv",
+			Fix:     "map to " + paths[0],
+		}, func() error {
+			return r.fileStorage.SetNodeFilePath(nodeID, paths[0])
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkRoot makes sure the root ID in the project metadata resolves to a node. The root
+// is only repaired when there is exactly one project node to point it at.
+func (r *doctorRun) checkRoot() error {
+	metadata, err := r.service.storage.GetProjectMetadata()
+	if err != nil {
+		return fmt.Errorf("failed to get project metadata: %w", err)
+	}
+	if metadata.RootSpecID == nil {
+		return nil
+	}
+	if _, err := r.service.storage.ReadNode(*metadata.RootSpecID); err == nil {
+		return nil
+	}
+
+	issue := DoctorIssue{
+		Kind:    IssueMissingRoot,
+		NodeID:  *metadata.RootSpecID,
+		Message: "root ID in project_metadata.json does not resolve to a node",
+	}
+
+	nodes, err := r.service.storage.ListNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var projects []models.Node
+	for _, node := range nodes {
+		if node.Type() == "project" {
+			projects = append(projects, node)
+		}
+	}
+	if len(projects) != 1 {
+		return r.record(issue, nil)
+	}
+
+	rootID := projects[0].ID()
+	issue.Fix = "set root to project " + rootID
+	return r.record(issue, func() error {
+		return r.service.storage.SetRootSpecID(&rootID)
+	})
+}
+
+func (r *doctorRun) nodeExists(id string) bool {
+	_, err := r.service.storage.ReadNode(id)
+	return err == nil
+}
+
+// checkSpecLinks finds hierarchy links with a missing node at either end
+func (r *doctorRun) checkSpecLinks() error {
+	links, err := r.service.storage.ListSpecSpecLinks()
+	if err != nil {
+		return fmt.Errorf("failed to list spec links: %w", err)
+	}
+
+	for _, link := range links {
+		var missing []string
+		for _, id := range []string{link.FromSpecID, link.ToSpecID} {
+			if !r.nodeExists(id) {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		if err := r.record(DoctorIssue{
+			Kind:    IssueDanglingLink,
+			NodeID:  missing[0],
+			Message: fmt.Sprintf("spec link %s -> %s (%s) refers to missing node %s", link.FromSpecID, link.ToSpecID, link.LinkLabel, strings.Join(missing, ", ")),
+			Fix:     "remove link",
+		}, func() error {
+			return r.service.storage.DeleteSpecSpecLink(link.FromSpecID, link.ToSpecID)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCommitLinks finds commit links whose spec no longer exists
+func (r *doctorRun) checkCommitLinks() error {
+	links, err := r.service.storage.ListSpecCommitLinks()
+	if err != nil {
+		return fmt.Errorf("failed to list commit links: %w", err)
+	}
+
+	for _, link := range links {
+		if r.nodeExists(link.SpecID) {
+			continue
+		}
+
+		if err := r.record(DoctorIssue{
+			Kind:    IssueDanglingLink,
+			NodeID:  link.SpecID,
+			Message: fmt.Sprintf("commit %s in %s is linked to missing node (%s)", link.CommitID, link.RepoPath, link.LinkLabel),
+			Fix:     "remove link",
+		}, func() error {
+			return r.service.storage.DeleteSpecCommitLinkByFields(link.SpecID, link.CommitID, link.RepoPath)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCycles reports loops in the hierarchy, which need a person to decide which link to break
+func (r *doctorRun) checkCycles() error {
+	cycles, err := r.service.specService.FindCycles()
+	if err != nil {
+		return err
+	}
+
+	for _, cycle := range cycles {
+		if err := r.record(DoctorIssue{
+			Kind:    IssueCycle,
+			NodeID:  cycle.Nodes[0].ID,
+			Message: cycle.String(),
+		}, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkChildSections finds nodes whose child links section no longer matches their children
+func (r *doctorRun) checkChildSections() error {
+	nodes, err := r.service.storage.ListNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	for _, node := range nodes {
+		children, err := r.service.specService.GetOrganizedChildren(node)
+		if err != nil {
+			return err
+		}
+
+		current, err := r.fileStorage.HasCurrentChildSection(node, children)
+		if err != nil {
+			return fmt.Errorf("failed to check child section of node %s: %w", node.ID(), err)
+		}
+		if current {
+			continue
+		}
+
+		if err := r.record(DoctorIssue{
+			Kind:    IssueStaleChildren,
+			NodeID:  node.ID(),
+			Message: fmt.Sprintf("child links of %q are out of date", node.Title()),
+			Fix:     "regenerate child links",
+		}, func() error {
+			return r.service.storage.WriteNodeWithChildren(node, children)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
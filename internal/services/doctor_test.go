@@ -0,0 +1,210 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func TestDoctor(t *testing.T) {
+	projectDir := t.TempDir()
+	store, err := storage.New(filepath.Join(projectDir, ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	doctor := NewDoctorService(store, specService)
+
+	report, err := doctor.Diagnose(false)
+	if err != nil {
+		t.Fatalf("Failed to diagnose store: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Fatalf("Expected a fresh store to be consistent, got %+v", report.Issues)
+	}
+
+	// A child linked through storage, so the root's child links section isn't regenerated
+	child, err := specService.CreateSpec("Child", "Child content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	if err := store.CreateSpecSpecLink(&models.SpecSpecLink{FromSpecID: child.ID(), ToSpecID: root.ID(), LinkLabel: "child"}); err != nil {
+		t.Fatalf("Failed to link child: %v", err)
+	}
+
+	// A file moved by hand without updating node-files.csv
+	moved, err := specService.CreateSpec("Moved", "Moved content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	movedPath := filepath.Join("docs", "moved.md")
+	if err := os.MkdirAll(filepath.Join(projectDir, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create docs directory: %v", err)
+	}
+	if err := os.Rename(store.GetNodeFilePath(moved.ID()), filepath.Join(projectDir, movedPath)); err != nil {
+		t.Fatalf("Failed to move spec file: %v", err)
+	}
+
+	// A file added by hand, and links left behind by a node deleted by hand
+	unmapped := models.NewSpecWithID("unmapped-spec", "Unmapped", "Unmapped content")
+	if err := os.WriteFile(filepath.Join(projectDir, "docs", "unmapped.md"), []byte("---\nid: unmapped-spec\ntype: specification\n---\n\n# Unmapped\n\nUnmapped content\n"), 0644); err != nil {
+		t.Fatalf("Failed to write unmapped spec: %v", err)
+	}
+	if err := store.CreateSpecSpecLink(&models.SpecSpecLink{FromSpecID: "deleted-spec", ToSpecID: root.ID(), LinkLabel: "child"}); err != nil {
+		t.Fatalf("Failed to create spec link: %v", err)
+	}
+	if err := store.CreateSpecCommitLink(&models.SpecCommitLink{SpecID: "deleted-spec", CommitID: "abc123", RepoPath: ".", LinkLabel: "implements"}); err != nil {
+		t.Fatalf("Failed to create commit link: %v", err)
+	}
+
+	report, err = doctor.Diagnose(false)
+	if err != nil {
+		t.Fatalf("Failed to diagnose store: %v", err)
+	}
+
+	kinds := make(map[IssueKind]int)
+	for _, issue := range report.Issues {
+		kinds[issue.Kind]++
+		if issue.Fixed {
+			t.Errorf("Expected no fixes without fix mode, got %+v", issue)
+		}
+	}
+	expected := map[IssueKind]int{
+		IssueMissingFile:   1,
+		IssueUnmappedFile:  1,
+		IssueDanglingLink:  2,
+		IssueStaleChildren: 1,
+	}
+	for kind, count := range expected {
+		if kinds[kind] != count {
+			t.Errorf("Expected %d %s issues, got %d: %+v", count, kind, kinds[kind], report.Issues)
+		}
+	}
+
+	report, err = doctor.Diagnose(true)
+	if err != nil {
+		t.Fatalf("Failed to fix store: %v", err)
+	}
+	if report.Unresolved() != 0 {
+		t.Errorf("Expected every issue to be fixed, got %+v", report.Issues)
+	}
+
+	report, err = doctor.Diagnose(false)
+	if err != nil {
+		t.Fatalf("Failed to diagnose store: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("Expected repaired store to be consistent, got %+v", report.Issues)
+	}
+
+	if node, err := store.ReadNode(moved.ID()); err != nil || node.Title() != "Moved" {
+		t.Errorf("Expected moved spec to be readable from its new path, got %v, %v", node, err)
+	}
+	if node, err := store.ReadNode(unmapped.ID()); err != nil || node.Title() != unmapped.Title() {
+		t.Errorf("Expected unmapped spec to be mapped, got %v, %v", node, err)
+	}
+	children, err := specService.GetChildren(root.ID())
+	if err != nil {
+		t.Fatalf("Failed to get children: %v", err)
+	}
+	if len(children) != 1 || children[0].ID() != child.ID() {
+		t.Errorf("Expected only the real child to remain linked to the root, got %v", children)
+	}
+}
+
+func TestDoctorMissingRoot(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+
+	missingID := "missing-root"
+	if err := store.SetRootSpecID(&missingID); err != nil {
+		t.Fatalf("Failed to set root ID: %v", err)
+	}
+
+	report, err := NewDoctorService(store, specService).Diagnose(true)
+	if err != nil {
+		t.Fatalf("Failed to fix store: %v", err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != IssueMissingRoot || !report.Issues[0].Fixed {
+		t.Fatalf("Expected a fixed missing root issue, got %+v", report.Issues)
+	}
+
+	newRoot, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	if newRoot.ID() != root.ID() {
+		t.Errorf("Expected root to point at the only project %s, got %s", root.ID(), newRoot.ID())
+	}
+}
+
+func TestDoctorFixesDanglingLinksThatShareEnds(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".zamm")
+	store, err := storage.New(baseDir)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+
+	// Two commit links and two spec links left behind by a node deleted by hand, each pair
+	// differing only in its label
+	for _, label := range []string{"implements", "references"} {
+		if err := store.CreateSpecCommitLink(&models.SpecCommitLink{SpecID: "deleted-spec", CommitID: "abc123", RepoPath: ".", LinkLabel: label}); err != nil {
+			t.Fatalf("Failed to create commit link: %v", err)
+		}
+	}
+	specLinks := "from_spec_id,to_spec_id,link_label\ndeleted-spec," + root.ID() + ",child\ndeleted-spec," + root.ID() + ",related\n"
+	if err := os.WriteFile(filepath.Join(baseDir, "spec-links.csv"), []byte(specLinks), 0644); err != nil {
+		t.Fatalf("Failed to write spec links: %v", err)
+	}
+
+	doctor := NewDoctorService(store, specService)
+	report, err := doctor.Diagnose(true)
+	if err != nil {
+		t.Fatalf("Failed to fix store: %v", err)
+	}
+	dangling := 0
+	for _, issue := range report.Issues {
+		if issue.Kind == IssueDanglingLink {
+			dangling++
+		}
+	}
+	if dangling != 4 || report.Unresolved() != 0 {
+		t.Errorf("Expected all 4 dangling links to be reported and fixed, got %+v", report.Issues)
+	}
+
+	report, err = doctor.Diagnose(false)
+	if err != nil {
+		t.Fatalf("Failed to diagnose store: %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("Expected repaired store to be consistent, got %+v", report.Issues)
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// ImplementationLinkLabels lists the commit link labels whose commits are expected to
+// bring the implementation in line with the spec's contents
+var ImplementationLinkLabels = []string{"implements", "updates", "fixes"}
+
+// DriftStatus describes whether a spec's implementation is up to date with its contents
+type DriftStatus string
+
+const (
+	// DriftStatusCurrent means the spec has not changed since its newest implementing commit
+	DriftStatusCurrent DriftStatus = "current"
+	// DriftStatusStale means the spec's title or content changed after its newest implementing commit
+	DriftStatusStale DriftStatus = "stale"
+	// DriftStatusUnimplemented means no implementing commit could be found in the spec's repository
+	DriftStatusUnimplemented DriftStatus = "unimplemented"
+	// DriftStatusUntracked means the spec's file is not inside a Git repository
+	DriftStatusUntracked DriftStatus = "untracked"
+)
+
+// DriftReport lists, for every specification, whether it was edited after the commits that implemented it
+type DriftReport struct {
+	TotalSpecs int         `json:"total_specs"`
+	StaleSpecs int         `json:"stale_specs"`
+	Specs      []SpecDrift `json:"specs"`
+}
+
+// SpecDrift describes the drift status of a single specification. ChangedIn lists the
+// commits, newest first, that changed the spec's title or content and are not contained
+// in any of its implementing commits.
+type SpecDrift struct {
+	SpecID             string      `json:"spec_id"`
+	Title              string      `json:"title"`
+	FilePath           string      `json:"file_path"`
+	Status             DriftStatus `json:"status"`
+	ImplementedIn      string      `json:"implemented_in,omitempty"`
+	ChangedIn          []string    `json:"changed_in,omitempty"`
+	UncommittedChanges bool        `json:"uncommitted_changes"`
+}
+
+// DriftReport compares the Git history of every spec's markdown file against the ancestry
+// of its implementing commits. No timestamps are involved: a spec is stale when a commit
+// changed its title or content without being an ancestor of any implementing commit, or
+// when its file has uncommitted edits.
+func (s *reportService) DriftReport() (*DriftReport, error) {
+	fileStorage, ok := s.storage.(storage.NodeFileStorage)
+	if !ok {
+		return nil, models.NewZammError(models.ErrTypeValidation, "drift detection needs specs stored as files, which the configured storage backend doesn't do")
+	}
+
+	nodes, err := s.storage.ListNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	report := &DriftReport{Specs: make([]SpecDrift, 0)}
+	for _, node := range nodes {
+		if node.Type() != "specification" {
+			continue
+		}
+
+		path, err := filepath.Abs(fileStorage.GetNodeFilePath(node.ID()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve file path for node %s: %w", node.ID(), err)
+		}
+
+		drift, err := s.specDrift(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Specs = append(report.Specs, *drift)
+		report.TotalSpecs++
+		if drift.Status == DriftStatusStale {
+			report.StaleSpecs++
+		}
+	}
+
+	sort.Slice(report.Specs, func(i, j int) bool {
+		return report.Specs[i].FilePath < report.Specs[j].FilePath
+	})
+
+	return report, nil
+}
+
+func (s *reportService) specDrift(node models.Node, path string) (*SpecDrift, error) {
+	drift := &SpecDrift{
+		SpecID:   node.ID(),
+		Title:    node.Title(),
+		FilePath: path,
+	}
+
+	repoDir := filepath.Dir(path)
+	if !s.gitService.IsRepository(repoDir) {
+		drift.Status = DriftStatusUntracked
+		return drift, nil
+	}
+
+	links, err := s.storage.GetLinksBySpec(node.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit links for node %s: %w", node.ID(), err)
+	}
+
+	// Commits linked from other repositories cannot be compared against this file's history
+	implementing := make([]string, 0)
+	for _, link := range links {
+		if !isImplementationLinkLabel(link.LinkLabel) {
+			continue
+		}
+		if commitID, err := s.gitService.ResolveCommit(repoDir, link.CommitID); err == nil {
+			implementing = append(implementing, commitID)
+		}
+	}
+	if len(implementing) == 0 {
+		drift.Status = DriftStatusUnimplemented
+		return drift, nil
+	}
+
+	drift.ImplementedIn, err = s.gitService.NewestCommit(repoDir, implementing)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.gitService.FileHistory(repoDir, path, implementing)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if s.changesSpecContent(repoDir, change) {
+			drift.ChangedIn = append(drift.ChangedIn, change.CommitID)
+		}
+	}
+
+	drift.UncommittedChanges = s.hasUncommittedContentChanges(repoDir, path)
+
+	drift.Status = DriftStatusCurrent
+	if len(drift.ChangedIn) > 0 || drift.UncommittedChanges {
+		drift.Status = DriftStatusStale
+	}
+	return drift, nil
+}
+
+// changesSpecContent reports whether a commit touching a spec file changed its title or
+// content, as opposed to only moving the file or regenerating its frontmatter or child links
+func (s *reportService) changesSpecContent(repoDir string, change FileChange) bool {
+	if change.Path == "" {
+		// Merge commits carry no file status; the changes they bring in are reported by their parents
+		return false
+	}
+	if change.PreviousPath == "" {
+		return true
+	}
+
+	after, err := s.gitService.ShowFile(repoDir, change.CommitID, change.Path)
+	if err != nil {
+		return true
+	}
+	before, err := s.gitService.ShowFile(repoDir, change.CommitID+"^", change.PreviousPath)
+	if err != nil {
+		return true
+	}
+
+	return !sameSpecContent(before, after)
+}
+
+// hasUncommittedContentChanges reports whether the spec file in the working tree differs
+// in title or content from the version committed at HEAD
+func (s *reportService) hasUncommittedContentChanges(repoDir, path string) bool {
+	working, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	committed, err := s.gitService.ShowFile(repoDir, "HEAD", "./"+filepath.Base(path))
+	if err != nil {
+		return true
+	}
+
+	return !sameSpecContent(committed, working)
+}
+
+// sameSpecContent compares two versions of a node file by title and content only
+func sameSpecContent(a, b []byte) bool {
+	nodeA, err := storage.ParseNode(a)
+	if err != nil {
+		return false
+	}
+	nodeB, err := storage.ParseNode(b)
+	if err != nil {
+		return false
+	}
+	return nodeA.Title() == nodeB.Title() && nodeA.Content() == nodeB.Content()
+}
+
+func isImplementationLinkLabel(label string) bool {
+	for _, implementationLabel := range ImplementationLinkLabels {
+		if label == implementationLabel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// GitService provides access to the Git repositories that specs are linked against
+type GitService interface {
+	IsRepository(repoPath string) bool
+	ResolveCommit(repoPath, rev string) (string, error)
+	Log(repoPath string, opts LogOptions) ([]GitCommit, error)
+	HooksDir(repoPath string) (string, error)
+	TopLevel(repoPath string) (string, error)
+	SetConfig(repoPath, key, value string) error
+	FileHistory(repoPath, path string, excludeReachableFrom []string) ([]FileChange, error)
+	ShowFile(repoPath, rev, path string) ([]byte, error)
+	NewestCommit(repoPath string, commitIDs []string) (string, error)
+}
+
+// GitCommit is a single commit as returned by GitService.Log
+type GitCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// FileChange is a commit that touched a file, as returned by GitService.FileHistory.
+// Paths are relative to the repository root; PreviousPath differs from Path when the
+// commit renamed the file and is empty when the commit added it.
+type FileChange struct {
+	CommitID     string `json:"commit_id"`
+	Path         string `json:"path"`
+	PreviousPath string `json:"previous_path,omitempty"`
+}
+
+// LogOptions selects which commits GitService.Log returns
+type LogOptions struct {
+	RevRange string // e.g. "HEAD", "v1.0..HEAD"; defaults to HEAD
+	MaxCount int    // 0 means no limit
+	Reverse  bool   // oldest commits first
+}
+
+// gitCLIService implements GitService by shelling out to the local git binary
+type gitCLIService struct {
+	gitPath string
+}
+
+// NewGitService creates a new GitService instance backed by the local git binary
+func NewGitService() GitService {
+	return &gitCLIService{
+		gitPath: "git",
+	}
+}
+
+// IsRepository reports whether repoPath is inside a Git work tree
+func (s *gitCLIService) IsRepository(repoPath string) bool {
+	out, err := s.run(repoPath, "rev-parse", "--is-inside-work-tree")
+	return err == nil && out == "true"
+}
+
+// ResolveCommit resolves a revision (full or short hash, branch, tag, HEAD, ...)
+// to the full hash of the commit it points to
+func (s *gitCLIService) ResolveCommit(repoPath, rev string) (string, error) {
+	rev = strings.TrimSpace(rev)
+	if rev == "" {
+		return "", models.NewZammError(models.ErrTypeValidation, "commit ID cannot be empty")
+	}
+	if strings.HasPrefix(rev, "-") {
+		return "", models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("invalid revision: %s", rev))
+	}
+
+	out, err := s.run(repoPath, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", models.NewZammError(models.ErrTypeNotFound, fmt.Sprintf("commit %s not found in repository %s", rev, repoPath))
+		}
+		return "", models.NewZammErrorWithCause(models.ErrTypeGit, "failed to run git", err)
+	}
+
+	return out, nil
+}
+
+// Log lists commits reachable from opts.RevRange, newest first unless opts.Reverse is set
+func (s *gitCLIService) Log(repoPath string, opts LogOptions) ([]GitCommit, error) {
+	revRange := strings.TrimSpace(opts.RevRange)
+	if revRange == "" {
+		revRange = "HEAD"
+	}
+	if strings.HasPrefix(revRange, "-") {
+		return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("invalid revision range: %s", revRange))
+	}
+
+	args := []string{"log", "--format=%H%x00%B%x1e"}
+	if opts.MaxCount > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", opts.MaxCount))
+	}
+	if opts.Reverse {
+		args = append(args, "--reverse")
+	}
+	args = append(args, revRange, "--")
+
+	out, err := s.run(repoPath, args...)
+	if err != nil {
+		return nil, models.NewZammErrorWithCause(models.ErrTypeGit, fmt.Sprintf("failed to read git history for %s", revRange), err)
+	}
+
+	commits := make([]GitCommit, 0)
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+
+		parts := strings.SplitN(record, "\x00", 2)
+		if len(parts) < 2 {
+			continue // Skip malformed records
+		}
+
+		commits = append(commits, GitCommit{
+			ID:      parts[0],
+			Message: strings.TrimSpace(parts[1]),
+		})
+	}
+
+	return commits, nil
+}
+
+// HooksDir returns the absolute path of the directory git runs hooks from,
+// taking core.hooksPath into account
+func (s *gitCLIService) HooksDir(repoPath string) (string, error) {
+	out, err := s.run(repoPath, "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return "", models.NewZammErrorWithCause(models.ErrTypeGit, fmt.Sprintf("failed to locate hooks directory for %s", repoPath), err)
+	}
+	return out, nil
+}
+
+// TopLevel returns the absolute path of the root of the working tree repoPath is in
+func (s *gitCLIService) TopLevel(repoPath string) (string, error) {
+	out, err := s.run(repoPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", models.NewZammErrorWithCause(models.ErrTypeGit, fmt.Sprintf("%s is not in a git working tree", repoPath), err)
+	}
+	return out, nil
+}
+
+// SetConfig sets a value in the repository's local git config
+func (s *gitCLIService) SetConfig(repoPath, key, value string) error {
+	if _, err := s.run(repoPath, "config", "--local", key, value); err != nil {
+		return models.NewZammErrorWithCause(models.ErrTypeGit, fmt.Sprintf("failed to set git config %s", key), err)
+	}
+	return nil
+}
+
+// FileHistory lists the commits reachable from HEAD that touched path, following renames,
+// newest first. Commits reachable from any of excludeReachableFrom are left out, so
+// passing the commits that implemented a file's contents yields only the later changes.
+func (s *gitCLIService) FileHistory(repoPath, path string, excludeReachableFrom []string) ([]FileChange, error) {
+	args := []string{"log", "--follow", "--format=%x1e%H", "--name-status", "HEAD"}
+	if len(excludeReachableFrom) > 0 {
+		args = append(args, "--not")
+		for _, commitID := range excludeReachableFrom {
+			if strings.HasPrefix(commitID, "-") {
+				return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("invalid revision: %s", commitID))
+			}
+			args = append(args, commitID)
+		}
+	}
+	args = append(args, "--", path)
+
+	out, err := s.run(repoPath, args...)
+	if err != nil {
+		return nil, models.NewZammErrorWithCause(models.ErrTypeGit, fmt.Sprintf("failed to read git history for %s", path), err)
+	}
+
+	changes := make([]FileChange, 0)
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+
+		change := FileChange{CommitID: lines[0]}
+		for _, line := range lines[1:] {
+			fields := strings.Split(line, "\t")
+			if len(fields) < 2 {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(fields[0], "R") && len(fields) == 3:
+				change.PreviousPath, change.Path = fields[1], fields[2]
+			case fields[0] == "A":
+				change.Path = fields[1]
+			default:
+				change.Path, change.PreviousPath = fields[1], fields[1]
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// ShowFile returns the contents of path as of revision rev. Paths are relative to the
+// repository root unless they start with "./"
+func (s *gitCLIService) ShowFile(repoPath, rev, path string) ([]byte, error) {
+	if strings.HasPrefix(rev, "-") {
+		return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("invalid revision: %s", rev))
+	}
+
+	cmd := exec.Command(s.gitPath, "-C", repoPath, "show", rev+":"+path)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, models.NewZammError(models.ErrTypeNotFound, fmt.Sprintf("%s not found at %s", path, rev))
+		}
+		return nil, models.NewZammErrorWithCause(models.ErrTypeGit, "failed to run git", err)
+	}
+	return out, nil
+}
+
+// NewestCommit returns the commit among commitIDs that is not an ancestor of any of the others.
+// When several commits qualify, such as the tips of unmerged branches, the most recent one is returned.
+func (s *gitCLIService) NewestCommit(repoPath string, commitIDs []string) (string, error) {
+	if len(commitIDs) == 0 {
+		return "", models.NewZammError(models.ErrTypeValidation, "no commits given")
+	}
+
+	args := []string{"rev-list", "--topo-order", "--max-count=1"}
+	for _, commitID := range commitIDs {
+		if strings.HasPrefix(commitID, "-") {
+			return "", models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("invalid revision: %s", commitID))
+		}
+		args = append(args, commitID)
+	}
+
+	out, err := s.run(repoPath, args...)
+	if err != nil {
+		return "", models.NewZammErrorWithCause(models.ErrTypeGit, "failed to order commits", err)
+	}
+	return out, nil
+}
+
+// run executes a git subcommand in repoPath and returns its trimmed stdout
+func (s *gitCLIService) run(repoPath string, args ...string) (string, error) {
+	cmd := exec.Command(s.gitPath, append([]string{"-C", repoPath}, args...)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return "", fmt.Errorf("git %s: %s: %w", args[0], strings.TrimSpace(stderr.String()), err)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
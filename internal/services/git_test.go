@@ -0,0 +1,189 @@
+package services
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// setupTestRepo creates a temporary git repository with a single commit
+// and returns its path along with the full hash of that commit
+func setupTestRepo(t *testing.T) (string, string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repoDir := t.TempDir()
+	runGit(t, repoDir, "init", "-q", "-b", "main")
+	commitFile(t, repoDir, "README.md", "hello\n", "Initial commit")
+
+	return repoDir, runGit(t, repoDir, "rev-parse", "HEAD")
+}
+
+// runGit runs a git command in the given repository and returns its trimmed output
+func runGit(t *testing.T, repoDir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", repoDir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile writes a file into the repository and commits it with the given message
+func commitFile(t *testing.T, repoDir, name, content, message string) string {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	runGit(t, repoDir, "add", name)
+	runGit(t, repoDir, "commit", "-q", "-m", message)
+	return runGit(t, repoDir, "rev-parse", "HEAD")
+}
+
+func TestGitService_ResolveCommit(t *testing.T) {
+	repoDir, headCommit := setupTestRepo(t)
+	gitService := NewGitService()
+
+	testCases := []struct {
+		name string
+		rev  string
+	}{
+		{"full hash", headCommit},
+		{"short hash", headCommit[:7]},
+		{"branch", "main"},
+		{"HEAD", "HEAD"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolved, err := gitService.ResolveCommit(repoDir, tc.rev)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if resolved != headCommit {
+				t.Errorf("Expected %s, got %s", headCommit, resolved)
+			}
+		})
+	}
+}
+
+func TestGitService_ResolveCommit_Unknown(t *testing.T) {
+	repoDir, _ := setupTestRepo(t)
+	gitService := NewGitService()
+
+	for _, rev := range []string{"deadbeef", strings.Repeat("a", 40), "no-such-branch", "--all"} {
+		t.Run(rev, func(t *testing.T) {
+			_, err := gitService.ResolveCommit(repoDir, rev)
+			if err == nil {
+				t.Fatalf("Expected error resolving %q", rev)
+			}
+			if _, ok := err.(*models.ZammError); !ok {
+				t.Errorf("Expected ZammError, got %T", err)
+			}
+		})
+	}
+}
+
+func TestGitService_IsRepository(t *testing.T) {
+	repoDir, _ := setupTestRepo(t)
+	gitService := NewGitService()
+
+	if !gitService.IsRepository(repoDir) {
+		t.Error("Expected temp repo to be recognized as a repository")
+	}
+	if gitService.IsRepository(t.TempDir()) {
+		t.Error("Expected plain directory not to be recognized as a repository")
+	}
+}
+
+func TestLinkSpecToCommit_ResolvesRevision(t *testing.T) {
+	repoDir, headCommit := setupTestRepo(t)
+
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	linkService := NewLinkService(store, NewGitService())
+
+	spec, err := specService.CreateSpec("Linked Spec", "Spec content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+
+	link, err := linkService.LinkSpecToCommit(spec.ID(), headCommit[:8], repoDir, "implements")
+	if err != nil {
+		t.Fatalf("Failed to link spec to short hash: %v", err)
+	}
+	if link.CommitID != headCommit {
+		t.Errorf("Expected stored commit %s, got %s", headCommit, link.CommitID)
+	}
+
+	links, err := linkService.GetCommitsForSpec(spec.ID())
+	if err != nil {
+		t.Fatalf("Failed to get commits for spec: %v", err)
+	}
+	if len(links) != 1 || links[0].CommitID != headCommit {
+		t.Fatalf("Expected a single link to %s, got %+v", headCommit, links)
+	}
+
+	specs, err := linkService.GetSpecsForCommit("HEAD", repoDir)
+	if err != nil {
+		t.Fatalf("Failed to get specs for HEAD: %v", err)
+	}
+	if len(specs) != 1 || specs[0].ID() != spec.ID() {
+		t.Errorf("Expected HEAD to resolve to linked spec, got %d specs", len(specs))
+	}
+
+	if err := linkService.UnlinkSpecFromCommit(spec.ID(), "main", repoDir); err != nil {
+		t.Fatalf("Failed to unlink using branch name: %v", err)
+	}
+}
+
+func TestLinkSpecToCommit_RejectsUnknownCommit(t *testing.T) {
+	repoDir, _ := setupTestRepo(t)
+
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	linkService := NewLinkService(store, NewGitService())
+
+	spec, err := specService.CreateSpec("Linked Spec", "Spec content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+
+	typo := strings.Repeat("b", 40)
+	if _, err := linkService.LinkSpecToCommit(spec.ID(), typo, repoDir, "implements"); err == nil {
+		t.Fatal("Expected error linking to a commit that does not exist")
+	}
+
+	if _, err := linkService.LinkSpecToCommit(spec.ID(), "HEAD", t.TempDir(), "implements"); err == nil {
+		t.Fatal("Expected error linking against a directory that is not a repository")
+	}
+
+	links, err := linkService.GetCommitsForSpec(spec.ID())
+	if err != nil {
+		t.Fatalf("Failed to get commits for spec: %v", err)
+	}
+	if len(links) != 0 {
+		t.Errorf("Expected no links to be stored, got %d", len(links))
+	}
+}
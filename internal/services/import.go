@@ -0,0 +1,344 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// ImportedFile is a markdown file that becomes a node when imported. A directory's
+// README becomes the parent of the other files and directories next to it.
+type ImportedFile struct {
+	// Path is relative to the directory being imported
+	Path  string `json:"path"`
+	Title string `json:"title"`
+	// NodeID is set once the file is imported, or from the start for a README that is
+	// already a node, which new children are linked to without changing it
+	NodeID   string          `json:"node_id,omitempty"`
+	Existing bool            `json:"existing,omitempty"`
+	Children []*ImportedFile `json:"children,omitempty"`
+
+	content string
+}
+
+// SkippedFile is a markdown file that import leaves alone
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ImportPlan is the hierarchy of nodes that importing a directory creates
+type ImportPlan struct {
+	Directory   string          `json:"directory"`
+	ParentID    string          `json:"parent_id"`
+	ParentTitle string          `json:"parent_title"`
+	Files       []*ImportedFile `json:"files"`
+	Skipped     []SkippedFile   `json:"skipped"`
+	Warnings    []string        `json:"warnings"`
+}
+
+// Count returns the number of nodes the plan creates
+func (p *ImportPlan) Count() int {
+	var count func(files []*ImportedFile) int
+	count = func(files []*ImportedFile) int {
+		total := 0
+		for _, file := range files {
+			if !file.Existing {
+				total++
+			}
+			total += count(file.Children)
+		}
+		return total
+	}
+	return count(p.Files)
+}
+
+// ImportService interface defines how plain markdown documentation is turned into nodes
+type ImportService interface {
+	PlanImport(dir, parentID string) (*ImportPlan, error)
+	Import(dir, parentID string) (*ImportPlan, error)
+}
+
+// importService implements the ImportService interface
+type importService struct {
+	storage     storage.Storage
+	specService SpecService
+}
+
+// NewImportService creates a new ImportService instance
+func NewImportService(storage storage.Storage, specService SpecService) ImportService {
+	return &importService{
+		storage:     storage,
+		specService: specService,
+	}
+}
+
+// PlanImport works out the nodes that importing a directory of markdown would create,
+// without changing anything. New nodes go under the given parent, or the root node if
+// parentID is empty.
+func (s *importService) PlanImport(dir, parentID string) (*ImportPlan, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, models.NewZammErrorWithCause(models.ErrTypeValidation, fmt.Sprintf("cannot import %s", dir), err)
+	}
+	if !info.IsDir() {
+		return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("cannot import %s: not a directory", dir))
+	}
+
+	// The files stay where they are, so they have to be somewhere node-files.csv can point to
+	if fileStorage, ok := s.storage.(storage.NodeFileStorage); ok {
+		relDir, err := filepath.Rel(fileStorage.ProjectRoot(), absDir)
+		if err != nil || relDir == ".." || strings.HasPrefix(relDir, ".."+string(filepath.Separator)) {
+			zammErr := models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("cannot import %s: it is outside the project", dir))
+			zammErr.Details = fmt.Sprintf("imported files stay where they are, so move them under %s first", fileStorage.ProjectRoot())
+			return nil, zammErr
+		}
+	}
+
+	var parent models.Node
+	if parentID == "" {
+		parent, err = s.specService.GetRootNode()
+	} else {
+		parent, err = s.specService.ReadNode(parentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent node: %w", err)
+	}
+
+	plan := &ImportPlan{
+		Directory:   absDir,
+		ParentID:    parent.ID(),
+		ParentTitle: parent.Title(),
+		Skipped:     make([]SkippedFile, 0),
+		Warnings:    make([]string, 0),
+	}
+	plan.Files, err = s.planDirectory(plan, absDir, "")
+	if err != nil {
+		return nil, err
+	}
+	warnAboutDividers(plan, plan.Files)
+	return plan, nil
+}
+
+// planDirectory plans the files of one directory. With a README, the directory becomes a
+// single node holding everything else in it; without one, its contents join the
+// directory above.
+func (s *importService) planDirectory(plan *ImportPlan, absDir, relDir string) ([]*ImportedFile, error) {
+	entries, err := os.ReadDir(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", absDir, err)
+	}
+
+	var readme *ImportedFile
+	files := make([]*ImportedFile, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue // .zamm, .git and other hidden directories aren't documentation
+		}
+		relPath := filepath.Join(relDir, name)
+
+		if entry.IsDir() {
+			children, err := s.planDirectory(plan, filepath.Join(absDir, name), relPath)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, children...)
+			continue
+		}
+		if !strings.EqualFold(filepath.Ext(name), ".md") {
+			continue
+		}
+
+		isReadme := strings.EqualFold(name, IndexFile)
+		file, err := s.planFile(plan, filepath.Join(absDir, name), relPath, isReadme)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil {
+			continue
+		}
+		if isReadme {
+			readme = file
+		} else {
+			files = append(files, file)
+		}
+	}
+
+	if readme == nil {
+		return files, nil
+	}
+	readme.Children = files
+	return []*ImportedFile{readme}, nil
+}
+
+// planFile reads a markdown file, returning nil if it is skipped
+func (s *importService) planFile(plan *ImportPlan, absPath, relPath string, isReadme bool) (*ImportedFile, error) {
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", absPath, err)
+	}
+
+	if strings.HasPrefix(string(data), "---\n") {
+		node, err := storage.ParseNode(data)
+		if err != nil || node.ID() == "" {
+			plan.Skipped = append(plan.Skipped, SkippedFile{Path: relPath, Reason: "has frontmatter that isn't a zamm node"})
+			return nil, nil
+		}
+		if _, err := s.specService.ReadNode(node.ID()); err != nil {
+			plan.Skipped = append(plan.Skipped, SkippedFile{Path: relPath, Reason: fmt.Sprintf("is node %s, which isn't in this project", node.ID())})
+			return nil, nil
+		}
+		if !isReadme {
+			plan.Skipped = append(plan.Skipped, SkippedFile{Path: relPath, Reason: fmt.Sprintf("is already node %s", node.ID())})
+			return nil, nil
+		}
+		return &ImportedFile{Path: relPath, Title: node.Title(), NodeID: node.ID(), Existing: true}, nil
+	}
+
+	title, content := splitTitle(string(data))
+	if title == "" {
+		name := strings.TrimSuffix(filepath.Base(absPath), filepath.Ext(absPath))
+		if isReadme {
+			name = filepath.Base(filepath.Dir(absPath))
+		}
+		title = titleFromFileName(name)
+	}
+	return &ImportedFile{Path: relPath, Title: title, content: content}, nil
+}
+
+// Import creates a node for every new file in the plan for a directory, links each to
+// the node of the README above it and records the files in node-files.csv where they
+// are, adding frontmatter to them. Either everything is imported or nothing is. The plan
+// is worked out inside the transaction, so the nodes it builds on can't change under it.
+func (s *importService) Import(dir, parentID string) (*ImportPlan, error) {
+	var plan *ImportPlan
+	err := s.storage.Transaction(func(tx storage.Storage) error {
+		s := &importService{storage: tx, specService: NewSpecService(tx)}
+		var err error
+		if plan, err = s.PlanImport(dir, parentID); err != nil {
+			return err
+		}
+
+		parents := make([]string, 0)
+		if len(plan.Files) > 0 {
+			parents = append(parents, plan.ParentID)
+		}
+		for _, file := range plan.Files {
+			if err := s.importFile(plan, file, plan.ParentID, &parents); err != nil {
+				return err
+			}
+		}
+
+		// Regenerate the child links of every node that gained children
+		for _, id := range parents {
+			node, err := s.storage.ReadNode(id)
+			if err != nil {
+				return fmt.Errorf("failed to read node %s: %w", id, err)
+			}
+			children, err := s.specService.GetOrganizedChildren(node)
+			if err != nil {
+				return err
+			}
+			if err := s.storage.WriteNodeWithChildren(node, children); err != nil {
+				return fmt.Errorf("failed to write child links of node %s: %w", id, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *importService) importFile(plan *ImportPlan, file *ImportedFile, parentID string, parents *[]string) error {
+	if !file.Existing {
+		node := models.NewSpec(file.Title, file.content)
+		file.NodeID = node.ID()
+
+		if fileStorage, ok := s.storage.(storage.NodeFileStorage); ok {
+			path, err := filepath.Rel(fileStorage.ProjectRoot(), filepath.Join(plan.Directory, file.Path))
+			if err != nil {
+				return fmt.Errorf("failed to locate %s: %w", file.Path, err)
+			}
+			if err := fileStorage.SetNodeFilePath(node.ID(), path); err != nil {
+				return err
+			}
+		}
+		if err := s.storage.WriteNode(node); err != nil {
+			return fmt.Errorf("failed to import %s: %w", file.Path, err)
+		}
+		if _, err := s.specService.AddChildToParent(node.ID(), parentID, "child"); err != nil {
+			return fmt.Errorf("failed to link %s: %w", file.Path, err)
+		}
+	}
+
+	if len(file.Children) > 0 {
+		*parents = append(*parents, file.NodeID)
+	}
+	for _, child := range file.Children {
+		if err := s.importFile(plan, child, file.NodeID, parents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTitle takes the first level 1 heading outside code blocks as the title, returning
+// the rest of the markdown as the content
+func splitTitle(markdown string) (string, string) {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	inCode := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || !strings.HasPrefix(line, "# ") {
+			continue
+		}
+
+		title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[2:]), "#"))
+		before := strings.TrimSpace(strings.Join(lines[:i], "\n"))
+		after := strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+		if before == "" || after == "" {
+			return title, before + after
+		}
+		return title, before + "\n\n" + after
+	}
+	return "", strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// titleFromFileName turns a name like getting-started into Getting started
+func titleFromFileName(name string) string {
+	title := strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+	if title == "" {
+		return "Untitled"
+	}
+	first, size := utf8.DecodeRuneInString(title)
+	return string(unicode.ToUpper(first)) + title[size:]
+}
+
+// warnAboutDividers warns about files without children that have a --- divider. zamm
+// takes everything after the last divider for the child links section, so the text
+// below it would be lost. Files with children get a real child links section after it.
+func warnAboutDividers(plan *ImportPlan, files []*ImportedFile) {
+	for _, file := range files {
+		if len(file.Children) == 0 && strings.Contains("\n"+file.content+"\n", "\n---\n") {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s has a --- divider, and the text after the last one would be read as child links and dropped", file.Path))
+		}
+		warnAboutDividers(plan, file.Children)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestImportMarkdownTree(t *testing.T) {
+	projectDir := t.TempDir()
+	store, err := storage.New(filepath.Join(projectDir, ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	importService := NewImportService(store, specService)
+
+	guideDir := filepath.Join(projectDir, "guide")
+	writeTestFile(t, filepath.Join(guideDir, "README.md"), "# User guide\n\nHow to use it.\n")
+	writeTestFile(t, filepath.Join(guideDir, "install.md"), "```\n# not a title\n```\n\n# Installing\n\nRun make.\n")
+	writeTestFile(t, filepath.Join(guideDir, "extras", "getting-started.md"), "No heading here.\n")
+	writeTestFile(t, filepath.Join(guideDir, "notes.txt"), "Not markdown\n")
+
+	plan, err := importService.PlanImport(guideDir, "")
+	if err != nil {
+		t.Fatalf("Failed to plan import: %v", err)
+	}
+	if plan.Count() != 3 || len(plan.Files) != 1 {
+		t.Fatalf("Expected the README to hold two files, got %d file(s) in %+v", plan.Count(), plan.Files)
+	}
+	readme := plan.Files[0]
+	if readme.Title != "User guide" || len(readme.Children) != 2 {
+		t.Fatalf("Expected the README to be the parent, got %+v", readme)
+	}
+	if readme.Children[0].Title != "Getting started" || readme.Children[1].Title != "Installing" {
+		t.Errorf("Expected titles from the file name and the first heading, got %q and %q", readme.Children[0].Title, readme.Children[1].Title)
+	}
+	nodes, err := store.ListNodes()
+	if err != nil {
+		t.Fatalf("Failed to list nodes: %v", err)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("Expected planning to create nothing, got %d node(s)", len(nodes))
+	}
+
+	plan, err = importService.Import(guideDir, "")
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	readme = plan.Files[0]
+	if got := store.GetNodeFilePath(readme.NodeID); got != filepath.Join(guideDir, "README.md") {
+		t.Errorf("Expected the README to stay where it is, got %s", got)
+	}
+	parents, err := specService.GetParents(readme.NodeID)
+	if err != nil || len(parents) != 1 || parents[0].ID() != root.ID() {
+		t.Errorf("Expected the README under the root, got %v (%v)", parents, err)
+	}
+	children, err := specService.GetChildren(readme.NodeID)
+	if err != nil || len(children) != 2 {
+		t.Fatalf("Expected the README to have two children, got %v (%v)", children, err)
+	}
+
+	installed, err := specService.ReadNode(readme.Children[1].NodeID)
+	if err != nil {
+		t.Fatalf("Failed to read imported node: %v", err)
+	}
+	if installed.Title() != "Installing" || installed.Content() != "```\n# not a title\n```\n\nRun make." {
+		t.Errorf("Expected the heading to become the title, got %q with content %q", installed.Title(), installed.Content())
+	}
+	data, err := os.ReadFile(filepath.Join(guideDir, "README.md"))
+	if err != nil {
+		t.Fatalf("Failed to read README: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "---\nid: "+readme.NodeID) || !strings.Contains(string(data), "[Installing](install.md)") {
+		t.Errorf("Expected frontmatter and child links in the README, got:\n%s", data)
+	}
+
+	// Importing again only picks up new files, under the README that is now a node
+	writeTestFile(t, filepath.Join(guideDir, "faq.md"), "# FAQ\n\nAsk away.\n")
+	plan, err = importService.Import(guideDir, "")
+	if err != nil {
+		t.Fatalf("Failed to import again: %v", err)
+	}
+	if plan.Count() != 1 || !plan.Files[0].Existing || len(plan.Skipped) != 2 {
+		t.Fatalf("Expected only the new file to be imported, got %d with %+v skipped", plan.Count(), plan.Skipped)
+	}
+	children, err = specService.GetChildren(readme.NodeID)
+	if err != nil || len(children) != 3 {
+		t.Errorf("Expected the new file under the existing README, got %v (%v)", children, err)
+	}
+}
+
+func TestImportRejectsDirectoriesOutsideProject(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+
+	if _, err := NewImportService(store, specService).PlanImport(t.TempDir(), ""); err == nil {
+		t.Error("Expected a directory outside the project to be rejected")
+	}
+}
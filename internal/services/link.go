@@ -16,17 +16,40 @@ type LinkService interface {
 	GetSpecsForCommit(commitID, repoPath string) ([]*models.Spec, error)
 	GetCommitsForSpec(specID string) ([]*models.SpecCommitLink, error)
 	UnlinkSpecFromCommit(specID, commitID, repoPath string) error
+
+	// Trailer-based linking
+	ResolveSpecReference(reference string) (*models.Spec, error)
+	CheckTrailers(message string) []UnresolvedTrailer
+	ScanCommits(repoPath string, opts LogOptions) (*TrailerScanResult, error)
+}
+
+// TrailerScanResult summarizes the links found while scanning commit message trailers
+type TrailerScanResult struct {
+	CommitsScanned int                      `json:"commits_scanned"`
+	Created        []*models.SpecCommitLink `json:"created"`
+	Existing       []*models.SpecCommitLink `json:"existing"`
+	Unresolved     []UnresolvedTrailer      `json:"unresolved"`
+}
+
+// UnresolvedTrailer is a spec trailer that could not be turned into a link
+type UnresolvedTrailer struct {
+	CommitID  string `json:"commit_id,omitempty"`
+	Label     string `json:"label"`
+	Reference string `json:"reference"`
+	Reason    string `json:"reason"`
 }
 
 // linkService implements the LinkService interface
 type linkService struct {
-	storage storage.Storage
+	storage    storage.Storage
+	gitService GitService
 }
 
 // NewLinkService creates a new LinkService instance
-func NewLinkService(storage storage.Storage) LinkService {
+func NewLinkService(storage storage.Storage, gitService GitService) LinkService {
 	return &linkService{
-		storage: storage,
+		storage:    storage,
+		gitService: gitService,
 	}
 }
 
@@ -51,9 +74,15 @@ func (s *linkService) LinkSpecToCommit(specID, commitID, repoPath, label string)
 		return nil, err
 	}
 
+	// Resolve the revision to the full hash of a commit that exists in the repository
+	resolvedCommitID, err := s.gitService.ResolveCommit(strings.TrimSpace(repoPath), commitID)
+	if err != nil {
+		return nil, err
+	}
+
 	link := &models.SpecCommitLink{
 		SpecID:    specID,
-		CommitID:  strings.TrimSpace(commitID),
+		CommitID:  resolvedCommitID,
 		RepoPath:  strings.TrimSpace(repoPath),
 		LinkLabel: strings.TrimSpace(label),
 	}
@@ -67,7 +96,8 @@ func (s *linkService) LinkSpecToCommit(specID, commitID, repoPath, label string)
 
 // GetSpecsForCommit retrieves all specs linked to a commit
 func (s *linkService) GetSpecsForCommit(commitID, repoPath string) ([]*models.Spec, error) {
-	if err := s.validateCommitInput(commitID, repoPath); err != nil {
+	commitID, err := s.lookupCommitID(commitID, repoPath)
+	if err != nil {
 		return nil, err
 	}
 
@@ -122,17 +152,149 @@ func (s *linkService) UnlinkSpecFromCommit(specID, commitID, repoPath string) er
 		return err
 	}
 
+	commitID, err := s.lookupCommitID(commitID, repoPath)
+	if err != nil {
+		return err
+	}
+
 	return s.storage.DeleteSpecCommitLinkByFields(specID, commitID, repoPath)
 }
 
-// validateLinkInput validates input for link operations
+// ResolveSpecReference finds the spec referred to by a spec ID or slug
+func (s *linkService) ResolveSpecReference(reference string) (*models.Spec, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return nil, models.NewZammError(models.ErrTypeValidation, "spec reference cannot be empty")
+	}
+
+	if node, err := s.storage.ReadNode(reference); err == nil {
+		if spec, ok := node.(*models.Spec); ok {
+			return spec, nil
+		}
+		return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("node %s is not a spec", reference))
+	}
+
+	nodes, err := s.storage.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*models.Spec
+	for _, node := range nodes {
+		if spec, ok := node.(*models.Spec); ok && spec.Slug() == reference {
+			matches = append(matches, spec)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, models.NewZammError(models.ErrTypeNotFound, fmt.Sprintf("no spec found with ID or slug %s", reference))
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, match := range matches {
+			ids = append(ids, match.ID())
+		}
+		zammErr := models.NewZammError(models.ErrTypeConflict, fmt.Sprintf("slug %s is ambiguous", reference))
+		zammErr.Details = "matches " + strings.Join(ids, ", ")
+		return nil, zammErr
+	}
+}
+
+// CheckTrailers returns the spec trailers in a commit message that do not resolve to a spec
+func (s *linkService) CheckTrailers(message string) []UnresolvedTrailer {
+	unresolved := make([]UnresolvedTrailer, 0)
+	for _, trailer := range ParseSpecTrailers(message) {
+		if _, err := s.ResolveSpecReference(trailer.Reference); err != nil {
+			unresolved = append(unresolved, UnresolvedTrailer{
+				Label:     trailer.Label,
+				Reference: trailer.Reference,
+				Reason:    err.Error(),
+			})
+		}
+	}
+	return unresolved
+}
+
+// ScanCommits walks the git history selected by opts and links every commit to the
+// specs referenced in its message trailers. Links that already exist are left alone.
+func (s *linkService) ScanCommits(repoPath string, opts LogOptions) (*TrailerScanResult, error) {
+	if err := s.validateRepoPath(repoPath); err != nil {
+		return nil, err
+	}
+	repoPath = strings.TrimSpace(repoPath)
+
+	commits, err := s.gitService.Log(repoPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TrailerScanResult{
+		CommitsScanned: len(commits),
+		Created:        make([]*models.SpecCommitLink, 0),
+		Existing:       make([]*models.SpecCommitLink, 0),
+		Unresolved:     make([]UnresolvedTrailer, 0),
+	}
+
+	for _, commit := range commits {
+		for _, trailer := range ParseSpecTrailers(commit.Message) {
+			spec, err := s.ResolveSpecReference(trailer.Reference)
+			if err != nil {
+				result.Unresolved = append(result.Unresolved, UnresolvedTrailer{
+					CommitID:  commit.ID,
+					Label:     trailer.Label,
+					Reference: trailer.Reference,
+					Reason:    err.Error(),
+				})
+				continue
+			}
+
+			existing, err := s.findLink(spec.ID(), commit.ID, repoPath, trailer.Label)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				result.Existing = append(result.Existing, existing)
+				continue
+			}
+
+			link, err := s.LinkSpecToCommit(spec.ID(), commit.ID, repoPath, trailer.Label)
+			if err != nil {
+				return nil, err
+			}
+			result.Created = append(result.Created, link)
+		}
+	}
+
+	return result, nil
+}
+
+// findLink returns the existing link matching all fields, or nil if there is none
+func (s *linkService) findLink(specID, commitID, repoPath, label string) (*models.SpecCommitLink, error) {
+	links, err := s.storage.GetLinksByCommit(commitID, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, link := range links {
+		if link.SpecID == specID && link.LinkLabel == label {
+			return link, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// validateLinkInput validates input for link operations. The commit ID may be
+// any revision that git can resolve, so only its presence is checked here.
 func (s *linkService) validateLinkInput(specID, commitID, repoPath, label string) error {
 	if specID == "" {
 		return models.NewZammError(models.ErrTypeValidation, "spec ID cannot be empty")
 	}
 
-	if err := s.validateCommitID(commitID); err != nil {
-		return err
+	if strings.TrimSpace(commitID) == "" {
+		return models.NewZammError(models.ErrTypeValidation, "commit ID cannot be empty")
 	}
 
 	if repoPath == "" {
@@ -142,17 +304,24 @@ func (s *linkService) validateLinkInput(specID, commitID, repoPath, label string
 	return nil
 }
 
-// validateCommitInput validates commit-related input
-func (s *linkService) validateCommitInput(commitID, repoPath string) error {
-	if err := s.validateCommitID(commitID); err != nil {
-		return err
+// lookupCommitID returns the full commit hash to look up existing links with.
+// Full hashes are used as-is so that links to commits which no longer exist in
+// the repository can still be found; anything else is resolved through git.
+func (s *linkService) lookupCommitID(commitID, repoPath string) (string, error) {
+	if repoPath == "" {
+		return "", models.NewZammError(models.ErrTypeValidation, "repository path cannot be empty")
 	}
 
-	if repoPath == "" {
-		return models.NewZammError(models.ErrTypeValidation, "repository path cannot be empty")
+	hexErr := s.validateCommitID(commitID)
+	if hexErr == nil {
+		return strings.TrimSpace(commitID), nil
 	}
 
-	return nil
+	if strings.TrimSpace(commitID) == "" || !s.gitService.IsRepository(repoPath) {
+		return "", hexErr
+	}
+
+	return s.gitService.ResolveCommit(repoPath, commitID)
 }
 
 // validateCommitID validates a Git commit hash
@@ -203,9 +372,8 @@ func (s *linkService) validateRepoPath(repoPath string) error {
 		return models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("repository path is not a directory: %s", repoPath))
 	}
 
-	// Check if it's a Git repository (contains .git directory)
-	gitPath := fmt.Sprintf("%s/.git", repoPath)
-	if _, err := os.Stat(gitPath); os.IsNotExist(err) {
+	// Check if it's inside a Git work tree
+	if !s.gitService.IsRepository(repoPath) {
 		return models.NewZammError(models.ErrTypeGit, fmt.Sprintf("path is not a Git repository: %s", repoPath))
 	}
 
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Migration upgrades the store from the previous schema version to Version
+type Migration struct {
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// up makes the migration's changes, or with dryRun only works out what they would be,
+	// and describes each change
+	up func(s *migrationService, dryRun bool) ([]string, error)
+}
+
+// migrations lists every change to the store format in order. Each one moves the store
+// to its version, the last of which must be storage.SchemaVersion.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Name:        "title-to-heading",
+		Description: "Move node titles out of the frontmatter into a level 1 heading",
+		up:          migrateTitlesToHeadings,
+	},
+	{
+		Version:     2,
+		Name:        "nodes-to-docs",
+		Description: "Organize node files left in .zamm/nodes into the docs/ hierarchy",
+		up:          migrateNodesToDocs,
+	},
+}
+
+// Migrations returns every migration in order
+func Migrations() []Migration {
+	return migrations
+}
+
+// MigrationStatus describes how far the store is from the format this zamm writes
+type MigrationStatus struct {
+	CurrentVersion int         `json:"current_version"`
+	LatestVersion  int         `json:"latest_version"`
+	Pending        []Migration `json:"pending"`
+}
+
+// AppliedMigration records the changes one migration made, or would make in a dry run
+type AppliedMigration struct {
+	Migration
+	Changes []string `json:"changes"`
+}
+
+// MigrationReport describes a run of the pending migrations
+type MigrationReport struct {
+	DryRun      bool               `json:"dry_run"`
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	Migrations  []AppliedMigration `json:"migrations"`
+}
+
+// MigrationService interface defines upgrades of the store format
+type MigrationService interface {
+	Status() (*MigrationStatus, error)
+	Up(dryRun bool) (*MigrationReport, error)
+}
+
+// migrationService implements the MigrationService interface
+type migrationService struct {
+	storage     storage.Storage
+	specService SpecService
+}
+
+// NewMigrationService creates a new MigrationService instance
+func NewMigrationService(storage storage.Storage, specService SpecService) MigrationService {
+	return &migrationService{
+		storage:     storage,
+		specService: specService,
+	}
+}
+
+// Status returns the store's schema version and the migrations it hasn't had yet
+func (s *migrationService) Status() (*MigrationStatus, error) {
+	metadata, err := s.storage.GetProjectMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project metadata: %w", err)
+	}
+
+	status := &MigrationStatus{
+		CurrentVersion: metadata.SchemaVersion,
+		LatestVersion:  storage.SchemaVersion,
+		Pending:        make([]Migration, 0),
+	}
+	for _, migration := range migrations {
+		if migration.Version > metadata.SchemaVersion {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Up applies the pending migrations in order, each in its own transaction along with the
+// schema version it brings the store to. With dryRun nothing is changed, and the report
+// lists what each migration would do to the store as it is now.
+func (s *migrationService) Up(dryRun bool) (*MigrationReport, error) {
+	status, err := s.Status()
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CheckSchemaVersion(status.CurrentVersion); err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{
+		DryRun:      dryRun,
+		FromVersion: status.CurrentVersion,
+		ToVersion:   status.CurrentVersion,
+		Migrations:  make([]AppliedMigration, 0, len(status.Pending)),
+	}
+	for _, migration := range status.Pending {
+		var changes []string
+		if dryRun {
+			changes, err = migration.up(s, true)
+		} else {
+			err = s.storage.Transaction(func(tx storage.Storage) error {
+				s := &migrationService{storage: tx, specService: NewSpecService(tx)}
+				var upErr error
+				if changes, upErr = migration.up(s, false); upErr != nil {
+					return upErr
+				}
+				return s.storage.SetSchemaVersion(migration.Version)
+			})
+		}
+		if err != nil {
+			return report, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+
+		if changes == nil {
+			changes = make([]string, 0)
+		}
+		report.Migrations = append(report.Migrations, AppliedMigration{Migration: migration, Changes: changes})
+		report.ToVersion = migration.Version
+	}
+	return report, nil
+}
+
+// nodeFileStorage returns the store as file storage, for migrations that only concern
+// files. Other backends never held nodes in an older format.
+func (s *migrationService) nodeFileStorage() (storage.NodeFileStorage, bool) {
+	fileStorage, ok := s.storage.(storage.NodeFileStorage)
+	return fileStorage, ok
+}
+
+// migrateTitlesToHeadings rewrites node files that still keep their title in the
+// frontmatter, which the current format renders as a level 1 heading instead
+func migrateTitlesToHeadings(s *migrationService, dryRun bool) ([]string, error) {
+	fileStorage, ok := s.nodeFileStorage()
+	if !ok {
+		return nil, nil
+	}
+
+	nodes, err := s.storage.ListNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	changes := make([]string, 0)
+	for _, node := range nodes {
+		path := fileStorage.GetNodeFilePath(node.ID())
+		hasTitle, err := frontmatterHasTitle(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !hasTitle {
+			continue
+		}
+
+		changes = append(changes, fmt.Sprintf("rewrite %s with %q as its heading", path, node.Title()))
+		if dryRun {
+			continue
+		}
+
+		children, err := s.specService.GetOrganizedChildren(node)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.storage.WriteNodeWithChildren(node, children); err != nil {
+			return nil, fmt.Errorf("failed to rewrite node %s: %w", node.ID(), err)
+		}
+	}
+	return changes, nil
+}
+
+// frontmatterHasTitle reports whether a node file lists its title in its frontmatter
+func frontmatterHasTitle(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	content := string(data)
+	if !strings.HasPrefix(content, "---\n") {
+		return false, nil
+	}
+	yamlContent, _, found := strings.Cut(content[4:], "\n---\n")
+	if !found {
+		return false, nil
+	}
+
+	var frontmatter map[string]interface{}
+	if err := yaml.Unmarshal([]byte(yamlContent), &frontmatter); err != nil {
+		return false, nil // Unparseable files are for zamm doctor to report
+	}
+	_, hasTitle := frontmatter["title"]
+	return hasTitle, nil
+}
+
+// migrateNodesToDocs organizes the hierarchy into docs/ if node files are still in their
+// original location of .zamm/nodes/<id>.md
+func migrateNodesToDocs(s *migrationService, dryRun bool) ([]string, error) {
+	fileStorage, ok := s.nodeFileStorage()
+	if !ok {
+		return nil, nil
+	}
+
+	if _, err := s.specService.GetRootNode(); err != nil {
+		if zammErr, ok := err.(*models.ZammError); ok && zammErr.Type == models.ErrTypeNotFound {
+			// Without a root there is no hierarchy to organize the files into
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	nodes, err := s.storage.ListNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	changes := make([]string, 0)
+	for _, node := range nodes {
+		path := fileStorage.GetNodeFilePath(node.ID())
+		if filepath.Base(path) == node.ID()+".md" && filepath.Base(filepath.Dir(path)) == "nodes" {
+			changes = append(changes, fmt.Sprintf("organize %s into %s/", path, DocumentationRoot))
+		}
+	}
+	if len(changes) == 0 || dryRun {
+		return changes, nil
+	}
+
+	if err := s.specService.OrganizeNodes(""); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
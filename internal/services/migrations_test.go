@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func TestMigrationsEndAtSchemaVersion(t *testing.T) {
+	for i, migration := range Migrations() {
+		if migration.Version != i+1 {
+			t.Errorf("Expected migration %s to have version %d, got %d", migration.Name, i+1, migration.Version)
+		}
+	}
+	if last := Migrations()[len(Migrations())-1]; last.Version != storage.SchemaVersion {
+		t.Errorf("Expected the last migration to reach schema version %d, got %d", storage.SchemaVersion, last.Version)
+	}
+}
+
+func TestMigrateUp(t *testing.T) {
+	projectDir := t.TempDir()
+	store, err := storage.New(filepath.Join(projectDir, ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	child, err := specService.CreateSpec("Child", "Child content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	if _, err := specService.AddChildToParent(child.ID(), root.ID(), "child"); err != nil {
+		t.Fatalf("Failed to link child: %v", err)
+	}
+	migrations := NewMigrationService(store, specService)
+
+	status, err := migrations.Status()
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	if status.CurrentVersion != storage.SchemaVersion || len(status.Pending) != 0 {
+		t.Fatalf("Expected a new store to be up to date, got %+v", status)
+	}
+
+	// A store from before schema versions, with a title in the frontmatter
+	if err := store.SetSchemaVersion(0); err != nil {
+		t.Fatalf("Failed to reset schema version: %v", err)
+	}
+	childPath := store.GetNodeFilePath(child.ID())
+	oldFormat := "---\nid: " + child.ID() + "\ntitle: Child\ntype: specification\n---\n\nChild content\n"
+	if err := os.WriteFile(childPath, []byte(oldFormat), 0644); err != nil {
+		t.Fatalf("Failed to write old format node: %v", err)
+	}
+
+	status, err = migrations.Status()
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	if status.CurrentVersion != 0 || len(status.Pending) != storage.SchemaVersion {
+		t.Fatalf("Expected every migration to be pending, got %+v", status)
+	}
+
+	report, err := migrations.Up(true)
+	if err != nil {
+		t.Fatalf("Failed to dry run migrations: %v", err)
+	}
+	if len(report.Migrations) != 2 || len(report.Migrations[0].Changes) != 1 || len(report.Migrations[1].Changes) != 2 {
+		t.Fatalf("Expected the child to be rewritten and both nodes organized, got %+v", report.Migrations)
+	}
+	if data, err := os.ReadFile(childPath); err != nil || string(data) != oldFormat {
+		t.Errorf("Expected a dry run to leave the node file alone, got %q, %v", data, err)
+	}
+	if status, err := migrations.Status(); err != nil || status.CurrentVersion != 0 {
+		t.Errorf("Expected a dry run to leave the schema version alone, got %+v, %v", status, err)
+	}
+
+	report, err = migrations.Up(false)
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if report.FromVersion != 0 || report.ToVersion != storage.SchemaVersion {
+		t.Errorf("Expected to migrate from 0 to %d, got %d to %d", storage.SchemaVersion, report.FromVersion, report.ToVersion)
+	}
+	childPath = store.GetNodeFilePath(child.ID())
+	if !strings.HasPrefix(childPath, filepath.Join(projectDir, DocumentationRoot)) {
+		t.Errorf("Expected the child to be organized into docs/, got %s", childPath)
+	}
+	data, err := os.ReadFile(childPath)
+	if err != nil {
+		t.Fatalf("Failed to read node file: %v", err)
+	}
+	if !strings.Contains(string(data), "# Child\n") || strings.Contains(string(data), "title:") {
+		t.Errorf("Expected the title to be a heading, got %q", data)
+	}
+
+	report, err = migrations.Up(false)
+	if err != nil || len(report.Migrations) != 0 {
+		t.Errorf("Expected nothing left to migrate, got %+v, %v", report, err)
+	}
+}
+
+func TestNewerSchemaIsReadOnly(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+
+	spec, err := specService.CreateSpec("Spec", "Content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	if err := store.SetSchemaVersion(storage.SchemaVersion + 1); err != nil {
+		t.Fatalf("Failed to set schema version: %v", err)
+	}
+
+	if _, err := specService.ReadNode(spec.ID()); err != nil {
+		t.Errorf("Expected a newer store to stay readable, got %v", err)
+	}
+
+	var zammErr *models.ZammError
+	if _, err := specService.CreateSpec("Other", "Content"); !errors.As(err, &zammErr) || zammErr.Type != models.ErrTypeConflict {
+		t.Errorf("Expected writing to a newer store to conflict, got %v", err)
+	}
+	if _, err := NewMigrationService(store, specService).Up(false); !errors.As(err, &zammErr) || zammErr.Type != models.ErrTypeConflict {
+		t.Errorf("Expected migrating a newer store to conflict, got %v", err)
+	}
+}
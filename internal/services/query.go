@@ -0,0 +1,318 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// queryKeys are the keys a query term can filter on
+var queryKeys = []string{"type", "tag", "status", "under", "has"}
+
+// queryNodeTypes are the values of a type: term
+var queryNodeTypes = []string{"specification", "project", "implementation"}
+
+// queryHasValues are the values of a has: term
+var queryHasValues = []string{"commits", "children", "parents", "tags"}
+
+// queryTerm is one whitespace-separated part of a query. A term without a key is free
+// text. A term matches a node if any of its comma-separated values does.
+type queryTerm struct {
+	key    string
+	values []string
+	negate bool
+}
+
+// Query selects nodes with terms that must all match, such as
+//
+//	type:specification tag:security status:approved,implemented under:architecture/storage
+//	has:commits -has:children "free text"
+//
+// A leading - negates a term, and quotes keep spaces, colons and commas in a value.
+type Query struct {
+	Text  string
+	terms []queryTerm
+}
+
+// ParseQuery parses the text of a query, checking its keys and values
+func ParseQuery(text string) (*Query, error) {
+	query := &Query{Text: strings.TrimSpace(text)}
+
+	var value strings.Builder
+	var key string
+	hasKey, negate, quoted, inQuotes, started := false, false, false, false, false
+	flush := func() error {
+		defer func() {
+			value.Reset()
+			key = ""
+			hasKey, negate, quoted, started = false, false, false, false
+		}()
+		if !started {
+			return nil
+		}
+		if !hasKey {
+			if value.Len() == 0 && !quoted {
+				// A lone - is text to search for rather than an empty negated term
+				value.WriteString("-")
+				negate = false
+			}
+			query.terms = append(query.terms, queryTerm{values: []string{strings.ToLower(value.String())}, negate: negate})
+			return nil
+		}
+		term, err := parseQueryTerm(strings.ToLower(key), value.String(), quoted)
+		if err != nil {
+			return err
+		}
+		term.negate = negate
+		query.terms = append(query.terms, term)
+		return nil
+	}
+
+	for _, r := range text {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			quoted = true
+			started = true
+		case inQuotes:
+			value.WriteRune(r)
+		case unicode.IsSpace(r):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case r == '-' && !started:
+			negate = true
+			started = true
+		case r == ':' && !hasKey && !quoted:
+			key = value.String()
+			value.Reset()
+			hasKey = true
+		default:
+			value.WriteRune(r)
+			started = true
+		}
+	}
+	if inQuotes {
+		return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("invalid query %q: unterminated quote", query.Text))
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// parseQueryTerm checks the key of a term and splits its value into alternatives. A
+// quoted value is a single alternative, commas and all.
+func parseQueryTerm(key, value string, quoted bool) (queryTerm, error) {
+	if !slices.Contains(queryKeys, key) {
+		zammErr := models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("unknown query key %q", key))
+		zammErr.Details = fmt.Sprintf("expected one of %s, or quote the term to search for its text", strings.Join(queryKeys, ", "))
+		return queryTerm{}, zammErr
+	}
+
+	values := []string{value}
+	if !quoted {
+		values = strings.Split(value, ",")
+	}
+	term := queryTerm{key: key, values: make([]string, 0, len(values))}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if key != "under" {
+			v = strings.ToLower(v)
+		}
+		term.values = append(term.values, v)
+	}
+	if len(term.values) == 0 {
+		return queryTerm{}, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("query key %s: needs a value", key))
+	}
+
+	for _, v := range term.values {
+		var allowed []string
+		switch key {
+		case "type":
+			allowed = queryNodeTypes
+		case "has":
+			allowed = queryHasValues
+		case "status":
+			if _, err := models.ParseStatus(v); err != nil {
+				return queryTerm{}, err
+			}
+		}
+		if allowed != nil && !slices.Contains(allowed, v) {
+			return queryTerm{}, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("unknown value %q for %s:, expected one of %s", v, key, strings.Join(allowed, ", ")))
+		}
+	}
+	return term, nil
+}
+
+// QueryService interface defines how nodes are selected with queries
+type QueryService interface {
+	Query(query string) ([]models.Node, error)
+}
+
+// queryService implements the QueryService interface
+type queryService struct {
+	storage     storage.Storage
+	specService SpecService
+}
+
+// NewQueryService creates a new QueryService instance
+func NewQueryService(storage storage.Storage, specService SpecService) QueryService {
+	return &queryService{
+		storage:     storage,
+		specService: specService,
+	}
+}
+
+// queryIndex holds what the terms of a query are matched against, read once per query
+type queryIndex struct {
+	parents     map[string][]string
+	children    map[string][]string
+	commits     map[string]bool
+	descendants map[string]map[string]bool // under: value -> IDs of the nodes below it
+}
+
+// Query returns the nodes that match every term of a query, in the order ListNodes
+// returns them. An empty query matches every node.
+func (s *queryService) Query(text string) ([]models.Node, error) {
+	query, err := ParseQuery(text)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.storage.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	index, err := s.buildIndex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.Node, 0)
+	for _, node := range nodes {
+		if index.matchesAll(query, node) {
+			results = append(results, node)
+		}
+	}
+	return results, nil
+}
+
+func (s *queryService) buildIndex(query *Query) (*queryIndex, error) {
+	links, err := s.storage.ListSpecSpecLinks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hierarchy links: %w", err)
+	}
+	commitLinks, err := s.storage.ListSpecCommitLinks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commit links: %w", err)
+	}
+
+	index := &queryIndex{
+		parents:     make(map[string][]string),
+		children:    make(map[string][]string),
+		commits:     make(map[string]bool),
+		descendants: make(map[string]map[string]bool),
+	}
+	for _, link := range links {
+		index.parents[link.FromSpecID] = append(index.parents[link.FromSpecID], link.ToSpecID)
+		index.children[link.ToSpecID] = append(index.children[link.ToSpecID], link.FromSpecID)
+	}
+	for _, link := range commitLinks {
+		index.commits[link.SpecID] = true
+	}
+
+	for _, term := range query.terms {
+		if term.key != "under" {
+			continue
+		}
+		for _, value := range term.values {
+			if _, ok := index.descendants[value]; ok {
+				continue
+			}
+			ancestor, err := s.resolveUnder(value)
+			if err != nil {
+				return nil, err
+			}
+			index.descendants[value] = index.collectDescendants(ancestor.ID())
+		}
+	}
+	return index, nil
+}
+
+// resolveUnder finds the node an under: term names, by ID or by slug path
+func (s *queryService) resolveUnder(value string) (models.Node, error) {
+	if node, err := s.storage.ReadNode(value); err == nil {
+		return node, nil
+	}
+	node, err := s.specService.FindNodeBySlugPath(value)
+	if err != nil {
+		return nil, models.NewZammErrorWithCause(models.ErrTypeNotFound, fmt.Sprintf("no node with ID or slug path %q for under:", value), err)
+	}
+	return node, nil
+}
+
+// collectDescendants returns the IDs of every node below a node, not counting the node
+func (index *queryIndex) collectDescendants(id string) map[string]bool {
+	descendants := make(map[string]bool)
+	queue := slices.Clone(index.children[id])
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if descendants[next] || next == id {
+			continue
+		}
+		descendants[next] = true
+		queue = append(queue, index.children[next]...)
+	}
+	return descendants
+}
+
+func (index *queryIndex) matchesAll(query *Query, node models.Node) bool {
+	for _, term := range query.terms {
+		matched := slices.ContainsFunc(term.values, func(value string) bool {
+			return index.matches(term.key, value, node)
+		})
+		if matched == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (index *queryIndex) matches(key, value string, node models.Node) bool {
+	switch key {
+	case "type":
+		return node.Type() == value
+	case "tag":
+		return slices.ContainsFunc(node.Tags(), func(tag string) bool {
+			return strings.EqualFold(tag, value)
+		})
+	case "status":
+		return string(node.Status()) == value
+	case "under":
+		return index.descendants[value][node.ID()]
+	case "has":
+		switch value {
+		case "commits":
+			return index.commits[node.ID()]
+		case "children":
+			return len(index.children[node.ID()]) > 0
+		case "parents":
+			return len(index.parents[node.ID()]) > 0
+		case "tags":
+			return len(node.Tags()) > 0
+		}
+		return false
+	}
+
+	text := strings.ToLower(node.Title() + "\n" + node.Slug() + "\n" + node.Content())
+	return strings.Contains(text, value)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func TestQueryNodes(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	queryService := NewQueryService(store, specService)
+
+	create := func(title, content, parentID string) models.Node {
+		t.Helper()
+		spec, err := specService.CreateSpec(title, content)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", title, err)
+		}
+		if _, err := specService.AddChildToParent(spec.ID(), parentID, "child"); err != nil {
+			t.Fatalf("Failed to link %s: %v", title, err)
+		}
+		return spec
+	}
+	architecture := create("Architecture", "How it fits together", root.ID())
+	storageSpec := create("Storage", "Where nodes live", architecture.ID())
+	encryption := create("Encryption at rest", "Files are encrypted on disk", storageSpec.ID())
+	login := create("Login", "Users sign in with a password", root.ID())
+
+	for _, node := range []models.Node{encryption, login} {
+		if _, err := specService.TagNode(node.ID(), []string{"security"}, nil); err != nil {
+			t.Fatalf("Failed to tag %s: %v", node.Title(), err)
+		}
+	}
+	encryption, err = specService.ReadNode(encryption.ID())
+	if err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	encryption.SetStatus(models.StatusApproved)
+	if err := store.WriteNode(encryption); err != nil {
+		t.Fatalf("Failed to save status: %v", err)
+	}
+	if err := store.CreateSpecCommitLink(&models.SpecCommitLink{SpecID: encryption.ID(), CommitID: "abc123", RepoPath: ".", LinkLabel: "implements"}); err != nil {
+		t.Fatalf("Failed to link commit: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"tag:security", []string{"Encryption at rest", "Login"}},
+		{"tag:security status:approved", []string{"Encryption at rest"}},
+		{"tag:security -status:approved", []string{"Login"}},
+		{"under:architecture", []string{"Encryption at rest", "Storage"}},
+		{"under:architecture/storage has:commits -has:children", []string{"Encryption at rest"}},
+		{"under:" + storageSpec.ID(), []string{"Encryption at rest"}},
+		{"type:specification has:children", []string{"Architecture", "Storage"}},
+		{"-has:parents", []string{root.Title()}},
+		{`"sign in"`, []string{"Login"}},
+		{"status:draft,approved tag:SECURITY", []string{"Encryption at rest", "Login"}},
+		{"type:project", []string{root.Title()}},
+	}
+	for _, test := range tests {
+		nodes, err := queryService.Query(test.query)
+		if err != nil {
+			t.Errorf("Query %q failed: %v", test.query, err)
+			continue
+		}
+		titles := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			titles = append(titles, node.Title())
+		}
+		slices.Sort(titles)
+		if !slices.Equal(titles, test.want) {
+			t.Errorf("Query %q matched %v, expected %v", test.query, titles, test.want)
+		}
+	}
+
+	for _, query := range []string{"owner:alice", "has:owners", "status:done", `"unterminated`, "tag:", "under:nowhere"} {
+		if _, err := queryService.Query(query); err == nil {
+			t.Errorf("Expected query %q to be rejected", query)
+		}
+	}
+}
+
+func TestTagNode(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	spec, err := specService.CreateSpec("Login", "Users sign in")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+
+	if _, err := specService.TagNode(spec.ID(), []string{"security", "auth", "security"}, nil); err != nil {
+		t.Fatalf("Failed to tag node: %v", err)
+	}
+	node, err := specService.TagNode(spec.ID(), nil, []string{"auth"})
+	if err != nil {
+		t.Fatalf("Failed to untag node: %v", err)
+	}
+	if !slices.Equal(node.Tags(), []string{"security"}) {
+		t.Errorf("Expected only the security tag, got %v", node.Tags())
+	}
+
+	reread, err := specService.ReadNode(spec.ID())
+	if err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	if !slices.Equal(reread.Tags(), []string{"security"}) {
+		t.Errorf("Expected the tags to be saved, got %v", reread.Tags())
+	}
+
+	if _, err := specService.TagNode(spec.ID(), []string{"two words"}, nil); err == nil {
+		t.Error("Expected a tag with a space to be rejected")
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// ReportService interface defines read-only reports over the spec hierarchy
+type ReportService interface {
+	CoverageReport() (*CoverageReport, error)
+	DriftReport() (*DriftReport, error)
+}
+
+// CoverageReport describes, for every specification reachable from the root node,
+// which kinds of commits have been linked to it or to any of its descendants
+type CoverageReport struct {
+	RootID       string         `json:"root_id"`
+	TotalSpecs   int            `json:"total_specs"`
+	CoveredSpecs int            `json:"covered_specs"`
+	Specs        []SpecCoverage `json:"specs"`
+}
+
+// SpecCoverage holds the commit link counts for a single specification. Counts are
+// numbers of distinct commits per link label.
+type SpecCoverage struct {
+	SpecID      string         `json:"spec_id"`
+	Title       string         `json:"title"`
+	Depth       int            `json:"depth"`
+	Status      models.Status  `json:"status"`
+	Covered     bool           `json:"covered"`
+	DirectLinks map[string]int `json:"direct_links"`
+	TotalLinks  map[string]int `json:"total_links"`
+}
+
+// HasLabel reports whether the spec or any of its descendants has a commit link with the given label
+func (c SpecCoverage) HasLabel(label string) bool {
+	return c.TotalLinks[label] > 0
+}
+
+// FilterByStatus returns a copy of the report with only the specs that have one of the
+// given statuses, and totals counting only those
+func (r *CoverageReport) FilterByStatus(statuses []models.Status) *CoverageReport {
+	filtered := &CoverageReport{RootID: r.RootID, Specs: make([]SpecCoverage, 0, len(r.Specs))}
+	for _, spec := range r.Specs {
+		if !slices.Contains(statuses, spec.Status) {
+			continue
+		}
+		filtered.Specs = append(filtered.Specs, spec)
+		filtered.TotalSpecs++
+		if spec.Covered {
+			filtered.CoveredSpecs++
+		}
+	}
+	return filtered
+}
+
+// reportService implements the ReportService interface
+type reportService struct {
+	storage     storage.Storage
+	specService SpecService
+	gitService  GitService
+}
+
+// NewReportService creates a new ReportService instance
+func NewReportService(storage storage.Storage, specService SpecService, gitService GitService) ReportService {
+	return &reportService{
+		storage:     storage,
+		specService: specService,
+		gitService:  gitService,
+	}
+}
+
+// commitSet groups distinct linked commits by link label
+type commitSet map[string]map[string]struct{}
+
+func (cs commitSet) add(label, commitKey string) {
+	if cs[label] == nil {
+		cs[label] = make(map[string]struct{})
+	}
+	cs[label][commitKey] = struct{}{}
+}
+
+func (cs commitSet) merge(other commitSet) {
+	for label, commits := range other {
+		for commitKey := range commits {
+			cs.add(label, commitKey)
+		}
+	}
+}
+
+func (cs commitSet) counts() map[string]int {
+	counts := make(map[string]int, len(cs))
+	for label, commits := range cs {
+		counts[label] = len(commits)
+	}
+	return counts
+}
+
+// coverageWalker walks the hierarchy once, memoizing each node's subtree links
+// so that nodes with several parents are only visited a single time
+type coverageWalker struct {
+	service  *reportService
+	subtree  map[string]commitSet
+	visiting map[string]bool
+	report   *CoverageReport
+}
+
+// CoverageReport walks the hierarchy from the root node and reports link coverage for every spec
+func (s *reportService) CoverageReport() (*CoverageReport, error) {
+	root, err := s.specService.GetRootNode()
+	if err != nil {
+		return nil, err
+	}
+
+	walker := &coverageWalker{
+		service:  s,
+		subtree:  make(map[string]commitSet),
+		visiting: make(map[string]bool),
+		report: &CoverageReport{
+			RootID: root.ID(),
+			Specs:  make([]SpecCoverage, 0),
+		},
+	}
+
+	if _, err := walker.walk(root, 0); err != nil {
+		return nil, err
+	}
+
+	for _, spec := range walker.report.Specs {
+		walker.report.TotalSpecs++
+		if spec.Covered {
+			walker.report.CoveredSpecs++
+		}
+	}
+
+	return walker.report, nil
+}
+
+func (w *coverageWalker) walk(node models.Node, depth int) (commitSet, error) {
+	if links, done := w.subtree[node.ID()]; done {
+		return links, nil
+	}
+	if w.visiting[node.ID()] {
+		// Cycles are not valid hierarchies; stop descending instead of recursing forever
+		return commitSet{}, nil
+	}
+	w.visiting[node.ID()] = true
+	defer delete(w.visiting, node.ID())
+
+	direct, err := w.service.storage.GetLinksBySpec(node.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit links for node %s: %w", node.ID(), err)
+	}
+
+	directSet := commitSet{}
+	for _, link := range direct {
+		directSet.add(link.LinkLabel, link.RepoPath+"@"+link.CommitID)
+	}
+
+	// Reserve this spec's row before visiting children so rows come out in hierarchy order
+	rowIndex := -1
+	if node.Type() == "specification" {
+		rowIndex = len(w.report.Specs)
+		w.report.Specs = append(w.report.Specs, SpecCoverage{
+			SpecID: node.ID(),
+			Title:  node.Title(),
+			Depth:  depth,
+			Status: node.Status(),
+		})
+	}
+
+	total := commitSet{}
+	total.merge(directSet)
+
+	children, err := w.service.specService.GetChildren(node.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children for node %s: %w", node.ID(), err)
+	}
+	for _, child := range children {
+		childLinks, err := w.walk(child, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		total.merge(childLinks)
+	}
+
+	if rowIndex >= 0 {
+		row := &w.report.Specs[rowIndex]
+		row.DirectLinks = directSet.counts()
+		row.TotalLinks = total.counts()
+		row.Covered = len(total) > 0
+	}
+
+	w.subtree[node.ID()] = total
+	return total, nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func TestCoverageReport(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+
+	createChild := func(title, parentID string) *models.Spec {
+		t.Helper()
+		spec, err := specService.CreateSpec(title, title+" content")
+		if err != nil {
+			t.Fatalf("Failed to create spec %s: %v", title, err)
+		}
+		if _, err := specService.AddChildToParent(spec.ID(), parentID, "child"); err != nil {
+			t.Fatalf("Failed to link %s: %v", title, err)
+		}
+		return spec
+	}
+
+	parent := createChild("Parent", root.ID())
+	implemented := createChild("Implemented", parent.ID())
+	tested := createChild("Tested", parent.ID())
+	uncovered := createChild("Uncovered", root.ID())
+
+	// A commit shared by two children must only be counted once for the parent
+	links := []*models.SpecCommitLink{
+		{SpecID: implemented.ID(), CommitID: "aaa", RepoPath: ".", LinkLabel: "implements"},
+		{SpecID: tested.ID(), CommitID: "aaa", RepoPath: ".", LinkLabel: "implements"},
+		{SpecID: tested.ID(), CommitID: "bbb", RepoPath: ".", LinkLabel: "tests"},
+	}
+	for _, link := range links {
+		if err := store.CreateSpecCommitLink(link); err != nil {
+			t.Fatalf("Failed to create commit link: %v", err)
+		}
+	}
+
+	report, err := NewReportService(store, specService, NewGitService()).CoverageReport()
+	if err != nil {
+		t.Fatalf("Failed to build coverage report: %v", err)
+	}
+
+	if report.RootID != root.ID() {
+		t.Errorf("Expected root ID %s, got %s", root.ID(), report.RootID)
+	}
+	if report.TotalSpecs != 4 || report.CoveredSpecs != 3 {
+		t.Errorf("Expected 3 of 4 specs covered, got %d of %d", report.CoveredSpecs, report.TotalSpecs)
+	}
+
+	byID := make(map[string]SpecCoverage)
+	for _, spec := range report.Specs {
+		byID[spec.SpecID] = spec
+	}
+
+	if got := byID[parent.ID()]; !got.Covered || got.TotalLinks["implements"] != 1 || got.TotalLinks["tests"] != 1 || len(got.DirectLinks) != 0 {
+		t.Errorf("Unexpected parent coverage: %+v", got)
+	}
+	if got := byID[parent.ID()]; got.Depth != 1 {
+		t.Errorf("Expected parent depth 1, got %d", got.Depth)
+	}
+	if got := byID[implemented.ID()]; !got.HasLabel("implements") || got.HasLabel("tests") || got.Depth != 2 {
+		t.Errorf("Unexpected implemented coverage: %+v", got)
+	}
+	if got := byID[uncovered.ID()]; got.Covered {
+		t.Errorf("Expected uncovered spec not to be covered: %+v", got)
+	}
+}
+
+func TestDriftReport(t *testing.T) {
+	repoDir, _ := setupTestRepo(t)
+
+	store, err := storage.New(filepath.Join(repoDir, ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	linkService := NewLinkService(store, NewGitService())
+	reportService := NewReportService(store, specService, NewGitService())
+
+	commitAll := func(message string) string {
+		t.Helper()
+		runGit(t, repoDir, "add", "-A")
+		runGit(t, repoDir, "commit", "-q", "-m", message)
+		return runGit(t, repoDir, "rev-parse", "HEAD")
+	}
+
+	driftFor := func(specID string) SpecDrift {
+		t.Helper()
+		report, err := reportService.DriftReport()
+		if err != nil {
+			t.Fatalf("Failed to build drift report: %v", err)
+		}
+		for _, spec := range report.Specs {
+			if spec.SpecID == specID {
+				return spec
+			}
+		}
+		t.Fatalf("Spec %s missing from drift report", specID)
+		return SpecDrift{}
+	}
+
+	spec, err := specService.CreateSpec("Storage", "Store nodes as files")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	unimplemented, err := specService.CreateSpec("Unimplemented", "Nothing yet")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	implementation := commitAll("Add storage")
+	if _, err := linkService.LinkSpecToCommit(spec.ID(), implementation, repoDir, "implements"); err != nil {
+		t.Fatalf("Failed to link commit: %v", err)
+	}
+
+	if got := driftFor(spec.ID()); got.Status != DriftStatusCurrent || got.ImplementedIn != implementation {
+		t.Errorf("Expected current spec implemented in %s, got %+v", implementation, got)
+	}
+	if got := driftFor(unimplemented.ID()); got.Status != DriftStatusUnimplemented {
+		t.Errorf("Expected unimplemented spec, got %+v", got)
+	}
+
+	// Changing only the frontmatter is not a content change
+	spec.SetSlug("storage")
+	if err := store.WriteNode(spec); err != nil {
+		t.Fatalf("Failed to set slug: %v", err)
+	}
+	commitAll("Set slug")
+	if got := driftFor(spec.ID()); got.Status != DriftStatusCurrent {
+		t.Errorf("Expected frontmatter change not to cause drift, got %+v", got)
+	}
+
+	if _, err := specService.UpdateSpec(spec.ID(), "Storage", "Store nodes as markdown files"); err != nil {
+		t.Fatalf("Failed to update spec: %v", err)
+	}
+	if got := driftFor(spec.ID()); got.Status != DriftStatusStale || !got.UncommittedChanges {
+		t.Errorf("Expected uncommitted edit to be stale, got %+v", got)
+	}
+
+	edit := commitAll("Edit storage spec")
+	got := driftFor(spec.ID())
+	if got.Status != DriftStatusStale || got.UncommittedChanges || len(got.ChangedIn) != 1 || got.ChangedIn[0] != edit {
+		t.Errorf("Expected spec to be stale since %s, got %+v", edit, got)
+	}
+
+	update := commitFile(t, repoDir, "storage.go", "package storage\n", "Update storage")
+	if _, err := linkService.LinkSpecToCommit(spec.ID(), update, repoDir, "updates"); err != nil {
+		t.Fatalf("Failed to link commit: %v", err)
+	}
+	if got := driftFor(spec.ID()); got.Status != DriftStatusCurrent || got.ImplementedIn != update {
+		t.Errorf("Expected spec to be current after update %s, got %+v", update, got)
+	}
+}
+
+func TestCoverageReportFilterByStatus(t *testing.T) {
+	report := &CoverageReport{
+		RootID:       "root",
+		TotalSpecs:   3,
+		CoveredSpecs: 2,
+		Specs: []SpecCoverage{
+			{SpecID: "a", Status: models.StatusDraft, Covered: true},
+			{SpecID: "b", Status: models.StatusApproved, Covered: true},
+			{SpecID: "c", Status: models.StatusApproved},
+		},
+	}
+
+	filtered := report.FilterByStatus([]models.Status{models.StatusApproved})
+	if len(filtered.Specs) != 2 || filtered.Specs[0].SpecID != "b" || filtered.Specs[1].SpecID != "c" {
+		t.Fatalf("Expected only the approved specs, got %+v", filtered.Specs)
+	}
+	if filtered.TotalSpecs != 2 || filtered.CoveredSpecs != 1 {
+		t.Errorf("Expected 1 of 2 approved specs covered, got %d of %d", filtered.CoveredSpecs, filtered.TotalSpecs)
+	}
+}
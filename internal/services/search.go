@@ -0,0 +1,158 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// snippetLength is roughly how many bytes of content a search result shows, and
+// snippetLead how many of them come before the first match
+const (
+	snippetLength = 160
+	snippetLead   = 40
+)
+
+// TextSpan is a range of bytes in a piece of text
+type TextSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchResult is a node that matched a full-text search, with the words that matched
+// highlighted in its title and in a snippet of its content
+type SearchResult struct {
+	ID              string        `json:"id"`
+	Type            string        `json:"type"`
+	Title           string        `json:"title"`
+	Status          models.Status `json:"status"`
+	Score           float64       `json:"score"`
+	TitleHighlights []TextSpan    `json:"title_highlights"`
+	Snippet         string        `json:"snippet"`
+	Highlights      []TextSpan    `json:"highlights"`
+}
+
+// SearchService interface defines full-text search over node titles and contents
+type SearchService interface {
+	Search(query string, limit int) ([]SearchResult, error)
+}
+
+// searchService implements the SearchService interface
+type searchService struct {
+	storage storage.Storage
+}
+
+// NewSearchService creates a new SearchService instance
+func NewSearchService(storage storage.Storage) SearchService {
+	return &searchService{
+		storage: storage,
+	}
+}
+
+// Search returns the best matches for a query, at most limit of them unless limit is 0
+func (s *searchService) Search(query string, limit int) ([]SearchResult, error) {
+	if len(storage.SplitWords(query)) == 0 {
+		return nil, models.NewZammError(models.ErrTypeValidation, "search query must contain at least one word")
+	}
+
+	hits, err := s.storage.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		snippet, highlights := makeSnippet(hit.Node.Content(), hit.Words)
+		results = append(results, SearchResult{
+			ID:              hit.Node.ID(),
+			Type:            hit.Node.Type(),
+			Title:           hit.Node.Title(),
+			Status:          hit.Node.Status(),
+			Score:           hit.Score,
+			TitleHighlights: highlightWords(hit.Node.Title(), hit.Words),
+			Snippet:         snippet,
+			Highlights:      highlights,
+		})
+	}
+	return results, nil
+}
+
+// highlightWords returns the spans of text taken up by any of the given words
+func highlightWords(text string, words []string) []TextSpan {
+	spans := make([]TextSpan, 0)
+	for _, word := range storage.SplitWords(text) {
+		for _, match := range words {
+			if word.Text == match {
+				spans = append(spans, TextSpan{Start: word.Start, End: word.End})
+				break
+			}
+		}
+	}
+	return spans
+}
+
+// makeSnippet picks out the part of the content around the first of the words to occur
+// in it, on a single line, along with where the words are in the snippet
+func makeSnippet(content string, words []string) (string, []TextSpan) {
+	text := strings.Join(strings.Fields(content), " ")
+	if text == "" {
+		return "", []TextSpan{}
+	}
+
+	// Content that doesn't fit is shown from a little before the first match
+	start := 0
+	if spans := highlightWords(text, words); len(text) > snippetLength && len(spans) > 0 && spans[0].Start > snippetLead {
+		start = wordStartAfter(text, min(spans[0].Start-snippetLead, len(text)-snippetLength))
+	}
+	end := len(text)
+	if end-start > snippetLength {
+		end = wordEndBefore(text, start, start+snippetLength)
+	}
+
+	snippet := text[start:end]
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet, highlightWords(snippet, words)
+}
+
+// wordStartAfter moves an offset on to the start of the next word, unless it is at the
+// start of one already
+func wordStartAfter(text string, offset int) int {
+	for _, word := range storage.SplitWords(text) {
+		if word.Start >= offset {
+			return word.Start
+		}
+	}
+	return offset
+}
+
+// wordEndBefore moves an offset back so that the text from start to it doesn't end part
+// way through a word
+func wordEndBefore(text string, start, offset int) int {
+	end := 0
+	for _, word := range storage.SplitWords(text) {
+		if word.End > offset {
+			break
+		}
+		if word.Start >= start {
+			end = word.End
+		}
+	}
+	if end == 0 {
+		// A single word longer than the snippet is cut, but not in the middle of a character
+		for offset > 0 && !utf8.RuneStart(text[offset]) {
+			offset--
+		}
+		return offset
+	}
+	return end
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func TestSearchSnippets(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	searchService := NewSearchService(store)
+
+	long := strings.Repeat("Filler words that say nothing much. ", 10) + "Backups are encrypted\nwith a rotating key."
+	if _, err := specService.CreateSpec("Backups", long); err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	if _, err := specService.CreateSpec("Encryption keys", "Keys rotate monthly."); err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+
+	results, err := searchService.Search("rotating key", 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Backups" {
+		t.Fatalf("Expected only the backups spec, got %+v", results)
+	}
+	result := results[0]
+	if !strings.HasPrefix(result.Snippet, "…") || !strings.HasSuffix(result.Snippet, "with a rotating key.") {
+		t.Errorf("Expected the snippet to lead up to the match on one line, got %q", result.Snippet)
+	}
+	if len(result.Snippet) > snippetLength+len("…") {
+		t.Errorf("Expected a snippet of at most %d bytes, got %d", snippetLength, len(result.Snippet))
+	}
+	highlighted := make([]string, 0, len(result.Highlights))
+	for _, span := range result.Highlights {
+		highlighted = append(highlighted, result.Snippet[span.Start:span.End])
+	}
+	if strings.Join(highlighted, ",") != "rotating,key" {
+		t.Errorf("Expected the matching words to be highlighted, got %v", highlighted)
+	}
+
+	results, err = searchService.Search("key", 1)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Encryption keys" || len(results[0].TitleHighlights) != 1 {
+		t.Errorf("Expected the title match to come first and be highlighted, got %+v", results)
+	}
+
+	if _, err := searchService.Search(" -- ", 0); err == nil {
+		t.Error("Expected a query without words to be rejected")
+	}
+}
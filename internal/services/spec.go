@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
@@ -25,15 +26,21 @@ type SpecService interface {
 	UpdateSpec(id, title, content string) (*models.Spec, error)
 	UpdateImplementation(id, title, content string, repoURL, branch, folderPath *string) (*models.Implementation, error)
 	WriteNode(id, title, content string) (models.Node, error)
+	TagNode(id string, add, remove []string) (models.Node, error)
 	ListNodes() ([]models.Node, error)
-	DeleteSpec(id string) error
+	PlanDeletion(id string, strategy DeleteStrategy) (*DeletionPlan, error)
+	DeleteNode(id string, strategy DeleteStrategy) (*DeletionPlan, error)
 	IsRootNode(node models.Node) bool
 
 	// Hierarchical operations
 	AddChildToParent(childSpecID, parentSpecID, label string) (*models.SpecSpecLink, error)
 	RemoveChildFromParent(childSpecID, parentSpecID string) error
+	MoveNode(nodeID, oldParentID, newParentID string) (*models.SpecSpecLink, error)
+	MoveChild(parentID, childID string, offset int) (models.Node, error)
 	GetParents(specID string) ([]models.Node, error)
 	GetChildren(specID string) ([]models.Node, error)
+	GetOrganizedChildren(node models.Node) (models.ChildGroup, error)
+	FindCycles() ([]SpecCycle, error)
 
 	// Root spec operations
 	InitializeRootSpec() error
@@ -42,6 +49,8 @@ type SpecService interface {
 
 	// Organization operations
 	OrganizeNodes(nodeID string) error
+	GetNodeSlugPath(node models.Node) (string, error)
+	FindNodeBySlugPath(slugPath string) (models.Node, error)
 }
 
 // specService implements the SpecService interface
@@ -246,6 +255,9 @@ func isImplementationNode(node models.Node) bool {
 	return node.Type() == "implementation"
 }
 
+// GetOrganizedChildren returns a node's children arranged by its child grouping, in the
+// node's child order. Children the order doesn't mention come after the others, in the
+// order they were linked.
 func (s *specService) GetOrganizedChildren(node models.Node) (models.ChildGroup, error) {
 	cg := node.GetChildGrouping()
 	allChildren, err := s.GetChildren(node.ID())
@@ -259,6 +271,7 @@ func (s *specService) GetOrganizedChildren(node models.Node) (models.ChildGroup,
 	if node.Type() == "project" {
 		cg.Regroup("Implementations", isImplementationNode)
 	}
+	cg.Arrange(node.ChildOrder())
 
 	return cg, nil
 }
@@ -308,23 +321,48 @@ func (s *specService) WriteNode(id, title, content string) (models.Node, error)
 	return node, err
 }
 
-// ListNodes retrieves all nodes regardless of type
-func (s *specService) ListNodes() ([]models.Node, error) {
-	nodes, err := s.storage.ListNodes()
+// TagNode adds tags to a node and removes others from it. Tags keep the order they were
+// added in, and adding a tag the node already has changes nothing.
+func (s *specService) TagNode(id string, add, remove []string) (models.Node, error) {
+	node, err := s.storage.ReadNode(id)
 	if err != nil {
 		return nil, err
 	}
 
-	return nodes, nil
+	tags := slices.Clone(node.Tags())
+	for _, tag := range add {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || strings.ContainsAny(tag, ", \t\n\"") {
+			return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("invalid tag %q: tags cannot be empty or contain spaces, commas or quotes", tag))
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range remove {
+		tags = slices.DeleteFunc(tags, func(existing string) bool {
+			return existing == strings.TrimSpace(tag)
+		})
+	}
+
+	if len(tags) == 0 {
+		tags = nil
+	}
+	node.SetTags(tags)
+	if err := s.resaveNodeWithChildren(node); err != nil {
+		return nil, err
+	}
+	return node, nil
 }
 
-// DeleteSpec deletes a specification
-func (s *specService) DeleteSpec(id string) error {
-	if id == "" {
-		return models.NewZammError(models.ErrTypeValidation, "spec ID cannot be empty")
+// ListNodes retrieves all nodes regardless of type
+func (s *specService) ListNodes() ([]models.Node, error) {
+	nodes, err := s.storage.ListNodes()
+	if err != nil {
+		return nil, err
 	}
 
-	return s.storage.DeleteNode(id)
+	return nodes, nil
 }
 
 // AddChildToParent adds a parent-child relationship by specifying the child and parent
@@ -340,17 +378,6 @@ func (s *specService) AddChildToParent(childSpecID, parentSpecID, label string)
 		return nil, models.NewZammError(models.ErrTypeValidation, "cannot link a node to itself")
 	}
 
-	// Verify both nodes exist
-	_, err := s.storage.ReadNode(childSpecID)
-	if err != nil {
-		return nil, models.NewZammError(models.ErrTypeValidation, "child node not found")
-	}
-
-	_, err = s.storage.ReadNode(parentSpecID)
-	if err != nil {
-		return nil, models.NewZammError(models.ErrTypeValidation, "parent node not found")
-	}
-
 	// Use provided link type or default to "child"
 	if label == "" {
 		label = "child"
@@ -362,7 +389,24 @@ func (s *specService) AddChildToParent(childSpecID, parentSpecID, label string)
 		LinkLabel:  label,
 	}
 
-	if err := s.storage.CreateSpecSpecLink(link); err != nil {
+	// The hierarchy checked for cycles has to be the one the link is added to
+	err := s.storage.Transaction(func(tx storage.Storage) error {
+		s := &specService{storage: tx}
+
+		// Verify both nodes exist
+		if _, err := s.storage.ReadNode(childSpecID); err != nil {
+			return models.NewZammError(models.ErrTypeValidation, "child node not found")
+		}
+		if _, err := s.storage.ReadNode(parentSpecID); err != nil {
+			return models.NewZammError(models.ErrTypeValidation, "parent node not found")
+		}
+
+		if err := s.checkForCycle(childSpecID, parentSpecID); err != nil {
+			return err
+		}
+		return s.storage.CreateSpecSpecLink(link)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -381,6 +425,84 @@ func (s *specService) RemoveChildFromParent(childSpecID, parentSpecID string) er
 	return s.storage.DeleteSpecLinkBySpecs(childSpecID, parentSpecID)
 }
 
+// MoveNode moves a node from one parent to another, keeping the label of the original link
+func (s *specService) MoveNode(nodeID, oldParentID, newParentID string) (*models.SpecSpecLink, error) {
+	if nodeID == "" {
+		return nil, models.NewZammError(models.ErrTypeValidation, "node ID cannot be empty")
+	}
+	if oldParentID == newParentID {
+		return nil, models.NewZammError(models.ErrTypeValidation, "old and new parent must differ")
+	}
+
+	links, err := s.storage.GetSpecSpecLinks(nodeID, models.Outgoing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent links for node %s: %w", nodeID, err)
+	}
+
+	var oldLink *models.SpecSpecLink
+	for _, link := range links {
+		if link.ToSpecID == oldParentID {
+			oldLink = link
+			break
+		}
+	}
+	if oldLink == nil {
+		return nil, models.NewZammError(models.ErrTypeNotFound, fmt.Sprintf("node %s is not a child of %s", nodeID, oldParentID))
+	}
+
+	// A failed move leaves the hierarchy unchanged
+	var newLink *models.SpecSpecLink
+	err = s.storage.Transaction(func(tx storage.Storage) error {
+		s := &specService{storage: tx}
+		if err := s.RemoveChildFromParent(nodeID, oldParentID); err != nil {
+			return err
+		}
+		newLink, err = s.AddChildToParent(nodeID, newParentID, oldLink.LinkLabel)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newLink, nil
+}
+
+// MoveChild moves a child up (a negative offset) or down among the children it is listed
+// with, and saves the new order on the parent. A child already at the edge of its group
+// stays where it is.
+func (s *specService) MoveChild(parentID, childID string, offset int) (models.Node, error) {
+	if parentID == "" || childID == "" {
+		return nil, models.NewZammError(models.ErrTypeValidation, "node ID cannot be empty")
+	}
+
+	// Reading the order and saving it happen together, so no child added meanwhile is lost
+	var parent models.Node
+	err := s.storage.Transaction(func(tx storage.Storage) error {
+		s := &specService{storage: tx}
+		var err error
+		if parent, err = s.storage.ReadNode(parentID); err != nil {
+			return err
+		}
+		children, err := s.GetOrganizedChildren(parent)
+		if err != nil {
+			return fmt.Errorf("failed to get organized children for node %s: %w", parentID, err)
+		}
+		if !slices.ContainsFunc(children.AllNodes(), func(child models.Node) bool { return child.ID() == childID }) {
+			return models.NewZammError(models.ErrTypeNotFound, fmt.Sprintf("node %s is not a child of %s", childID, parentID))
+		}
+		if !children.Move(childID, offset) {
+			return nil
+		}
+
+		parent.SetChildOrder(children.Order())
+		return s.storage.WriteNodeWithChildren(parent, children)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
+
 // GetParents retrieves all parent nodes for a given node
 func (s *specService) GetParents(specID string) ([]models.Node, error) {
 	if specID == "" {
@@ -493,8 +615,16 @@ func (s *specService) validateSpecInput(title, content string) error {
 	return nil
 }
 
-// OrganizeNodes moves nodes from generic locations to hierarchical paths
+// OrganizeNodes moves nodes from generic locations to hierarchical paths. Either every
+// file is moved or, if organizing fails part way, none are.
 func (s *specService) OrganizeNodes(nodeID string) error {
+	return s.storage.Transaction(func(tx storage.Storage) error {
+		s := &specService{storage: tx}
+		return s.organizeNodes(nodeID)
+	})
+}
+
+func (s *specService) organizeNodes(nodeID string) error {
 	if nodeID != "" {
 		// Organize specific node only (not its subtree)
 		node, err := s.storage.ReadNode(nodeID)
@@ -525,7 +655,7 @@ func (s *specService) OrganizeNodes(nodeID string) error {
 		return fmt.Errorf("failed to get root node: %w", err)
 	}
 
-	return s.organizeNodeRecursively(rootNode, DocumentationRoot)
+	return s.organizeNodeRecursively(rootNode, DocumentationRoot, nil)
 }
 
 func (s *specService) generateMissingSlugs() error {
@@ -556,6 +686,7 @@ func (s *specService) generateSlugForNodeAndAncestors(node models.Node) error {
 
 	// Generate slugs for all ancestors (needed for path computation)
 	currentNode := node
+	visited := []string{node.ID()}
 	for {
 		parents, err := s.GetParents(currentNode.ID())
 		if err != nil {
@@ -567,6 +698,10 @@ func (s *specService) generateSlugForNodeAndAncestors(node models.Node) error {
 		}
 
 		parent := parents[0]
+		if slices.Contains(visited, parent.ID()) {
+			return s.ancestorCycleError(visited, parent.ID())
+		}
+		visited = append(visited, parent.ID())
 		if err := s.generateSlugForSingleNode(parent); err != nil {
 			return err
 		}
@@ -588,7 +723,9 @@ func (s *specService) generateSlugForSingleNode(node models.Node) error {
 	return nil
 }
 
-func (s *specService) organizeNodeRecursively(node models.Node, basePath string) error {
+// organizeNodeRecursively organizes a node and everything below it. ancestors are the
+// nodes above it on the way down from the root, for noticing cycles.
+func (s *specService) organizeNodeRecursively(node models.Node, basePath string, ancestors []string) error {
 	// First organize this node
 	if err := s.organizeSingleNode(node, basePath); err != nil {
 		return err
@@ -611,8 +748,15 @@ func (s *specService) organizeNodeRecursively(node models.Node, basePath string)
 			childBasePath = filepath.Join(basePath, slug)
 		}
 
+		ancestors = append(slices.Clone(ancestors), node.ID())
 		for _, child := range children {
-			if err := s.organizeNodeRecursively(child, childBasePath); err != nil {
+			if i := slices.Index(ancestors, child.ID()); i >= 0 {
+				// Walk the cycle back up, from the child through its parents
+				up := slices.Clone(ancestors[i+1:])
+				slices.Reverse(up)
+				return s.ancestorCycleError(append([]string{child.ID()}, up...), child.ID())
+			}
+			if err := s.organizeNodeRecursively(child, childBasePath, ancestors); err != nil {
 				return err
 			}
 		}
@@ -659,13 +803,20 @@ func (s *specService) resaveNodeWithChildren(node models.Node) error {
 		return fmt.Errorf("failed to get organized children for node %s: %w", node.ID(), err)
 	}
 
+	// Once an order was chosen, it is kept up to date with the children that were added
+	// and removed since
+	if !node.ChildOrder().IsEmpty() {
+		node.SetChildOrder(children.Order())
+	}
 	return s.storage.WriteNodeWithChildren(node, children)
 }
 
+// moveNodeToPath moves a node's file into place. Backends that don't store nodes as
+// files have nothing to move.
 func (s *specService) moveNodeToPath(node models.Node, newPath string) error {
-	fileStorage, ok := s.storage.(*storage.FileStorage)
+	fileStorage, ok := s.storage.(storage.NodeFileStorage)
 	if !ok {
-		return fmt.Errorf("storage is not FileStorage type")
+		return nil
 	}
 
 	return fileStorage.MoveNodeFile(node, newPath)
@@ -687,9 +838,60 @@ func (s *specService) getNodeSlug(node models.Node) string {
 	return s.sanitizeSlug(node.Title())
 }
 
+// GetNodeSlugPath returns the slash-separated slugs leading from the root node to the given
+// node, following the first parent at each level like OrganizeNodes does. The root node's
+// slug path is empty.
+func (s *specService) GetNodeSlugPath(node models.Node) (string, error) {
+	if s.IsRootNode(node) {
+		return "", nil
+	}
+
+	basePath, err := s.computeNodeBasePath(node)
+	if err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(filepath.ToSlash(basePath), "/")[1:] // drop DocumentationRoot
+	return strings.Join(append(segments, s.getNodeSlug(node)), "/"), nil
+}
+
+// FindNodeBySlugPath walks down from the root node, matching one slug per path segment
+func (s *specService) FindNodeBySlugPath(slugPath string) (models.Node, error) {
+	node, err := s.GetRootNode()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, segment := range strings.Split(strings.Trim(slugPath, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+
+		children, err := s.GetChildren(node.ID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get children for node %s: %w", node.ID(), err)
+		}
+
+		var match models.Node
+		for _, child := range children {
+			if s.getNodeSlug(child) == segment {
+				match = child
+				break
+			}
+		}
+		if match == nil {
+			return nil, models.NewZammError(models.ErrTypeNotFound, fmt.Sprintf("no node found at slug path %s", slugPath))
+		}
+		node = match
+	}
+
+	return node, nil
+}
+
 func (s *specService) computeNodeBasePath(node models.Node) (string, error) {
 	var pathSegments []string
 	currentNode := node
+	visited := []string{node.ID()}
 
 	for {
 		parents, err := s.GetParents(currentNode.ID())
@@ -705,6 +907,10 @@ func (s *specService) computeNodeBasePath(node models.Node) (string, error) {
 		}
 
 		parent := parents[0]
+		if slices.Contains(visited, parent.ID()) {
+			return "", s.ancestorCycleError(visited, parent.ID())
+		}
+		visited = append(visited, parent.ID())
 		parentSlug := s.getNodeSlug(parent)
 
 		// Only add parent slug to path if it's not empty (i.e., not the root)
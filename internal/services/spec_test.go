@@ -1,7 +1,11 @@
 package services
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
@@ -387,6 +391,37 @@ func TestAddChildToParent(t *testing.T) {
 		}
 	})
 
+	t.Run("PreventCycle", func(t *testing.T) {
+		grandchild, err := service.CreateSpec("Grandchild", "Grandchild content")
+		if err != nil {
+			t.Fatalf("Failed to create grandchild spec: %v", err)
+		}
+		if _, err := service.AddChildToParent(grandchild.ID(), childSpec.ID(), "child"); err != nil {
+			t.Fatalf("Failed to add grandchild: %v", err)
+		}
+
+		// Parent -> Child -> Grandchild already exists, so Parent can't become a child of Grandchild
+		_, err = service.AddChildToParent(parentSpec.ID(), grandchild.ID(), "child")
+		zammErr, ok := err.(*models.ZammError)
+		if !ok {
+			t.Fatalf("Expected ZammError, got %T (%v)", err, err)
+		}
+		if zammErr.Type != models.ErrTypeConflict {
+			t.Errorf("Expected conflict error, got %v", zammErr.Type)
+		}
+		if want := "Parent -> Grandchild -> Child -> Parent"; zammErr.Details != want {
+			t.Errorf("Expected cycle %q, got %q", want, zammErr.Details)
+		}
+
+		parents, err := service.GetParents(parentSpec.ID())
+		if err != nil {
+			t.Fatalf("Failed to get parents: %v", err)
+		}
+		if len(parents) != 0 {
+			t.Errorf("Expected rejected link not to be stored, got %d parents", len(parents))
+		}
+	})
+
 	t.Run("CustomLinkLabel", func(t *testing.T) {
 		// Create a new child spec for custom link type test
 		customChild, err := service.CreateSpec("Custom Child", "Custom child content")
@@ -423,6 +458,145 @@ func TestAddChildToParent(t *testing.T) {
 	})
 }
 
+func TestFindCycles(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	service := NewSpecService(store)
+
+	specs := make(map[string]*models.Spec)
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		specs[id] = models.NewSpecWithID(id, strings.ToUpper(id), id+" content")
+		if err := store.WriteNode(specs[id]); err != nil {
+			t.Fatalf("Failed to write spec %s: %v", id, err)
+		}
+	}
+
+	cycles, err := service.FindCycles()
+	if err != nil {
+		t.Fatalf("Failed to find cycles: %v", err)
+	}
+	if len(cycles) != 0 {
+		t.Errorf("Expected no cycles in an empty hierarchy, got %v", cycles)
+	}
+
+	// Links written directly to storage, as a hand-edited spec-links.csv might contain them
+	links := []*models.SpecSpecLink{
+		{FromSpecID: "a", ToSpecID: "b", LinkLabel: "child"},
+		{FromSpecID: "b", ToSpecID: "c", LinkLabel: "child"},
+		{FromSpecID: "c", ToSpecID: "a", LinkLabel: "child"},
+		{FromSpecID: "d", ToSpecID: "a", LinkLabel: "child"},
+		{FromSpecID: "e", ToSpecID: "e", LinkLabel: "child"},
+	}
+	for _, link := range links {
+		if err := store.CreateSpecSpecLink(link); err != nil {
+			t.Fatalf("Failed to create spec link: %v", err)
+		}
+	}
+
+	cycles, err = service.FindCycles()
+	if err != nil {
+		t.Fatalf("Failed to find cycles: %v", err)
+	}
+	if len(cycles) != 2 {
+		t.Fatalf("Expected 2 cycles, got %v", cycles)
+	}
+	if got := cycles[0].String(); got != "A -> B -> C -> A" {
+		t.Errorf("Unexpected first cycle %q", got)
+	}
+	if got := cycles[1].String(); got != "E -> E" {
+		t.Errorf("Unexpected second cycle %q", got)
+	}
+}
+
+func TestAncestorWalksStopAtCycles(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".zamm")
+	store, err := storage.New(baseDir)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	service := NewSpecService(store)
+	if err := service.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := service.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if err := store.WriteNode(models.NewSpecWithID(id, strings.ToUpper(id), id+" content")); err != nil {
+			t.Fatalf("Failed to write spec %s: %v", id, err)
+		}
+	}
+
+	// A cycle written straight into spec-links.csv, as a hand edit or a merge might leave it
+	csv := "from_spec_id,to_spec_id,link_label\na,b,child\nb,a,child\nb," + root.ID() + ",child\n"
+	if err := os.WriteFile(filepath.Join(baseDir, "spec-links.csv"), []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write spec links: %v", err)
+	}
+
+	expectCycle := func(t *testing.T, err error, cycle string) {
+		t.Helper()
+		var zammErr *models.ZammError
+		if !errors.As(err, &zammErr) {
+			t.Fatalf("Expected ZammError, got %T: %v", err, err)
+		}
+		if zammErr.Type != models.ErrTypeConflict || !strings.HasPrefix(zammErr.Details, cycle) {
+			t.Errorf("Expected a conflict naming the cycle %s, got %v: %s", cycle, zammErr.Type, zammErr.Details)
+		}
+	}
+
+	a, err := service.ReadNode("a")
+	if err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	_, err = service.GetNodeSlugPath(a)
+	expectCycle(t, err, "A -> B -> A")
+	expectCycle(t, service.OrganizeNodes("a"), "A -> B -> A")
+	// Organizing everything comes down from the root through B
+	expectCycle(t, service.OrganizeNodes(""), "B -> A -> B")
+}
+
+func TestConcurrentLinksCannotFormACycle(t *testing.T) {
+	// Two stores on one directory contend for it like two processes would
+	baseDir := filepath.Join(t.TempDir(), ".zamm")
+	services := make([]SpecService, 2)
+	for i := range services {
+		store, err := storage.New(baseDir)
+		if err != nil {
+			t.Fatalf("failed to create file storage: %v", err)
+		}
+		services[i] = NewSpecService(store)
+	}
+
+	for round := 0; round < 10; round++ {
+		a, err := services[0].CreateSpec("A", "a content")
+		if err != nil {
+			t.Fatalf("Failed to create spec: %v", err)
+		}
+		b, err := services[0].CreateSpec("B", "b content")
+		if err != nil {
+			t.Fatalf("Failed to create spec: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i, pair := range [][2]string{{a.ID(), b.ID()}, {b.ID(), a.ID()}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = services[i].AddChildToParent(pair[0], pair[1], "")
+			}()
+		}
+		wg.Wait()
+
+		if (errs[0] == nil) == (errs[1] == nil) {
+			t.Fatalf("Expected exactly one of the links to be added, got %v and %v", errs[0], errs[1])
+		}
+	}
+}
+
 func TestInitializeRootSpec(t *testing.T) {
 	service, cleanup := setupTestService(t)
 	defer cleanup()
@@ -489,3 +663,235 @@ func TestInitializeRootSpec(t *testing.T) {
 		}
 	})
 }
+
+func TestMoveNode(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+
+	oldParent, err := service.CreateSpec("Old Parent", "Old parent content")
+	if err != nil {
+		t.Fatalf("Failed to create old parent spec: %v", err)
+	}
+	newParent, err := service.CreateSpec("New Parent", "New parent content")
+	if err != nil {
+		t.Fatalf("Failed to create new parent spec: %v", err)
+	}
+	child, err := service.CreateSpec("Child", "Child content")
+	if err != nil {
+		t.Fatalf("Failed to create child spec: %v", err)
+	}
+	if _, err := service.AddChildToParent(child.ID(), oldParent.ID(), "implements"); err != nil {
+		t.Fatalf("Failed to add child to parent: %v", err)
+	}
+
+	t.Run("KeepsLinkLabel", func(t *testing.T) {
+		link, err := service.MoveNode(child.ID(), oldParent.ID(), newParent.ID())
+		if err != nil {
+			t.Fatalf("Failed to move node: %v", err)
+		}
+		if link.ToSpecID != newParent.ID() || link.LinkLabel != "implements" {
+			t.Errorf("Unexpected link after move: %+v", link)
+		}
+
+		parents, err := service.GetParents(child.ID())
+		if err != nil {
+			t.Fatalf("Failed to get parents: %v", err)
+		}
+		if len(parents) != 1 || parents[0].ID() != newParent.ID() {
+			t.Errorf("Expected only the new parent, got %d parents", len(parents))
+		}
+	})
+
+	t.Run("NotAChild", func(t *testing.T) {
+		_, err := service.MoveNode(child.ID(), oldParent.ID(), newParent.ID())
+		zammErr, ok := err.(*models.ZammError)
+		if !ok {
+			t.Fatalf("Expected ZammError, got %T", err)
+		}
+		if zammErr.Type != models.ErrTypeNotFound {
+			t.Errorf("Expected not found error, got %v", zammErr.Type)
+		}
+	})
+
+	t.Run("RestoresParentOnFailure", func(t *testing.T) {
+		_, err := service.MoveNode(child.ID(), newParent.ID(), "nonexistent-id")
+		if err == nil {
+			t.Fatal("Expected error when moving to a nonexistent parent")
+		}
+
+		parents, err := service.GetParents(child.ID())
+		if err != nil {
+			t.Fatalf("Failed to get parents: %v", err)
+		}
+		if len(parents) != 1 || parents[0].ID() != newParent.ID() {
+			t.Errorf("Expected original parent to be restored, got %d parents", len(parents))
+		}
+	})
+}
+
+func TestMoveChild(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	service := NewSpecService(store)
+	if err := service.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := service.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	parent, err := service.CreateSpec("Parent", "Parent content")
+	if err != nil {
+		t.Fatalf("Failed to create parent spec: %v", err)
+	}
+	if _, err := service.AddChildToParent(parent.ID(), root.ID(), "child"); err != nil {
+		t.Fatalf("Failed to link parent to root: %v", err)
+	}
+	for _, title := range []string{"First", "Second", "Third"} {
+		child, err := service.CreateSpec(title, title+" content")
+		if err != nil {
+			t.Fatalf("Failed to create child spec: %v", err)
+		}
+		if _, err := service.AddChildToParent(child.ID(), parent.ID(), "child"); err != nil {
+			t.Fatalf("Failed to link child to parent: %v", err)
+		}
+	}
+
+	childTitles := func() string {
+		t.Helper()
+		node, err := service.ReadNode(parent.ID())
+		if err != nil {
+			t.Fatalf("Failed to read parent: %v", err)
+		}
+		children, err := service.GetOrganizedChildren(node)
+		if err != nil {
+			t.Fatalf("Failed to get organized children: %v", err)
+		}
+		titles := make([]string, 0, children.Size())
+		for _, child := range children.AllNodes() {
+			titles = append(titles, child.Title())
+		}
+		return strings.Join(titles, ",")
+	}
+	childID := func(title string) string {
+		t.Helper()
+		children, err := service.GetChildren(parent.ID())
+		if err != nil {
+			t.Fatalf("Failed to get children: %v", err)
+		}
+		for _, child := range children {
+			if child.Title() == title {
+				return child.ID()
+			}
+		}
+		t.Fatalf("No child titled %s", title)
+		return ""
+	}
+
+	if got := childTitles(); got != "First,Second,Third" {
+		t.Fatalf("Expected children in the order they were linked, got %s", got)
+	}
+	if _, err := service.MoveChild(parent.ID(), childID("Third"), -1); err != nil {
+		t.Fatalf("Failed to move child: %v", err)
+	}
+	if _, err := service.MoveChild(parent.ID(), childID("First"), -1); err != nil {
+		t.Fatalf("Failed to move the first child: %v", err)
+	}
+	if got := childTitles(); got != "First,Third,Second" {
+		t.Errorf("Expected the third child to move up one place, got %s", got)
+	}
+
+	// The order is in the parent's file, and survives organizing the files
+	if err := service.OrganizeNodes(""); err != nil {
+		t.Fatalf("Failed to organize nodes: %v", err)
+	}
+	if got := childTitles(); got != "First,Third,Second" {
+		t.Errorf("Expected organizing to keep the order, got %s", got)
+	}
+	data, err := os.ReadFile(store.GetNodeFilePath(parent.ID()))
+	if err != nil {
+		t.Fatalf("Failed to read parent file: %v", err)
+	}
+	markdown := string(data)
+	if !(strings.Index(markdown, "[First]") < strings.Index(markdown, "[Third]") && strings.Index(markdown, "[Third]") < strings.Index(markdown, "[Second]")) {
+		t.Errorf("Expected the child links section in the chosen order, got:\n%s", markdown)
+	}
+
+	// Children linked later go after the ones that were ordered
+	fourth, err := service.CreateSpec("Fourth", "Fourth content")
+	if err != nil {
+		t.Fatalf("Failed to create child spec: %v", err)
+	}
+	if _, err := service.AddChildToParent(fourth.ID(), parent.ID(), "child"); err != nil {
+		t.Fatalf("Failed to link child to parent: %v", err)
+	}
+	if _, err := service.MoveChild(parent.ID(), fourth.ID(), -2); err != nil {
+		t.Fatalf("Failed to move child: %v", err)
+	}
+	if got := childTitles(); got != "First,Fourth,Third,Second" {
+		t.Errorf("Expected the new child to move up two places, got %s", got)
+	}
+
+	_, err = service.MoveChild(parent.ID(), root.ID(), 1)
+	if zammErr, ok := err.(*models.ZammError); !ok || zammErr.Type != models.ErrTypeNotFound {
+		t.Errorf("Expected a not found error for a node that isn't a child, got %v", err)
+	}
+}
+
+func TestSlugPaths(t *testing.T) {
+	service, cleanup := setupTestService(t)
+	defer cleanup()
+
+	if err := service.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := service.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+
+	parent, err := service.CreateSpec("Storage Layer", "Parent content")
+	if err != nil {
+		t.Fatalf("Failed to create parent spec: %v", err)
+	}
+	if _, err := service.AddChildToParent(parent.ID(), root.ID(), "child"); err != nil {
+		t.Fatalf("Failed to link parent to root: %v", err)
+	}
+	child, err := service.CreateSpec("File Format", "Child content")
+	if err != nil {
+		t.Fatalf("Failed to create child spec: %v", err)
+	}
+	if _, err := service.AddChildToParent(child.ID(), parent.ID(), "child"); err != nil {
+		t.Fatalf("Failed to link child to parent: %v", err)
+	}
+
+	slugPath, err := service.GetNodeSlugPath(child)
+	if err != nil {
+		t.Fatalf("Failed to get slug path: %v", err)
+	}
+	if slugPath != "storage-layer/file-format" {
+		t.Errorf("Expected slug path storage-layer/file-format, got %s", slugPath)
+	}
+
+	rootPath, err := service.GetNodeSlugPath(root)
+	if err != nil {
+		t.Fatalf("Failed to get root slug path: %v", err)
+	}
+	if rootPath != "" {
+		t.Errorf("Expected empty slug path for root, got %s", rootPath)
+	}
+
+	found, err := service.FindNodeBySlugPath(slugPath)
+	if err != nil {
+		t.Fatalf("Failed to find node by slug path: %v", err)
+	}
+	if found.ID() != child.ID() {
+		t.Errorf("Expected to find %s, got %s", child.ID(), found.ID())
+	}
+
+	if _, err := service.FindNodeBySlugPath("storage-layer/missing"); err == nil {
+		t.Error("Expected error for unknown slug path")
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// statusTransitions lists the statuses a node can move to from each status. A node can
+// always be deprecated, and steps back for rework.
+var statusTransitions = map[models.Status][]models.Status{
+	models.StatusDraft:       {models.StatusProposed, models.StatusDeprecated},
+	models.StatusProposed:    {models.StatusApproved, models.StatusDraft, models.StatusDeprecated},
+	models.StatusApproved:    {models.StatusImplemented, models.StatusProposed, models.StatusDeprecated},
+	models.StatusImplemented: {models.StatusVerified, models.StatusApproved, models.StatusDeprecated},
+	models.StatusVerified:    {models.StatusImplemented, models.StatusDeprecated},
+	models.StatusDeprecated:  {models.StatusDraft},
+}
+
+// StatusChange records a node moving from one status to another
+type StatusChange struct {
+	NodeID string        `json:"node_id"`
+	Title  string        `json:"title"`
+	From   models.Status `json:"from"`
+	To     models.Status `json:"to"`
+}
+
+// StatusService interface defines how nodes move through their lifecycle
+type StatusService interface {
+	SetStatus(id string, status models.Status) (*StatusChange, error)
+	NextStatuses(node models.Node) []models.Status
+}
+
+// statusService implements the StatusService interface
+type statusService struct {
+	storage     storage.Storage
+	specService SpecService
+}
+
+// NewStatusService creates a new StatusService instance
+func NewStatusService(storage storage.Storage, specService SpecService) StatusService {
+	return &statusService{
+		storage:     storage,
+		specService: specService,
+	}
+}
+
+// NextStatuses returns the statuses a node can move to from its current one. A node with
+// a status zamm doesn't know, written by hand, can move to any status.
+func (s *statusService) NextStatuses(node models.Node) []models.Status {
+	next, ok := statusTransitions[node.Status()]
+	if !ok {
+		return models.Statuses
+	}
+	return next
+}
+
+// SetStatus moves a node to a new status, if the transition is allowed. A node can only
+// be implemented once it has at least one implements commit link.
+func (s *statusService) SetStatus(id string, status models.Status) (*StatusChange, error) {
+	node, err := s.specService.ReadNode(id)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &StatusChange{NodeID: node.ID(), Title: node.Title(), From: node.Status(), To: status}
+	if change.From == status {
+		return change, nil
+	}
+
+	next := s.NextStatuses(node)
+	if !slices.Contains(next, status) {
+		names := make([]string, 0, len(next))
+		for _, allowed := range next {
+			names = append(names, string(allowed))
+		}
+		zammErr := models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("cannot move node %s from %s to %s", node.ID(), change.From, status))
+		zammErr.Details = fmt.Sprintf("from %s it can move to %s", change.From, strings.Join(names, ", "))
+		return nil, zammErr
+	}
+
+	if status == models.StatusImplemented {
+		if err := s.requireImplementsLink(node); err != nil {
+			return nil, err
+		}
+	}
+
+	node.SetStatus(status)
+	children, err := s.specService.GetOrganizedChildren(node)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.WriteNodeWithChildren(node, children); err != nil {
+		return nil, fmt.Errorf("failed to save node %s: %w", node.ID(), err)
+	}
+	return change, nil
+}
+
+func (s *statusService) requireImplementsLink(node models.Node) error {
+	// Any kind of node can be implemented, not only specs
+	links, err := s.storage.GetLinksBySpec(node.ID())
+	if err != nil {
+		return fmt.Errorf("failed to get commit links for node %s: %w", node.ID(), err)
+	}
+	for _, link := range links {
+		if link.LinkLabel == "implements" {
+			return nil
+		}
+	}
+
+	zammErr := models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("node %s has no implements commit link", node.ID()))
+	zammErr.Details = fmt.Sprintf("link the commit that implements it first, with zamm link create --spec %s --commit <commit>", node.ID())
+	return zammErr
+}
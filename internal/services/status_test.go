@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	statusService := NewStatusService(store, specService)
+
+	spec, err := specService.CreateSpec("Spec", "Spec content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	if spec.Status() != models.StatusDraft {
+		t.Fatalf("Expected a new spec to be a draft, got %s", spec.Status())
+	}
+
+	var zammErr *models.ZammError
+	if _, err := statusService.SetStatus(spec.ID(), models.StatusApproved); !errors.As(err, &zammErr) || zammErr.Type != models.ErrTypeValidation {
+		t.Fatalf("Expected skipping proposed to be rejected, got %v", err)
+	}
+
+	for _, status := range []models.Status{models.StatusProposed, models.StatusApproved} {
+		if _, err := statusService.SetStatus(spec.ID(), status); err != nil {
+			t.Fatalf("Failed to move to %s: %v", status, err)
+		}
+	}
+	if _, err := statusService.SetStatus(spec.ID(), models.StatusImplemented); err == nil {
+		t.Fatal("Expected implemented to need an implements link")
+	}
+
+	if err := store.CreateSpecCommitLink(&models.SpecCommitLink{SpecID: spec.ID(), CommitID: "aaa", RepoPath: ".", LinkLabel: "implements"}); err != nil {
+		t.Fatalf("Failed to create commit link: %v", err)
+	}
+	change, err := statusService.SetStatus(spec.ID(), models.StatusImplemented)
+	if err != nil {
+		t.Fatalf("Failed to move to implemented: %v", err)
+	}
+	if change.From != models.StatusApproved || change.To != models.StatusImplemented {
+		t.Errorf("Expected a change from approved to implemented, got %+v", change)
+	}
+
+	node, err := store.ReadNode(spec.ID())
+	if err != nil {
+		t.Fatalf("Failed to read spec: %v", err)
+	}
+	if node.Status() != models.StatusImplemented {
+		t.Errorf("Expected the status to be saved, got %s", node.Status())
+	}
+	if len(node.Metadata()) != 0 {
+		t.Errorf("Expected status to be a node field rather than metadata, got %v", node.Metadata())
+	}
+}
+
+func TestNonSpecNodesCanBeImplemented(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	statusService := NewStatusService(store, NewSpecService(store))
+
+	project := models.NewProject("Project", "Project content")
+	project.SetStatus(models.StatusApproved)
+	if err := store.WriteNode(project); err != nil {
+		t.Fatalf("Failed to write project: %v", err)
+	}
+
+	var zammErr *models.ZammError
+	_, err = statusService.SetStatus(project.ID(), models.StatusImplemented)
+	if !errors.As(err, &zammErr) || zammErr.Message != "node "+project.ID()+" has no implements commit link" {
+		t.Fatalf("Expected implemented to need an implements link, got %v", err)
+	}
+
+	if err := store.CreateSpecCommitLink(&models.SpecCommitLink{SpecID: project.ID(), CommitID: "aaa", RepoPath: ".", LinkLabel: "implements"}); err != nil {
+		t.Fatalf("Failed to create commit link: %v", err)
+	}
+	if _, err := statusService.SetStatus(project.ID(), models.StatusImplemented); err != nil {
+		t.Fatalf("Failed to move the project to implemented: %v", err)
+	}
+}
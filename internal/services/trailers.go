@@ -0,0 +1,81 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SpecTrailerLabels lists the commit message trailer keys that link a commit to a spec,
+// matching the ways a commit can relate to a spec
+var SpecTrailerLabels = []string{"implements", "updates", "fixes", "refactors", "documents", "tests"}
+
+var trailerLinePattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*):\s*(.*)$`)
+
+// scissorsLine is the line git puts above the diff it appends to the message of a verbose
+// commit. Git drops it and everything after it from the message.
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// SpecTrailer is a commit message trailer that references a spec, e.g. "Implements: <spec-id>"
+type SpecTrailer struct {
+	Label     string `json:"label"`
+	Reference string `json:"reference"`
+}
+
+// ParseSpecTrailers extracts spec references from the trailer block of a commit message.
+// The trailer block is the last paragraph of the message; comment lines starting with
+// '#' and everything from git's scissors line on are ignored so that messages from
+// commit-msg hooks can be parsed as-is. A single trailer may reference several specs
+// separated by commas.
+func ParseSpecTrailers(message string) []SpecTrailer {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if strings.TrimRight(line, "\r") == scissorsLine {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+
+	// Find the last paragraph
+	end := len(lines)
+	for end > 0 && lines[end-1] == "" {
+		end--
+	}
+	start := end
+	for start > 0 && lines[start-1] != "" {
+		start--
+	}
+
+	trailers := make([]SpecTrailer, 0)
+	for _, line := range lines[start:end] {
+		match := trailerLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		label := strings.ToLower(match[1])
+		if !isSpecTrailerLabel(label) {
+			continue
+		}
+
+		for _, reference := range strings.Split(match[2], ",") {
+			reference = strings.TrimSpace(reference)
+			if reference != "" {
+				trailers = append(trailers, SpecTrailer{Label: label, Reference: reference})
+			}
+		}
+	}
+
+	return trailers
+}
+
+func isSpecTrailerLabel(label string) bool {
+	for _, specLabel := range SpecTrailerLabels {
+		if label == specLabel {
+			return true
+		}
+	}
+	return false
+}
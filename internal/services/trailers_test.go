@@ -0,0 +1,160 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func TestParseSpecTrailers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		message  string
+		expected []SpecTrailer
+	}{
+		{
+			name:     "no trailers",
+			message:  "Fix a bug\n\nLonger description of the fix.",
+			expected: []SpecTrailer{},
+		},
+		{
+			name:    "single trailer",
+			message: "Add storage layer\n\nImplements: storage",
+			expected: []SpecTrailer{
+				{Label: "implements", Reference: "storage"},
+			},
+		},
+		{
+			name:    "multiple trailers and references",
+			message: "Refactor links\n\nSome body text.\n\nRefactors: abc, def\nSigned-off-by: Someone <s@example.com>\ntests: links",
+			expected: []SpecTrailer{
+				{Label: "refactors", Reference: "abc"},
+				{Label: "refactors", Reference: "def"},
+				{Label: "tests", Reference: "links"},
+			},
+		},
+		{
+			name:     "trailers outside the last paragraph are ignored",
+			message:  "Subject\n\nFixes: early\n\nThe real body.",
+			expected: []SpecTrailer{},
+		},
+		{
+			name:    "comment lines are ignored",
+			message: "Subject\n\nFixes: bug-spec\n\n# Please enter the commit message for your changes.\n# Lines starting with '#' will be ignored.\n",
+			expected: []SpecTrailer{
+				{Label: "fixes", Reference: "bug-spec"},
+			},
+		},
+		{
+			name:    "the diff of a verbose commit is ignored",
+			message: "Subject\n\nImplements: storage\n\n# Please enter the commit message for your changes.\n# ------------------------ >8 ------------------------\n# Do not modify or remove the line above.\ndiff --git a/storage.go b/storage.go\nindex 1234567..89abcde 100644\n--- a/storage.go\n+++ b/storage.go\n@@ -1 +1 @@\n-package old\n+package storage\n",
+			expected: []SpecTrailer{
+				{Label: "implements", Reference: "storage"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ParseSpecTrailers(tc.message)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestScanCommits(t *testing.T) {
+	repoDir, _ := setupTestRepo(t)
+
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	linkService := NewLinkService(store, NewGitService())
+
+	storageSpec, err := specService.CreateSpec("Storage", "Storage content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	storageSpec.SetSlug("storage")
+	if err := store.WriteNode(storageSpec); err != nil {
+		t.Fatalf("Failed to set slug: %v", err)
+	}
+
+	testsSpec, err := specService.CreateSpec("Tests", "Tests content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+
+	since := runGit(t, repoDir, "rev-parse", "HEAD")
+	first := commitFile(t, repoDir, "a.txt", "a\n", "Add storage\n\nImplements: storage")
+	second := commitFile(t, repoDir, "b.txt", "b\n", "Add tests\n\nTests: "+testsSpec.ID()+", missing-spec")
+
+	result, err := linkService.ScanCommits(repoDir, LogOptions{RevRange: since + "..HEAD", Reverse: true})
+	if err != nil {
+		t.Fatalf("Failed to scan commits: %v", err)
+	}
+
+	if result.CommitsScanned != 2 {
+		t.Errorf("Expected 2 commits scanned, got %d", result.CommitsScanned)
+	}
+	if len(result.Created) != 2 {
+		t.Fatalf("Expected 2 links created, got %d", len(result.Created))
+	}
+	if result.Created[0].CommitID != first || result.Created[0].SpecID != storageSpec.ID() || result.Created[0].LinkLabel != "implements" {
+		t.Errorf("Unexpected first link: %+v", result.Created[0])
+	}
+	if result.Created[1].CommitID != second || result.Created[1].SpecID != testsSpec.ID() || result.Created[1].LinkLabel != "tests" {
+		t.Errorf("Unexpected second link: %+v", result.Created[1])
+	}
+	if len(result.Unresolved) != 1 || result.Unresolved[0].Reference != "missing-spec" {
+		t.Errorf("Expected missing-spec to be unresolved, got %+v", result.Unresolved)
+	}
+
+	// Scanning again must not create duplicates
+	result, err = linkService.ScanCommits(repoDir, LogOptions{RevRange: since + "..HEAD", Reverse: true})
+	if err != nil {
+		t.Fatalf("Failed to rescan commits: %v", err)
+	}
+	if len(result.Created) != 0 || len(result.Existing) != 2 {
+		t.Errorf("Expected rescan to find 2 existing links and create none, got %d created and %d existing",
+			len(result.Created), len(result.Existing))
+	}
+
+	links, err := linkService.GetCommitsForSpec(storageSpec.ID())
+	if err != nil {
+		t.Fatalf("Failed to get commits for spec: %v", err)
+	}
+	if len(links) != 1 {
+		t.Errorf("Expected exactly 1 link for storage spec, got %d", len(links))
+	}
+}
+
+func TestCheckTrailers(t *testing.T) {
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	linkService := NewLinkService(store, NewGitService())
+
+	spec, err := specService.CreateSpec("Known", "Known content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+
+	unresolved := linkService.CheckTrailers("Subject\n\nImplements: " + spec.ID() + "\nFixes: unknown-slug\n")
+	if len(unresolved) != 1 {
+		t.Fatalf("Expected 1 unresolved trailer, got %+v", unresolved)
+	}
+	if unresolved[0].Label != "fixes" || unresolved[0].Reference != "unknown-slug" {
+		t.Errorf("Unexpected unresolved trailer: %+v", unresolved[0])
+	}
+
+	if unresolved := linkService.CheckTrailers("Subject only"); len(unresolved) != 0 {
+		t.Errorf("Expected no unresolved trailers, got %+v", unresolved)
+	}
+}
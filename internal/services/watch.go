@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+// WatchResult describes what was done about a change to the store's files
+type WatchResult struct {
+	// Node is the node whose file changed, as re-read from the file, or nil if the change
+	// wasn't to a node file or the file was removed
+	Node models.Node
+	// RegeneratedParents are the parents whose child links section no longer matched the
+	// changed node, and was regenerated
+	RegeneratedParents []models.Node
+}
+
+// WatchService interface defines how hand edits to node files are folded back into the store
+type WatchService interface {
+	HandleChange(change storage.Change) (*WatchResult, error)
+}
+
+// watchService implements the WatchService interface
+type watchService struct {
+	storage     *storage.FileStorage
+	specService SpecService
+}
+
+// NewWatchService creates a new WatchService instance
+func NewWatchService(fileStorage *storage.FileStorage, specService SpecService) WatchService {
+	return &watchService{
+		storage:     fileStorage,
+		specService: specService,
+	}
+}
+
+// HandleChange re-reads a node file that changed on disk and regenerates the child links
+// sections of its parents, which show its title. The edited file itself is left alone,
+// so as not to write over an editor that still has it open. Parents are only written if
+// their section is out of date, so the changes this causes settle after one round.
+func (s *watchService) HandleChange(change storage.Change) (*WatchResult, error) {
+	result := &WatchResult{RegeneratedParents: make([]models.Node, 0)}
+	if change.Kind != storage.NodeFileChanged {
+		return result, nil
+	}
+
+	if _, err := os.Stat(change.Path); errors.Is(err, os.ErrNotExist) {
+		// Removed or moved away; a move shows up as its own change to the new path
+		return result, nil
+	}
+
+	node, err := s.storage.ReadNode(change.NodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", change.Path, err)
+	}
+	result.Node = node
+
+	parents, err := s.specService.GetParents(node.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parents of node %s: %w", node.ID(), err)
+	}
+	for _, parent := range parents {
+		children, err := s.specService.GetOrganizedChildren(parent)
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := s.storage.HasCurrentChildSection(parent, children)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check child section of node %s: %w", parent.ID(), err)
+		}
+		if current {
+			continue
+		}
+
+		if err := s.storage.WriteNodeWithChildren(parent, children); err != nil {
+			return nil, fmt.Errorf("failed to regenerate child links of node %s: %w", parent.ID(), err)
+		}
+		result.RegeneratedParents = append(result.RegeneratedParents, parent)
+	}
+
+	return result, nil
+}
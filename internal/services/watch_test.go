@@ -0,0 +1,82 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/storage"
+)
+
+func TestWatchRegeneratesParentsOfHandEditedNodes(t *testing.T) {
+	store, err := storage.New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	specService := NewSpecService(store)
+	if err := specService.InitializeRootSpec(); err != nil {
+		t.Fatalf("Failed to initialize root spec: %v", err)
+	}
+	root, err := specService.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to get root node: %v", err)
+	}
+	child, err := specService.CreateSpec("Child", "Child content")
+	if err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+	if _, err := specService.AddChildToParent(child.ID(), root.ID(), "child"); err != nil {
+		t.Fatalf("Failed to link child: %v", err)
+	}
+	watchService := NewWatchService(store, specService)
+
+	childPath := store.GetNodeFilePath(child.ID())
+	data, err := os.ReadFile(childPath)
+	if err != nil {
+		t.Fatalf("Failed to read child file: %v", err)
+	}
+	edited := strings.Replace(string(data), "# Child", "# Renamed child", 1)
+	if err := os.WriteFile(childPath, []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to edit child file: %v", err)
+	}
+
+	change := storage.Change{Kind: storage.NodeFileChanged, NodeID: child.ID(), Path: childPath}
+	result, err := watchService.HandleChange(change)
+	if err != nil {
+		t.Fatalf("Failed to handle change: %v", err)
+	}
+	if result.Node == nil || result.Node.Title() != "Renamed child" {
+		t.Fatalf("Expected the edited node to be re-read, got %+v", result.Node)
+	}
+	if len(result.RegeneratedParents) != 1 || result.RegeneratedParents[0].ID() != root.ID() {
+		t.Fatalf("Expected the root's child links to be regenerated, got %+v", result.RegeneratedParents)
+	}
+	rootData, err := os.ReadFile(store.GetNodeFilePath(root.ID()))
+	if err != nil {
+		t.Fatalf("Failed to read root file: %v", err)
+	}
+	if !strings.Contains(string(rootData), "Renamed child") {
+		t.Errorf("Expected the root's child links to show the new title, got:\n%s", rootData)
+	}
+
+	// The regenerated root is already current, so handling the change again settles
+	result, err = watchService.HandleChange(change)
+	if err != nil {
+		t.Fatalf("Failed to handle change: %v", err)
+	}
+	if len(result.RegeneratedParents) != 0 {
+		t.Errorf("Expected nothing more to regenerate, got %+v", result.RegeneratedParents)
+	}
+
+	if err := os.Remove(childPath); err != nil {
+		t.Fatalf("Failed to remove child file: %v", err)
+	}
+	result, err = watchService.HandleChange(change)
+	if err != nil {
+		t.Fatalf("Failed to handle removal: %v", err)
+	}
+	if result.Node != nil {
+		t.Errorf("Expected no node for a removed file, got %+v", result.Node)
+	}
+}
@@ -1,35 +1,82 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
 	"gopkg.in/yaml.v3"
 )
 
-// FileStorage implements file-based storage for ZAMM
+// FileStorage implements file-based storage for ZAMM. Operations that hold the store's
+// lock do their work on a view of it that shares everything but what the operation holds,
+// see lock.go.
 type FileStorage struct {
+	*fileStore
+
+	hold lockHold
+	// journal is the undo log of the transaction the view belongs to, if any
+	journal *journal
+}
+
+// fileStore is the state every view of a FileStorage shares
+type fileStore struct {
 	baseDir string
+
+	// mu serializes the operations of the process: writers hold it exclusively and
+	// readers share it
+	mu sync.RWMutex
+
+	// lockMu guards the process's hold on the lock file shared with other processes, and
+	// lockWaitMu lets one caller at a time wait for that lock
+	lockMu      sync.Mutex
+	lockWaitMu  sync.Mutex
+	lockFile    *os.File
+	lockHolders int
+	lockTimeout time.Duration
+
+	index  *graphIndex
+	search *searchIndex
 }
 
 // New creates a new file-based storage instance
 func New(baseDir string) (*FileStorage, error) {
-	fs := &FileStorage{
-		baseDir: baseDir,
-	}
+	fs := &FileStorage{fileStore: &fileStore{
+		baseDir:     baseDir,
+		lockTimeout: DefaultLockTimeout,
+		index:       newGraphIndex(),
+		search:      newSearchIndex(),
+	}}
 
 	if _, err := os.Stat(fs.nodesDir()); errors.Is(err, os.ErrNotExist) {
 		if err := fs.initialize(); err != nil {
 			return nil, err
 		}
 	}
+
+	// Roll back whatever an interrupted process left behind, unless another process is
+	// using the store and so may still be in the middle of it
+	if err := fs.acquireLock(true, 0); err == nil {
+		// Stores created before the lock file and journal existed don't ignore them yet
+		err = fs.ignoreProcessFiles()
+		fs.releaseLock()
+		if err != nil {
+			return nil, err
+		}
+	} else if zammErr, ok := err.(*models.ZammError); !ok || zammErr.Type != models.ErrTypeConflict {
+		return nil, err
+	}
 	return fs, nil
 }
 
@@ -51,7 +98,7 @@ func (fs *FileStorage) initialize() error {
 	}
 
 	// Create empty files if they don't exist
-	files := []string{"spec-links.csv", "commit-links.csv", "node-files.csv", "project_metadata.json"}
+	files := []string{"spec-links.csv", "commit-links.csv", "node-files.csv", "project_metadata.json", ".gitignore"}
 	for _, file := range files {
 		path := filepath.Join(fs.baseDir, file)
 		if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -80,8 +127,10 @@ func (fs *FileStorage) createEmptyFile(path, filename string) error {
 			{"node_id", "file_path"},
 		})
 	case "project_metadata.json":
-		metadata := models.ProjectMetadata{}
+		metadata := models.ProjectMetadata{SchemaVersion: SchemaVersion}
 		return fs.writeJSONFile(path, metadata)
+	case ".gitignore":
+		return fs.writeFile(path, []byte(strings.Join(processFiles, "\n")+"\n"))
 	default:
 		// Create empty file
 		file, err := os.Create(path)
@@ -92,31 +141,82 @@ func (fs *FileStorage) createEmptyFile(path, filename string) error {
 	}
 }
 
+// processFiles are the entries of .zamm/.gitignore: the lock file and transaction journal
+// only mean something to running processes
+var processFiles = []string{"lock", "journal/"}
+
+// ignoreProcessFiles adds the entries of processFiles that .zamm/.gitignore is missing,
+// keeping whatever else it lists
+func (fs *FileStorage) ignoreProcessFiles() error {
+	path := filepath.Join(fs.baseDir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	content := string(data)
+	lines := strings.Split(content, "\n")
+	for _, entry := range processFiles {
+		if slices.Contains(lines, entry) {
+			continue
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += entry + "\n"
+	}
+	if content == string(data) {
+		return nil
+	}
+	return fs.writeFile(path, []byte(content))
+}
+
 // Node operations
 
-// DeleteNode deletes a node
+// DeleteNode deletes a node's file and its row in node-files.csv. Links to the node are
+// left for the caller to remove.
 func (fs *FileStorage) DeleteNode(id string) error {
-	path := fs.GetNodeFilePath(id)
+	err := fs.transaction(func(fs *FileStorage) error {
+		path := fs.GetNodeFilePath(id)
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return models.NewZammError(models.ErrTypeNotFound, "node not found")
-	}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return models.NewZammError(models.ErrTypeNotFound, "node not found")
+		}
 
-	return os.Remove(path)
+		if err := fs.removeFile(path); err != nil {
+			return err
+		}
+		return fs.RemoveNodeFilePath(id)
+	})
+	if err != nil {
+		return err
+	}
+	fs.search.remove(id)
+	return nil
 }
 
 // ListNodes returns all nodes
 func (fs *FileStorage) ListNodes() ([]models.Node, error) {
+	var nodes []models.Node
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		nodes, err = fs.listNodes()
+		return err
+	})
+	return nodes, err
+}
+
+func (fs *FileStorage) listNodes() ([]models.Node, error) {
 	nodes := make([]models.Node, 0)
 
 	// Get all nodes from node-files.csv
-	nodeFiles, err := fs.getAllNodeFileLinks()
+	nodeFiles, err := fs.nodeFileLinks()
 	if err != nil {
 		return nil, err
 	}
 
 	for nodeID := range nodeFiles {
-		node, err := fs.ReadNode(nodeID)
+		node, err := fs.readNode(nodeID)
 		if err != nil {
 			continue // Skip invalid files
 		}
@@ -131,27 +231,76 @@ func (fs *FileStorage) ListNodes() ([]models.Node, error) {
 	return nodes, nil
 }
 
+// Search returns the nodes whose titles and contents contain every word of the query,
+// ranked by relevance
+func (fs *FileStorage) Search(query string) ([]SearchHit, error) {
+	nodes, err := fs.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	return fs.search.search(nodes, query), nil
+}
+
+// ReadNode reads a node from its markdown file
 func (fs *FileStorage) ReadNode(id string) (models.Node, error) {
+	var node models.Node
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		node, err = fs.readNode(id)
+		return err
+	})
+	return node, err
+}
+
+func (fs *FileStorage) readNode(id string) (models.Node, error) {
 	path := fs.GetNodeFilePath(id)
 
-	// Read file once and parse into a generic map structure
-	data, err := os.ReadFile(path)
+	parsed, err := fs.index.node(path).load(path, func() (parsedNode, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return parsedNode{}, err
+		}
+		return parseNodeDocument(data)
+	})
 	if err != nil {
 		if os.IsNotExist(err) {
+			// Lookups of IDs that never existed shouldn't leave entries behind
+			fs.index.forgetNode(path)
 			return nil, models.NewZammError(models.ErrTypeNotFound, "node not found")
 		}
 		return nil, err
 	}
 
+	return parsed.decode()
+}
+
+// ParseNode parses a node from its markdown representation: YAML frontmatter, an
+// optional level 1 title heading and the content, followed by an optional child
+// links section which is ignored
+func ParseNode(data []byte) (models.Node, error) {
+	parsed, err := parseNodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.decode()
+}
+
+// parsedNode is a node's markdown converted to the JSON its type unmarshals from
+type parsedNode struct {
+	nodeType string
+	jsonData []byte
+}
+
+func parseNodeDocument(data []byte) (parsedNode, error) {
 	content := string(data)
 
 	if !strings.HasPrefix(content, "---\n") {
-		return nil, fmt.Errorf("invalid markdown format: missing frontmatter")
+		return parsedNode{}, fmt.Errorf("invalid markdown format: missing frontmatter")
 	}
 
 	parts := strings.SplitN(content[4:], "\n---\n", 2)
 	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid markdown format: malformed frontmatter")
+		return parsedNode{}, fmt.Errorf("invalid markdown format: malformed frontmatter")
 	}
 
 	yamlContent := parts[0]
@@ -164,7 +313,13 @@ func (fs *FileStorage) ReadNode(id string) (models.Node, error) {
 
 	var frontmatter map[string]interface{}
 	if err := yaml.Unmarshal([]byte(yamlContent), &frontmatter); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML frontmatter: %w", err)
+		return parsedNode{}, fmt.Errorf("failed to parse YAML frontmatter: %w", err)
+	}
+	if frontmatter == nil {
+		frontmatter = make(map[string]interface{})
+	}
+	if err := extractMetadata(yamlContent, frontmatter); err != nil {
+		return parsedNode{}, fmt.Errorf("failed to parse YAML frontmatter: %w", err)
 	}
 
 	// Extract title from level 1 heading if present
@@ -191,11 +346,18 @@ func (fs *FileStorage) ReadNode(id string) (models.Node, error) {
 
 	jsonData, err := json.Marshal(frontmatter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal frontmatter: %w", err)
+		return parsedNode{}, fmt.Errorf("failed to marshal frontmatter: %w", err)
 	}
 
+	return parsedNode{nodeType: nodeType, jsonData: jsonData}, nil
+}
+
+// decode creates a new node of the parsed type, so every caller gets its own copy
+func (p parsedNode) decode() (models.Node, error) {
+	jsonData := p.jsonData
+
 	// Based on the type, create the appropriate node
-	switch nodeType {
+	switch p.nodeType {
 	case "specification":
 		var spec models.Spec
 		if err := json.Unmarshal(jsonData, &spec); err != nil {
@@ -231,18 +393,20 @@ func (fs *FileStorage) WriteNode(node models.Node) error {
 
 // CreateSpecCommitLink creates a new spec-commit link
 func (fs *FileStorage) CreateSpecCommitLink(link *models.SpecCommitLink) error {
-	links, err := fs.getAllSpecCommitLinks()
-	if err != nil {
-		return err
-	}
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		links, err := fs.getAllSpecCommitLinks()
+		if err != nil {
+			return err
+		}
 
-	links = append(links, link)
-	return fs.writeSpecCommitLinks(links)
+		links = append(links, link)
+		return fs.writeSpecCommitLinks(links)
+	})
 }
 
 // GetSpecCommitLinks retrieves all spec-commit links for a spec
 func (fs *FileStorage) GetSpecCommitLinks(specID string) ([]*models.SpecCommitLink, error) {
-	allLinks, err := fs.getAllSpecCommitLinks()
+	allLinks, err := fs.ListSpecCommitLinks()
 	if err != nil {
 		return nil, err
 	}
@@ -259,55 +423,59 @@ func (fs *FileStorage) GetSpecCommitLinks(specID string) ([]*models.SpecCommitLi
 
 // DeleteSpecCommitLink deletes a spec-commit link by matching fields
 func (fs *FileStorage) DeleteSpecCommitLink(specID string) error {
-	links, err := fs.getAllSpecCommitLinks()
-	if err != nil {
-		return err
-	}
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		links, err := fs.getAllSpecCommitLinks()
+		if err != nil {
+			return err
+		}
 
-	found := false
-	filtered := make([]*models.SpecCommitLink, 0, len(links))
-	for _, link := range links {
-		if link.SpecID != specID {
-			filtered = append(filtered, link)
-		} else {
-			found = true
+		found := false
+		filtered := make([]*models.SpecCommitLink, 0, len(links))
+		for _, link := range links {
+			if link.SpecID != specID {
+				filtered = append(filtered, link)
+			} else {
+				found = true
+			}
 		}
-	}
 
-	if !found {
-		return models.NewZammError(models.ErrTypeNotFound, "spec-commit link not found")
-	}
+		if !found {
+			return models.NewZammError(models.ErrTypeNotFound, "spec-commit link not found")
+		}
 
-	return fs.writeSpecCommitLinks(filtered)
+		return fs.writeSpecCommitLinks(filtered)
+	})
 }
 
 // DeleteSpecCommitLinkByFields deletes a spec-commit link by matching all fields
 func (fs *FileStorage) DeleteSpecCommitLinkByFields(specID, commitID, repoPath string) error {
-	links, err := fs.getAllSpecCommitLinks()
-	if err != nil {
-		return err
-	}
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		links, err := fs.getAllSpecCommitLinks()
+		if err != nil {
+			return err
+		}
 
-	found := false
-	filtered := make([]*models.SpecCommitLink, 0, len(links))
-	for _, link := range links {
-		if link.SpecID != specID || link.CommitID != commitID || link.RepoPath != repoPath {
-			filtered = append(filtered, link)
-		} else {
-			found = true
+		found := false
+		filtered := make([]*models.SpecCommitLink, 0, len(links))
+		for _, link := range links {
+			if link.SpecID != specID || link.CommitID != commitID || link.RepoPath != repoPath {
+				filtered = append(filtered, link)
+			} else {
+				found = true
+			}
 		}
-	}
 
-	if !found {
-		return models.NewZammError(models.ErrTypeNotFound, "spec-commit link not found")
-	}
+		if !found {
+			return models.NewZammError(models.ErrTypeNotFound, "spec-commit link not found")
+		}
 
-	return fs.writeSpecCommitLinks(filtered)
+		return fs.writeSpecCommitLinks(filtered)
+	})
 }
 
 // GetLinksByCommit retrieves all spec-commit links for a commit
 func (fs *FileStorage) GetLinksByCommit(commitID, repoPath string) ([]*models.SpecCommitLink, error) {
-	allLinks, err := fs.getAllSpecCommitLinks()
+	allLinks, err := fs.ListSpecCommitLinks()
 	if err != nil {
 		return nil, err
 	}
@@ -327,6 +495,17 @@ func (fs *FileStorage) GetLinksBySpec(specID string) ([]*models.SpecCommitLink,
 	return fs.GetSpecCommitLinks(specID)
 }
 
+// ListSpecCommitLinks retrieves every spec-commit link
+func (fs *FileStorage) ListSpecCommitLinks() ([]*models.SpecCommitLink, error) {
+	var links []*models.SpecCommitLink
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		links, err = fs.getAllSpecCommitLinks()
+		return err
+	})
+	return links, err
+}
+
 // DeleteLink deletes a spec-commit link by specID (alias for DeleteSpecCommitLink)
 func (fs *FileStorage) DeleteLink(specID string) error {
 	return fs.DeleteSpecCommitLink(specID)
@@ -336,85 +515,115 @@ func (fs *FileStorage) DeleteLink(specID string) error {
 
 // CreateSpecSpecLink creates a new spec-spec link
 func (fs *FileStorage) CreateSpecSpecLink(link *models.SpecSpecLink) error {
-	links, err := fs.getAllSpecSpecLinks()
-	if err != nil {
-		return err
-	}
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		links, err := fs.getAllSpecSpecLinks()
+		if err != nil {
+			return err
+		}
 
-	links = append(links, link)
-	return fs.writeSpecSpecLinks(links)
+		links = append(links, link)
+		return fs.writeSpecSpecLinks(links)
+	})
 }
 
 // GetSpecSpecLinks retrieves spec-spec links
 func (fs *FileStorage) GetSpecSpecLinks(specID string, direction models.Direction) ([]*models.SpecSpecLink, error) {
-	allLinks, err := fs.getAllSpecSpecLinks()
+	var index *specLinkIndex
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		index, err = fs.specLinkIndex()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	links := make([]*models.SpecSpecLink, 0, len(allLinks))
-	for _, link := range allLinks {
-		if direction == models.Outgoing && link.FromSpecID == specID {
-			links = append(links, link)
-		} else if direction == models.Incoming && link.ToSpecID == specID {
-			links = append(links, link)
-		}
+	switch direction {
+	case models.Outgoing:
+		return copySpecSpecLinks(index.byFrom[specID]), nil
+	case models.Incoming:
+		return copySpecSpecLinks(index.byTo[specID]), nil
+	default:
+		return make([]*models.SpecSpecLink, 0), nil
 	}
+}
 
-	return links, nil
+// ListSpecSpecLinks retrieves every spec-spec link
+func (fs *FileStorage) ListSpecSpecLinks() ([]*models.SpecSpecLink, error) {
+	var links []*models.SpecSpecLink
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		links, err = fs.getAllSpecSpecLinks()
+		return err
+	})
+	return links, err
 }
 
 // DeleteSpecSpecLink deletes a spec-spec link by matching fields
 func (fs *FileStorage) DeleteSpecSpecLink(fromSpecID, toSpecID string) error {
-	links, err := fs.getAllSpecSpecLinks()
-	if err != nil {
-		return err
-	}
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		links, err := fs.getAllSpecSpecLinks()
+		if err != nil {
+			return err
+		}
 
-	found := false
-	filtered := make([]*models.SpecSpecLink, 0, len(links))
-	for _, link := range links {
-		if link.FromSpecID != fromSpecID || link.ToSpecID != toSpecID {
-			filtered = append(filtered, link)
-		} else {
-			found = true
+		found := false
+		filtered := make([]*models.SpecSpecLink, 0, len(links))
+		for _, link := range links {
+			if link.FromSpecID != fromSpecID || link.ToSpecID != toSpecID {
+				filtered = append(filtered, link)
+			} else {
+				found = true
+			}
 		}
-	}
 
-	if !found {
-		return models.NewZammError(models.ErrTypeNotFound, "spec-spec link not found")
-	}
+		if !found {
+			return models.NewZammError(models.ErrTypeNotFound, "spec-spec link not found")
+		}
 
-	return fs.writeSpecSpecLinks(filtered)
+		return fs.writeSpecSpecLinks(filtered)
+	})
 }
 
 // DeleteSpecLinkBySpecs deletes a spec-spec link by source and target spec IDs
 func (fs *FileStorage) DeleteSpecLinkBySpecs(fromSpecID, toSpecID string) error {
-	links, err := fs.getAllSpecSpecLinks()
-	if err != nil {
-		return err
-	}
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		links, err := fs.getAllSpecSpecLinks()
+		if err != nil {
+			return err
+		}
 
-	found := false
-	filtered := make([]*models.SpecSpecLink, 0, len(links))
-	for _, link := range links {
-		if link.FromSpecID != fromSpecID || link.ToSpecID != toSpecID {
-			filtered = append(filtered, link)
-		} else {
-			found = true
+		found := false
+		filtered := make([]*models.SpecSpecLink, 0, len(links))
+		for _, link := range links {
+			if link.FromSpecID != fromSpecID || link.ToSpecID != toSpecID {
+				filtered = append(filtered, link)
+			} else {
+				found = true
+			}
 		}
-	}
 
-	if !found {
-		return models.NewZammError(models.ErrTypeNotFound, "spec-spec link not found")
-	}
+		if !found {
+			return models.NewZammError(models.ErrTypeNotFound, "spec-spec link not found")
+		}
 
-	return fs.writeSpecSpecLinks(filtered)
+		return fs.writeSpecSpecLinks(filtered)
+	})
 }
 
 // Hierarchical operations
 // GetLinkedSpecs retrieves specs linked to a given spec
 func (fs *FileStorage) GetLinkedSpecs(specID string, direction models.Direction) ([]*models.Spec, error) {
+	var result []*models.Spec
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		result, err = fs.getLinkedSpecs(specID, direction)
+		return err
+	})
+	return result, err
+}
+
+func (fs *FileStorage) getLinkedSpecs(specID string, direction models.Direction) ([]*models.Spec, error) {
 	links, err := fs.GetSpecSpecLinks(specID, direction)
 	if err != nil {
 		return nil, err
@@ -445,6 +654,16 @@ func (fs *FileStorage) GetLinkedSpecs(specID string, direction models.Direction)
 
 // GetLinkedNodes retrieves nodes linked to a given node
 func (fs *FileStorage) GetLinkedNodes(nodeID string, direction models.Direction) ([]models.Node, error) {
+	var result []models.Node
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		result, err = fs.getLinkedNodes(nodeID, direction)
+		return err
+	})
+	return result, err
+}
+
+func (fs *FileStorage) getLinkedNodes(nodeID string, direction models.Direction) ([]models.Node, error) {
 	links, err := fs.GetSpecSpecLinks(nodeID, direction)
 	if err != nil {
 		return nil, err
@@ -472,7 +691,17 @@ func (fs *FileStorage) GetLinkedNodes(nodeID string, direction models.Direction)
 
 // GetOrphanSpecs retrieves all specs that don't have any parent links
 func (fs *FileStorage) GetOrphanSpecs() ([]*models.Spec, error) {
-	allNodes, err := fs.ListNodes()
+	var result []*models.Spec
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		result, err = fs.getOrphanSpecs()
+		return err
+	})
+	return result, err
+}
+
+func (fs *FileStorage) getOrphanSpecs() ([]*models.Spec, error) {
+	allNodes, err := fs.listNodes()
 	if err != nil {
 		return nil, err
 	}
@@ -503,49 +732,78 @@ func (fs *FileStorage) GetOrphanSpecs() ([]*models.Spec, error) {
 
 // ProjectMetadata operations
 
-// GetProjectMetadata retrieves project metadata
+// GetProjectMetadata retrieves project metadata, creating the default metadata if the
+// store has none
 func (fs *FileStorage) GetProjectMetadata() (*models.ProjectMetadata, error) {
-	path := filepath.Join(fs.baseDir, "project_metadata.json")
+	var metadata *models.ProjectMetadata
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		metadata, err = fs.readProjectMetadata()
+		return err
+	})
+	if !os.IsNotExist(err) {
+		return metadata, err
+	}
 
-	var metadata models.ProjectMetadata
-	if err := fs.readJSONFile(path, &metadata); err != nil {
+	err = fs.withWriteLock(func(fs *FileStorage) error {
+		var err error
+		metadata, err = fs.readProjectMetadata()
 		if os.IsNotExist(err) {
-			// Create default metadata
-			metadata = models.ProjectMetadata{}
-			if err := fs.writeJSONFile(path, metadata); err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, err
+			metadata = &models.ProjectMetadata{}
+			return fs.writeJSONFile(fs.projectMetadataPath(), metadata)
 		}
-	}
+		return err
+	})
+	return metadata, err
+}
 
+func (fs *FileStorage) projectMetadataPath() string {
+	return filepath.Join(fs.baseDir, "project_metadata.json")
+}
+
+func (fs *FileStorage) readProjectMetadata() (*models.ProjectMetadata, error) {
+	var metadata models.ProjectMetadata
+	if err := fs.readJSONFile(fs.projectMetadataPath(), &metadata); err != nil {
+		return nil, err
+	}
 	return &metadata, nil
 }
 
 // SetRootSpecID sets the root spec ID
 func (fs *FileStorage) SetRootSpecID(specID *string) error {
-	metadata, err := fs.GetProjectMetadata()
-	if err != nil {
-		return err
-	}
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		metadata, err := fs.GetProjectMetadata()
+		if err != nil {
+			return err
+		}
+
+		metadata.RootSpecID = specID
+
+		return fs.writeJSONFile(fs.projectMetadataPath(), metadata)
+	})
+}
 
-	metadata.RootSpecID = specID
+// SetSchemaVersion records the version of the store format
+func (fs *FileStorage) SetSchemaVersion(version int) error {
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		metadata, err := fs.GetProjectMetadata()
+		if err != nil {
+			return err
+		}
 
-	path := filepath.Join(fs.baseDir, "project_metadata.json")
-	return fs.writeJSONFile(path, metadata)
+		metadata.SchemaVersion = version
+
+		return fs.writeJSONFile(fs.projectMetadataPath(), metadata)
+	})
 }
 
 // Helper methods
 
 func (fs *FileStorage) getNodeFilePathIfExists(nodeID string) (string, bool) {
-	nodeFiles, err := fs.getAllNodeFileLinks()
+	nodeFiles, err := fs.nodeFileLinks()
 	if err == nil {
 		if customPath, exists := nodeFiles[nodeID]; exists {
-			if !filepath.IsAbs(customPath) {
-				return filepath.Join(filepath.Dir(fs.baseDir), customPath), true
-			}
-			return customPath, true
+			return fs.ResolveNodeFilePath(customPath), true
 		}
 	}
 	return "", false
@@ -567,6 +825,26 @@ func (fs *FileStorage) GetNodeFilePath(nodeID string) string {
 // getAllSpecCommitLinks reads all spec-commit links from CSV
 func (fs *FileStorage) getAllSpecCommitLinks() ([]*models.SpecCommitLink, error) {
 	path := filepath.Join(fs.baseDir, "commit-links.csv")
+	links, err := fs.index.commitLinks.load(path, func() ([]*models.SpecCommitLink, error) {
+		return fs.readSpecCommitLinks(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copySpecCommitLinks(links), nil
+}
+
+func copySpecCommitLinks(links []*models.SpecCommitLink) []*models.SpecCommitLink {
+	copied := make([]*models.SpecCommitLink, 0, len(links))
+	for _, link := range links {
+		linkCopy := *link
+		copied = append(copied, &linkCopy)
+	}
+	return copied
+}
+
+func (fs *FileStorage) readSpecCommitLinks(path string) ([]*models.SpecCommitLink, error) {
 	records, err := fs.readCSVFile(path)
 	if err != nil {
 		return nil, err
@@ -611,12 +889,45 @@ func (fs *FileStorage) writeSpecCommitLinks(links []*models.SpecCommitLink) erro
 		})
 	}
 
-	return fs.writeCSVFile(path, records)
+	if err := fs.writeCSVFile(path, records); err != nil {
+		fs.index.commitLinks.invalidate()
+		return err
+	}
+	fs.index.commitLinks.store(path, copySpecCommitLinks(links))
+	return nil
 }
 
 // getAllSpecSpecLinks reads all spec-spec links from CSV
 func (fs *FileStorage) getAllSpecSpecLinks() ([]*models.SpecSpecLink, error) {
+	index, err := fs.specLinkIndex()
+	if err != nil {
+		return nil, err
+	}
+	return copySpecSpecLinks(index.all), nil
+}
+
+// specLinkIndex returns the shared index of spec-spec links, which must not be modified
+func (fs *FileStorage) specLinkIndex() (*specLinkIndex, error) {
 	path := filepath.Join(fs.baseDir, "spec-links.csv")
+	return fs.index.specLinks.load(path, func() (*specLinkIndex, error) {
+		links, err := fs.readSpecSpecLinks(path)
+		if err != nil {
+			return nil, err
+		}
+		return newSpecLinkIndex(links), nil
+	})
+}
+
+func copySpecSpecLinks(links []*models.SpecSpecLink) []*models.SpecSpecLink {
+	copied := make([]*models.SpecSpecLink, 0, len(links))
+	for _, link := range links {
+		linkCopy := *link
+		copied = append(copied, &linkCopy)
+	}
+	return copied
+}
+
+func (fs *FileStorage) readSpecSpecLinks(path string) ([]*models.SpecSpecLink, error) {
 	records, err := fs.readCSVFile(path)
 	if err != nil {
 		return nil, err
@@ -659,7 +970,12 @@ func (fs *FileStorage) writeSpecSpecLinks(links []*models.SpecSpecLink) error {
 		})
 	}
 
-	return fs.writeCSVFile(path, records)
+	if err := fs.writeCSVFile(path, records); err != nil {
+		fs.index.specLinks.invalidate()
+		return err
+	}
+	fs.index.specLinks.store(path, newSpecLinkIndex(copySpecSpecLinks(links)))
+	return nil
 }
 
 // File I/O helpers
@@ -683,15 +999,20 @@ func (fs *FileStorage) generateMarkdownString(v interface{}) (string, error) {
 
 	title, hasTitle := nodeData["title"].(string)
 
-	// Create frontmatter map with all fields except content and title
+	// Create frontmatter map with all fields except content and title. Metadata fields are
+	// added after the others, in their own order.
 	frontmatter := make(map[string]interface{})
 	for key, value := range nodeData {
-		if key != "content" && key != "title" {
+		if key != "content" && key != "title" && key != "metadata" {
 			frontmatter[key] = value
 		}
 	}
+	var metadata models.Metadata
+	if node, ok := v.(models.Node); ok {
+		metadata = node.Metadata()
+	}
 
-	yamlData, err := yaml.Marshal(frontmatter)
+	yamlData, err := marshalFrontmatter(frontmatter, metadata)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal YAML frontmatter: %w", err)
 	}
@@ -716,11 +1037,22 @@ func (fs *FileStorage) generateMarkdownString(v interface{}) (string, error) {
 	return mdContent.String(), nil
 }
 
+// WriteNodeWithExtraData writes a node's markdown followed by extraData. A node without a
+// file yet is also added to node-files.csv, in the same transaction.
 func (fs *FileStorage) WriteNodeWithExtraData(node models.Node, extraData string) error {
-	path, exists := fs.getNodeFilePathIfExists(node.ID())
-	if !exists {
-		path = fs.GetNodeFilePath(node.ID())
+	content, err := fs.generateMarkdownString(node)
+	if err != nil {
+		return err
+	}
+	content += extraData
+
+	err = fs.transaction(func(fs *FileStorage) error {
+		path, exists := fs.getNodeFilePathIfExists(node.ID())
+		if exists {
+			return fs.writeFile(path, []byte(content))
+		}
 
+		path = fs.GetNodeFilePath(node.ID())
 		// Ensure the node is tracked in node-files.csv
 		// Get relative path from the project root for storage
 		projectRoot := filepath.Dir(fs.baseDir)
@@ -730,19 +1062,16 @@ func (fs *FileStorage) WriteNodeWithExtraData(node models.Node, extraData string
 			relPath = path
 		}
 
-		err = fs.updateNodeFilePath(node.ID(), relPath)
-		if err != nil {
+		if err := fs.updateNodeFilePath(node.ID(), relPath); err != nil {
 			return err
 		}
-	}
-
-	content, err := fs.generateMarkdownString(node)
+		return fs.writeFile(path, []byte(content))
+	})
 	if err != nil {
 		return err
 	}
-
-	content += extraData
-	return os.WriteFile(path, []byte(content), 0644)
+	fs.search.put(node)
+	return nil
 }
 
 // generateChildrenString generates child links for the markdown
@@ -788,7 +1117,7 @@ func (fs *FileStorage) writeJSONFile(path string, v interface{}) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return fs.writeFile(path, data)
 }
 
 // readCSVFile reads CSV data from a file
@@ -807,23 +1136,33 @@ func (fs *FileStorage) readCSVFile(path string) ([][]string, error) {
 
 // writeCSVFile writes CSV data to a file
 func (fs *FileStorage) writeCSVFile(path string, records [][]string) error {
-	file, err := os.Create(path)
-	if err != nil {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(records); err != nil {
 		return err
 	}
-	defer func() {
-		_ = file.Close() // Explicitly ignore error in defer
-	}()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
 
-	return writer.WriteAll(records)
+	return fs.writeFile(path, buf.Bytes())
 }
 
 // getAllNodeFileLinks reads all node-file mappings from CSV
 func (fs *FileStorage) getAllNodeFileLinks() (map[string]string, error) {
+	nodeFiles, err := fs.nodeFileLinks()
+	if err != nil {
+		return nil, err
+	}
+	return maps.Clone(nodeFiles), nil
+}
+
+// nodeFileLinks returns the shared node-file mappings, which must not be modified
+func (fs *FileStorage) nodeFileLinks() (map[string]string, error) {
 	path := filepath.Join(fs.baseDir, "node-files.csv")
+	return fs.index.nodeFiles.load(path, func() (map[string]string, error) {
+		return fs.readNodeFileLinks(path)
+	})
+}
+
+func (fs *FileStorage) readNodeFileLinks(path string) (map[string]string, error) {
 	records, err := fs.readCSVFile(path)
 	if err != nil {
 		return nil, err
@@ -847,7 +1186,136 @@ func (fs *FileStorage) getAllNodeFileLinks() (map[string]string, error) {
 
 // GetAllNodeFileLinks returns all node-file mappings (public wrapper for getAllNodeFileLinks)
 func (fs *FileStorage) GetAllNodeFileLinks() (map[string]string, error) {
-	return fs.getAllNodeFileLinks()
+	var nodeFiles map[string]string
+	err := fs.withReadLock(func(fs *FileStorage) error {
+		var err error
+		nodeFiles, err = fs.getAllNodeFileLinks()
+		return err
+	})
+	return nodeFiles, err
+}
+
+// ProjectRoot returns the directory containing the .zamm directory, which node file paths
+// are relative to
+func (fs *FileStorage) ProjectRoot() string {
+	return filepath.Dir(fs.baseDir)
+}
+
+// ResolveNodeFilePath turns a path from node-files.csv, which is relative to the project
+// root unless absolute, into a path that can be opened
+func (fs *FileStorage) ResolveNodeFilePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(fs.baseDir), path)
+}
+
+// SetNodeFilePath records the file a node is stored in. Relative paths are relative to
+// the project root.
+func (fs *FileStorage) SetNodeFilePath(nodeID, path string) error {
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		return fs.updateNodeFilePath(nodeID, path)
+	})
+}
+
+// RemoveNodeFilePath removes a node's row from node-files.csv
+func (fs *FileStorage) RemoveNodeFilePath(nodeID string) error {
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		nodeFiles, err := fs.getAllNodeFileLinks()
+		if err != nil {
+			return err
+		}
+
+		delete(nodeFiles, nodeID)
+		return fs.writeNodeFileLinks(nodeFiles)
+	})
+}
+
+// FindNodeFiles scans the nodes directory and the documentation folder next to the .zamm
+// directory for markdown files with an ID in their frontmatter. It returns the paths of
+// those files, relative to the project root, keyed by ID. Files that can't be parsed as
+// nodes are ignored.
+func (fs *FileStorage) FindNodeFiles() (map[string][]string, error) {
+	projectRoot := filepath.Dir(fs.baseDir)
+	found := make(map[string][]string)
+
+	for _, dir := range []string{fs.nodesDir(), filepath.Join(projectRoot, "docs")} {
+		err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if entry.IsDir() || filepath.Ext(path) != ".md" {
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			node, err := ParseNode(data)
+			if err != nil || node.ID() == "" {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(projectRoot, path)
+			if err != nil {
+				relPath = path
+			}
+			found[node.ID()] = append(found[node.ID()], relPath)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+	}
+
+	for _, paths := range found {
+		sort.Strings(paths)
+	}
+	return found, nil
+}
+
+// HasCurrentChildSection reports whether the child links section at the end of a node's
+// file matches the one that would be generated for the given children
+func (fs *FileStorage) HasCurrentChildSection(node models.Node, childGrouping models.ChildGroup) (bool, error) {
+	data, err := os.ReadFile(fs.GetNodeFilePath(node.ID()))
+	if err != nil {
+		return false, err
+	}
+
+	expected, err := fs.generateChildrenString(node, childGrouping)
+	if err != nil {
+		return false, err
+	}
+
+	return childSection(string(data)) == strings.TrimSpace(expected), nil
+}
+
+// childSection extracts the trimmed child links section that follows the last divider
+// of a node's markdown, or an empty string if there is none
+func childSection(markdown string) string {
+	if !strings.HasPrefix(markdown, "---\n") {
+		return ""
+	}
+	parts := strings.SplitN(markdown[4:], "\n---\n", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	markdown = parts[1]
+
+	dividerIndex := strings.LastIndex(markdown, "\n---\n")
+	if dividerIndex == -1 {
+		return ""
+	}
+
+	section := strings.TrimSpace(markdown[dividerIndex:])
+	if !strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(section, "---")), "## Child Specifications") {
+		return ""
+	}
+	return section
 }
 
 func (fs *FileStorage) writeNodeFileLinks(nodeFiles map[string]string) error {
@@ -869,7 +1337,12 @@ func (fs *FileStorage) writeNodeFileLinks(nodeFiles map[string]string) error {
 		records = append(records, []string{nodeID, nodeFiles[nodeID]})
 	}
 
-	return fs.writeCSVFile(path, records)
+	if err := fs.writeCSVFile(path, records); err != nil {
+		fs.index.nodeFiles.invalidate()
+		return err
+	}
+	fs.index.nodeFiles.store(path, maps.Clone(nodeFiles))
+	return nil
 }
 
 func (fs *FileStorage) WriteNodeWithChildren(node models.Node, childGrouping models.ChildGroup) error {
@@ -901,11 +1374,12 @@ func (fs *FileStorage) MoveNodeFile(node models.Node, newPath string) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.Rename(currentPath, fullNewPath); err != nil {
-		return fmt.Errorf("failed to move file: %w", err)
-	}
-
-	return fs.updateNodeFilePath(node.ID(), newPath)
+	return fs.transaction(func(fs *FileStorage) error {
+		if err := fs.renameFile(currentPath, fullNewPath); err != nil {
+			return fmt.Errorf("failed to move file: %w", err)
+		}
+		return fs.updateNodeFilePath(node.ID(), newPath)
+	})
 }
 
 type markdownChildrenRenderer struct {
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -96,3 +97,96 @@ func TestGenerateMarkdownStringWithChildren(t *testing.T) {
 		t.Error("Output should contain link to child 2", output)
 	}
 }
+
+func TestCustomFrontmatterRoundTrips(t *testing.T) {
+	fs, err := New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	frontmatter := `id: custom-id
+type: specification
+priority: 2
+owner: alice
+review:
+    due: 2025-03-01
+    by: bob
+labels:
+    - api
+    - "yes"
+`
+	if err := os.WriteFile(fs.GetNodeFilePath("custom-id"), []byte("---\n"+frontmatter+"---\n\n# Custom\n\nContent\n"), 0644); err != nil {
+		t.Fatalf("Failed to write node file: %v", err)
+	}
+
+	node, err := fs.ReadNode("custom-id")
+	if err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	metadata := node.Metadata()
+	keys := make([]string, 0, len(metadata))
+	for _, field := range metadata {
+		keys = append(keys, field.Key)
+	}
+	if strings.Join(keys, ",") != "priority,owner,review,labels" {
+		t.Fatalf("Expected the custom fields in file order, got %v", keys)
+	}
+	if owner, _ := metadata.Get("owner"); owner != "alice" {
+		t.Errorf("Expected owner alice, got %v", owner)
+	}
+
+	node.SetTitle("Renamed")
+	if err := fs.WriteNode(node); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+	data, err := os.ReadFile(fs.GetNodeFilePath("custom-id"))
+	if err != nil {
+		t.Fatalf("Failed to read node file: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "---\n"+frontmatter+"---\n\n# Renamed\n") {
+		t.Errorf("Expected the frontmatter to be written back unchanged, got:\n%s", data)
+	}
+}
+
+func TestOpeningAStoreIgnoresProcessFiles(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".zamm")
+	if _, err := New(baseDir); err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	// A store from before the lock file and journal, with an entry of the user's own
+	gitignore := filepath.Join(baseDir, ".gitignore")
+	if err := os.WriteFile(gitignore, []byte("scratch/"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := New(baseDir); err != nil {
+			t.Fatalf("Failed to reopen file storage: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(gitignore)
+	if err != nil {
+		t.Fatalf("Failed to read .gitignore: %v", err)
+	}
+	if string(data) != "scratch/\nlock\njournal/\n" {
+		t.Errorf("Expected the process files to be added once, got %q", data)
+	}
+}
+
+func TestGetProjectMetadataCreatesDefault(t *testing.T) {
+	fs, err := New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	if err := os.Remove(fs.projectMetadataPath()); err != nil {
+		t.Fatalf("Failed to remove project metadata: %v", err)
+	}
+
+	metadata, err := fs.GetProjectMetadata()
+	if err != nil || metadata.RootSpecID != nil {
+		t.Fatalf("Expected empty metadata, got %+v, %v", metadata, err)
+	}
+	if _, err := os.Stat(fs.projectMetadataPath()); err != nil {
+		t.Errorf("Expected the default metadata to be written: %v", err)
+	}
+}
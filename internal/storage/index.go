@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"os"
+	"sync"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// cachedFile holds what was parsed from one version of a file. Every file is replaced by
+// renaming a new file over it, so besides size and mtime the file's identity tells
+// versions apart, even when they are written within the filesystem's mtime granularity.
+type cachedFile[T any] struct {
+	mu    sync.Mutex
+	info  os.FileInfo
+	value T
+}
+
+// load returns the cached value if the file hasn't changed since it was cached, and
+// otherwise parses the file again
+func (c *cachedFile[T]) load(path string, parse func() (T, error)) (T, error) {
+	var zero T
+	info, err := os.Stat(path)
+	if err != nil {
+		c.invalidate()
+		return zero, err
+	}
+
+	c.mu.Lock()
+	if c.info != nil && sameVersion(c.info, info) {
+		value := c.value
+		c.mu.Unlock()
+		return value, nil
+	}
+	c.mu.Unlock()
+
+	// If the file changes again while it is parsed, the next load sees a newer version
+	// than the one recorded here and parses it again
+	value, err := parse()
+	if err != nil {
+		return zero, err
+	}
+
+	c.mu.Lock()
+	c.info = info
+	c.value = value
+	c.mu.Unlock()
+	return value, nil
+}
+
+// store records the value that was just written to the file
+func (c *cachedFile[T]) store(path string, value T) {
+	info, err := os.Stat(path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.info = nil
+		return
+	}
+	c.info = info
+	c.value = value
+}
+
+func (c *cachedFile[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.info = nil
+}
+
+func sameVersion(a, b os.FileInfo) bool {
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// specLinkIndex looks spec-spec links up by either end, keeping the order of the CSV
+type specLinkIndex struct {
+	all    []*models.SpecSpecLink
+	byFrom map[string][]*models.SpecSpecLink
+	byTo   map[string][]*models.SpecSpecLink
+}
+
+func newSpecLinkIndex(links []*models.SpecSpecLink) *specLinkIndex {
+	index := &specLinkIndex{
+		all:    links,
+		byFrom: make(map[string][]*models.SpecSpecLink),
+		byTo:   make(map[string][]*models.SpecSpecLink),
+	}
+	for _, link := range links {
+		index.byFrom[link.FromSpecID] = append(index.byFrom[link.FromSpecID], link)
+		index.byTo[link.ToSpecID] = append(index.byTo[link.ToSpecID], link)
+	}
+	return index
+}
+
+// graphIndex keeps the parsed CSV indexes and node files of a store in memory. Values
+// handed out from it are shared, so they must not be modified.
+type graphIndex struct {
+	nodeFiles   cachedFile[map[string]string]
+	specLinks   cachedFile[*specLinkIndex]
+	commitLinks cachedFile[[]*models.SpecCommitLink]
+
+	nodesMu sync.Mutex
+	nodes   map[string]*cachedFile[parsedNode] // node file path -> parsed contents
+}
+
+func newGraphIndex() *graphIndex {
+	return &graphIndex{nodes: make(map[string]*cachedFile[parsedNode])}
+}
+
+// node returns the cache for the node file at path
+func (g *graphIndex) node(path string) *cachedFile[parsedNode] {
+	g.nodesMu.Lock()
+	defer g.nodesMu.Unlock()
+
+	cached, ok := g.nodes[path]
+	if !ok {
+		cached = &cachedFile[parsedNode]{}
+		g.nodes[path] = cached
+	}
+	return cached
+}
+
+// forgetNode drops the cache for a node file that was removed or moved away
+func (g *graphIndex) forgetNode(path string) {
+	g.nodesMu.Lock()
+	defer g.nodesMu.Unlock()
+	delete(g.nodes, path)
+}
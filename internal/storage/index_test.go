@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+func setupIndexedStore(t *testing.T) *FileStorage {
+	t.Helper()
+
+	fs, err := New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	for _, node := range []models.Node{
+		models.NewSpecWithID("parent", "Parent", "Parent content"),
+		models.NewSpecWithID("child", "Child", "Child content"),
+	} {
+		if err := fs.WriteNode(node); err != nil {
+			t.Fatalf("Failed to write node: %v", err)
+		}
+	}
+	if err := fs.CreateSpecSpecLink(&models.SpecSpecLink{FromSpecID: "child", ToSpecID: "parent", LinkLabel: "child"}); err != nil {
+		t.Fatalf("Failed to create spec link: %v", err)
+	}
+	return fs
+}
+
+func TestIndexSeesWritesAndExternalChanges(t *testing.T) {
+	fs := setupIndexedStore(t)
+
+	children, err := fs.GetLinkedNodes("parent", models.Incoming)
+	if err != nil || len(children) != 1 || children[0].ID() != "child" {
+		t.Fatalf("Expected parent to have one child, got %v, %v", children, err)
+	}
+
+	// A write through the store
+	if err := fs.WriteNode(models.NewSpecWithID("child", "Renamed", "Child content")); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+	if node, err := fs.ReadNode("child"); err != nil || node.Title() != "Renamed" {
+		t.Errorf("Expected the written title, got %v, %v", node, err)
+	}
+
+	// Edits made by hand, or by another process, in place and with the same size
+	childPath := fs.GetNodeFilePath("child")
+	data, err := os.ReadFile(childPath)
+	if err != nil {
+		t.Fatalf("Failed to read node file: %v", err)
+	}
+	edited := []byte(string(data[:len(data)-len("Child content\n")]) + "Other content\n")
+	if err := os.WriteFile(childPath, edited, 0644); err != nil {
+		t.Fatalf("Failed to edit node file: %v", err)
+	}
+	if node, err := fs.ReadNode("child"); err != nil || node.Content() != "Other content" {
+		t.Errorf("Expected the edited content, got %v, %v", node, err)
+	}
+
+	linksPath := filepath.Join(fs.baseDir, "spec-links.csv")
+	if err := os.WriteFile(linksPath, []byte("from_spec_id,to_spec_id,link_label\nparent,child,child\n"), 0644); err != nil {
+		t.Fatalf("Failed to edit spec links: %v", err)
+	}
+	if children, err := fs.GetLinkedNodes("parent", models.Incoming); err != nil || len(children) != 0 {
+		t.Errorf("Expected the parent to have lost its child, got %v, %v", children, err)
+	}
+	if parents, err := fs.GetLinkedNodes("parent", models.Outgoing); err != nil || len(parents) != 1 {
+		t.Errorf("Expected the edited link, got %v, %v", parents, err)
+	}
+
+	if err := os.Remove(childPath); err != nil {
+		t.Fatalf("Failed to remove node file: %v", err)
+	}
+	if _, err := fs.ReadNode("child"); err == nil {
+		t.Error("Expected a removed node file to no longer be readable")
+	}
+}
+
+func TestIndexHandsOutCopies(t *testing.T) {
+	fs := setupIndexedStore(t)
+
+	node, err := fs.ReadNode("child")
+	if err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	node.SetTitle("Changed in memory")
+
+	links, err := fs.GetSpecSpecLinks("child", models.Outgoing)
+	if err != nil || len(links) != 1 {
+		t.Fatalf("Expected one link, got %v, %v", links, err)
+	}
+	links[0].LinkLabel = "changed"
+
+	nodeFiles, err := fs.GetAllNodeFileLinks()
+	if err != nil {
+		t.Fatalf("Failed to read node files: %v", err)
+	}
+	delete(nodeFiles, "child")
+
+	if node, err := fs.ReadNode("child"); err != nil || node.Title() != "Child" {
+		t.Errorf("Expected the stored title, got %v, %v", node, err)
+	}
+	if links, err := fs.ListSpecSpecLinks(); err != nil || links[0].LinkLabel != "child" {
+		t.Errorf("Expected the stored link label, got %v, %v", links, err)
+	}
+	if nodeFiles, err := fs.GetAllNodeFileLinks(); err != nil || nodeFiles["child"] == "" {
+		t.Errorf("Expected the stored node file mapping, got %v, %v", nodeFiles, err)
+	}
+}
+
+func TestIndexForgetsMissingNodes(t *testing.T) {
+	fs := setupIndexedStore(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := fs.ReadNode("missing"); err == nil {
+			t.Fatal("Expected reading a missing node to fail")
+		}
+	}
+	if err := os.Remove(fs.GetNodeFilePath("child")); err != nil {
+		t.Fatalf("Failed to remove node file: %v", err)
+	}
+	if _, err := fs.ReadNode("child"); err == nil {
+		t.Fatal("Expected reading a removed node to fail")
+	}
+
+	fs.index.nodesMu.Lock()
+	defer fs.index.nodesMu.Unlock()
+	for path := range fs.index.nodes {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected no cache entry for missing file %s", path)
+		}
+	}
+}
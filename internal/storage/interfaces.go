@@ -12,6 +12,9 @@ type Storage interface {
 	WriteNodeWithChildren(node models.Node, childGrouping models.ChildGroup) error
 	DeleteNode(id string) error
 	ListNodes() ([]models.Node, error)
+	// Search returns the nodes whose titles and contents contain every word of the query,
+	// ranked by relevance. The index behind it is kept up to date as nodes are written.
+	Search(query string) ([]SearchHit, error)
 
 	// SpecCommitLink operations
 	CreateSpecCommitLink(link *models.SpecCommitLink) error
@@ -20,11 +23,13 @@ type Storage interface {
 	DeleteSpecCommitLinkByFields(specID, commitID, repoPath string) error
 	GetLinksByCommit(commitID, repoPath string) ([]*models.SpecCommitLink, error)
 	GetLinksBySpec(specID string) ([]*models.SpecCommitLink, error)
+	ListSpecCommitLinks() ([]*models.SpecCommitLink, error)
 	DeleteLink(specID string) error
 
 	// SpecSpecLink operations
 	CreateSpecSpecLink(link *models.SpecSpecLink) error
 	GetSpecSpecLinks(specID string, direction models.Direction) ([]*models.SpecSpecLink, error)
+	ListSpecSpecLinks() ([]*models.SpecSpecLink, error)
 	DeleteSpecSpecLink(fromSpecID, toSpecID string) error
 	DeleteSpecLinkBySpecs(fromSpecID, toSpecID string) error
 
@@ -36,4 +41,25 @@ type Storage interface {
 	// ProjectMetadata operations
 	GetProjectMetadata() (*models.ProjectMetadata, error)
 	SetRootSpecID(specID *string) error
+	SetSchemaVersion(version int) error
+
+	// Transaction runs fn so that the changes it makes through tx either all happen or are
+	// all undone. Other users of the store wait until fn returns.
+	Transaction(fn func(tx Storage) error) error
+}
+
+// NodeFileStorage is implemented by backends that keep each node in its own markdown file,
+// which can be opened, moved and tracked in Git. Features that only make sense for files
+// check for it rather than for a specific backend.
+type NodeFileStorage interface {
+	Storage
+
+	// GetNodeFilePath returns the path of the file a node is stored in
+	GetNodeFilePath(nodeID string) string
+	// MoveNodeFile moves a node's file to a path relative to the project root
+	MoveNodeFile(node models.Node, newPath string) error
+	// SetNodeFilePath records the file a node is stored in, relative to the project root
+	SetNodeFilePath(nodeID, path string) error
+	// ProjectRoot returns the directory that node file paths are relative to
+	ProjectRoot() string
 }
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// journalEntry records the state of one file before a transaction first touched it
+type journalEntry struct {
+	Path    string `json:"path"`
+	Backup  string `json:"backup,omitempty"`
+	Existed bool   `json:"existed"`
+}
+
+// journal is the undo log of a transaction in progress. It lives in the journal
+// directory of the store until the transaction commits, so that an operation that was
+// interrupted can be rolled back the next time the store is opened.
+type journal struct {
+	Entries []journalEntry `json:"entries"`
+	tracked map[string]bool
+}
+
+func (fs *FileStorage) journalDir() string {
+	return filepath.Join(fs.baseDir, "journal")
+}
+
+func (fs *FileStorage) journalPath() string {
+	return filepath.Join(fs.journalDir(), "journal.json")
+}
+
+// Transaction runs fn so that the files it writes, renames or removes through tx either
+// all change or all keep their previous contents. If fn returns an error, every file it
+// touched is restored. If the process dies before fn returns, the files are restored the
+// next time the store is locked. The store stays locked until fn returns, and
+// transactions started on tx join this one.
+func (fs *FileStorage) Transaction(fn func(tx Storage) error) error {
+	return fs.transaction(func(tx *FileStorage) error {
+		return fn(tx)
+	})
+}
+
+func (fs *FileStorage) transaction(fn func(fs *FileStorage) error) error {
+	if fs.journal != nil {
+		return fn(fs)
+	}
+
+	return fs.withWriteLock(func(fs *FileStorage) error {
+		if err := os.MkdirAll(fs.journalDir(), 0755); err != nil {
+			return fmt.Errorf("failed to create journal directory: %w", err)
+		}
+		tx := fs.view(holdExclusive, &journal{Entries: make([]journalEntry, 0), tracked: make(map[string]bool)})
+
+		if err := fn(tx); err != nil {
+			if rollbackErr := fs.rollback(tx.journal); rollbackErr != nil {
+				return fmt.Errorf("%w (rolling back also failed: %v)", err, rollbackErr)
+			}
+			return err
+		}
+
+		// Once the journal is gone the transaction can no longer be rolled back
+		if err := os.RemoveAll(fs.journalDir()); err != nil {
+			return fmt.Errorf("failed to remove journal: %w", err)
+		}
+		return nil
+	})
+}
+
+// track saves a copy of a file before the view's transaction changes it for the first
+// time. Outside a transaction it does nothing.
+func (fs *FileStorage) track(path string) error {
+	if fs.journal == nil {
+		return nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if fs.journal.tracked[absPath] {
+		return nil
+	}
+
+	entry := journalEntry{Path: fs.journalRelPath(absPath)}
+	data, err := os.ReadFile(absPath)
+	switch {
+	case err == nil:
+		entry.Existed = true
+		entry.Backup = strconv.Itoa(len(fs.journal.Entries)) + ".bak"
+		// The backup has to be on disk before the journal refers to it
+		if err := writeFileAtomic(filepath.Join(fs.journalDir(), entry.Backup), data); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	fs.journal.Entries = append(fs.journal.Entries, entry)
+	data, err = json.MarshalIndent(fs.journal, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(fs.journalPath(), data)
+	}
+	if err != nil {
+		fs.journal.Entries = fs.journal.Entries[:len(fs.journal.Entries)-1]
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	fs.journal.tracked[absPath] = true
+	return nil
+}
+
+// journalRelPath stores paths relative to the project root, so that a journal left
+// behind still applies if the project directory is moved
+func (fs *FileStorage) journalRelPath(absPath string) string {
+	projectRoot, err := filepath.Abs(filepath.Dir(fs.baseDir))
+	if err != nil {
+		return absPath
+	}
+	relPath, err := filepath.Rel(projectRoot, absPath)
+	if err != nil {
+		return absPath
+	}
+	return relPath
+}
+
+// rollback restores every file recorded in the journal, newest first, then removes it
+func (fs *FileStorage) rollback(j *journal) error {
+	projectRoot := filepath.Dir(fs.baseDir)
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		entry := j.Entries[i]
+		path := entry.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectRoot, path)
+		}
+
+		if !entry.Existed {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(fs.journalDir(), entry.Backup))
+		if err != nil {
+			return fmt.Errorf("failed to read backup of %s: %w", entry.Path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", entry.Path, err)
+		}
+		if err := writeFileAtomic(path, data); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+		}
+	}
+
+	return os.RemoveAll(fs.journalDir())
+}
+
+// recoverJournal rolls back a transaction that was interrupted before it committed. The
+// caller must hold the store's lock exclusively.
+func (fs *FileStorage) recoverJournal() error {
+	if _, err := os.Stat(fs.journalPath()); errors.Is(err, os.ErrNotExist) {
+		// A journal directory without a journal never recorded any change
+		return os.RemoveAll(fs.journalDir())
+	}
+
+	var j journal
+	if err := fs.readJSONFile(fs.journalPath(), &j); err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+	return fs.rollback(&j)
+}
+
+// writeFile replaces the contents of a file, recording its old contents if a
+// transaction is running
+func (fs *FileStorage) writeFile(path string, data []byte) error {
+	if err := fs.track(path); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// removeFile removes a file, recording its contents if a transaction is running
+func (fs *FileStorage) removeFile(path string) error {
+	if err := fs.track(path); err != nil {
+		return err
+	}
+	fs.index.forgetNode(path)
+	return os.Remove(path)
+}
+
+// renameFile moves a file, recording both ends of the move if a transaction is running
+func (fs *FileStorage) renameFile(oldPath, newPath string) error {
+	if err := fs.track(oldPath); err != nil {
+		return err
+	}
+	if err := fs.track(newPath); err != nil {
+		return err
+	}
+	fs.index.forgetNode(oldPath)
+	return os.Rename(oldPath, newPath)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place,
+// so that readers and crashes only ever see the old or the new contents
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpPath) // Explicitly ignore error, the file is gone after a successful rename
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir flushes a directory entry so that a rename survives a power loss. Not every
+// platform supports syncing directories, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
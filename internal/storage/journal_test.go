@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+func setupJournalStore(t *testing.T) (*FileStorage, string) {
+	t.Helper()
+
+	baseDir := filepath.Join(t.TempDir(), ".zamm")
+	fs, err := New(baseDir)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	if err := fs.WriteNode(models.NewSpecWithID("existing", "Existing", "Original content")); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+	return fs, baseDir
+}
+
+// assertOriginalState checks that only the node written by setupJournalStore exists, with
+// its original contents, and that no journal or temporary files are left behind
+func assertOriginalState(t *testing.T, fs *FileStorage) {
+	t.Helper()
+
+	node, err := fs.ReadNode("existing")
+	if err != nil || node.Content() != "Original content" {
+		t.Errorf("Expected existing node to keep its original content, got %v, %v", node, err)
+	}
+	if _, err := fs.ReadNode("added"); err == nil {
+		t.Error("Expected added node to be rolled back")
+	}
+	nodeFiles, err := fs.GetAllNodeFileLinks()
+	if err != nil {
+		t.Fatalf("Failed to read node files: %v", err)
+	}
+	if _, ok := nodeFiles["added"]; ok || len(nodeFiles) != 1 {
+		t.Errorf("Expected node-files.csv to be rolled back, got %v", nodeFiles)
+	}
+	links, err := fs.ListSpecSpecLinks()
+	if err != nil {
+		t.Fatalf("Failed to list spec links: %v", err)
+	}
+	if len(links) != 0 {
+		t.Errorf("Expected spec links to be rolled back, got %v", links)
+	}
+	if _, err := os.Stat(fs.journalDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected journal to be removed, got %v", err)
+	}
+	assertNoTempFiles(t, fs.baseDir)
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("Expected no temporary files, found %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to scan %s: %v", dir, err)
+	}
+}
+
+// changeEverything modifies a node, adds a node and links it, touching several files
+func changeEverything(t *testing.T, fs Storage) {
+	t.Helper()
+
+	if err := fs.WriteNode(models.NewSpecWithID("existing", "Existing", "Changed content")); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+	if err := fs.WriteNode(models.NewSpecWithID("added", "Added", "Added content")); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+	if err := fs.CreateSpecSpecLink(&models.SpecSpecLink{FromSpecID: "added", ToSpecID: "existing", LinkLabel: "child"}); err != nil {
+		t.Fatalf("Failed to create spec link: %v", err)
+	}
+}
+
+func TestTransactionCommits(t *testing.T) {
+	fs, _ := setupJournalStore(t)
+
+	err := fs.Transaction(func(tx Storage) error {
+		changeEverything(t, tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if node, err := fs.ReadNode("existing"); err != nil || node.Content() != "Changed content" {
+		t.Errorf("Expected existing node to be changed, got %v, %v", node, err)
+	}
+	if _, err := fs.ReadNode("added"); err != nil {
+		t.Errorf("Expected added node to exist: %v", err)
+	}
+	if _, err := os.Stat(fs.journalDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected journal to be removed after commit, got %v", err)
+	}
+	assertNoTempFiles(t, fs.baseDir)
+}
+
+func TestTransactionRollsBackOnError(t *testing.T) {
+	fs, _ := setupJournalStore(t)
+
+	failure := errors.New("step failed")
+	err := fs.Transaction(func(tx Storage) error {
+		changeEverything(t, tx)
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the transaction's error, got %v", err)
+	}
+
+	assertOriginalState(t, fs)
+}
+
+func TestInterruptedTransactionRollsBackOnOpen(t *testing.T) {
+	fs, baseDir := setupJournalStore(t)
+
+	// A panic stands in for the process dying, leaving the journal on disk
+	func() {
+		defer func() {
+			_ = recover()
+		}()
+		_ = fs.Transaction(func(tx Storage) error {
+			changeEverything(t, tx)
+			panic("interrupted")
+		})
+	}()
+	if _, err := os.Stat(filepath.Join(baseDir, "journal", "journal.json")); err != nil {
+		t.Fatalf("Expected journal to survive the interruption: %v", err)
+	}
+
+	reopened, err := New(baseDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen file storage: %v", err)
+	}
+	assertOriginalState(t, reopened)
+}
+
+func TestMoveNodeFileRollsBack(t *testing.T) {
+	fs, baseDir := setupJournalStore(t)
+	node, err := fs.ReadNode("existing")
+	if err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	oldPath := fs.GetNodeFilePath(node.ID())
+
+	failure := errors.New("step failed")
+	err = fs.Transaction(func(tx Storage) error {
+		if err := tx.(*FileStorage).MoveNodeFile(node, filepath.Join("docs", "existing.md")); err != nil {
+			t.Fatalf("Failed to move node file: %v", err)
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the transaction's error, got %v", err)
+	}
+
+	if fs.GetNodeFilePath(node.ID()) != oldPath {
+		t.Errorf("Expected node-files.csv to point at %s again, got %s", oldPath, fs.GetNodeFilePath(node.ID()))
+	}
+	if _, err := os.Stat(oldPath); err != nil {
+		t.Errorf("Expected file to be moved back: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(baseDir), "docs", "existing.md")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected moved file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// DefaultLockTimeout is how long FileStorage waits for another process to release the store
+const DefaultLockTimeout = 10 * time.Second
+
+// lockRetryInterval is how often a locked store is polled while waiting for it
+const lockRetryInterval = 10 * time.Millisecond
+
+// The store is shared between processes, such as an MCP server and an interactive session,
+// through an advisory lock on the lock file in the .zamm directory. Platforms without file
+// locking go without it, see lock_other.go. Within a process, operations are serialized by
+// a read-write mutex. Writers hold both exclusively for the whole read-modify-write of the
+// files they change, and readers that look at several files share them, so nobody sees
+// another writer's half-applied changes.
+//
+// An operation runs the rest of its work on a view of the store that records what it
+// holds, and operations nested in it reuse that instead of locking again. Other goroutines
+// have no such view, so they wait for the operation to finish.
+
+// lockHold is how much of the store a view of it holds
+type lockHold int
+
+const (
+	holdNone lockHold = iota
+	holdShared
+	holdExclusive
+)
+
+func (fs *FileStorage) lockPath() string {
+	return filepath.Join(fs.baseDir, "lock")
+}
+
+// SetLockTimeout sets how long operations wait for another process to release the store
+// before failing with a conflict error
+func (fs *FileStorage) SetLockTimeout(timeout time.Duration) {
+	fs.lockMu.Lock()
+	defer fs.lockMu.Unlock()
+	fs.lockTimeout = timeout
+}
+
+// view returns a view of the store for a call chain that holds it as given
+func (fs *FileStorage) view(hold lockHold, j *journal) *FileStorage {
+	return &FileStorage{fileStore: fs.fileStore, hold: hold, journal: j}
+}
+
+// withWriteLock runs fn on a view of the store that holds it exclusively, unless the store
+// was written by a newer zamm
+func (fs *FileStorage) withWriteLock(fn func(fs *FileStorage) error) error {
+	switch fs.hold {
+	case holdExclusive:
+		return fn(fs)
+	case holdShared:
+		return errors.New("cannot write to the store while reading it")
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.acquireLock(true, fs.getLockTimeout()); err != nil {
+		return err
+	}
+	defer fs.releaseLock()
+
+	held := fs.view(holdExclusive, nil)
+	if err := held.checkWritable(); err != nil {
+		return err
+	}
+	return fn(held)
+}
+
+// withReadLock runs fn on a view of the store that no other writer can change meanwhile
+func (fs *FileStorage) withReadLock(fn func(fs *FileStorage) error) error {
+	if fs.hold != holdNone {
+		return fn(fs)
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if err := fs.acquireLock(false, fs.getLockTimeout()); err != nil {
+		return err
+	}
+	defer fs.releaseLock()
+
+	return fn(fs.view(holdShared, nil))
+}
+
+func (fs *FileStorage) getLockTimeout() time.Duration {
+	fs.lockMu.Lock()
+	defer fs.lockMu.Unlock()
+	return fs.lockTimeout
+}
+
+// acquireLock takes the process's hold on the lock file, waiting up to timeout for other
+// processes to release it. Readers in the process share one hold. Taking it first rolls
+// back any transaction another process left unfinished.
+func (fs *FileStorage) acquireLock(exclusive bool, timeout time.Duration) error {
+	// Only one caller waits for the lock file at a time, without blocking the others
+	// that only need lockMu
+	fs.lockWaitMu.Lock()
+	defer fs.lockWaitMu.Unlock()
+
+	fs.lockMu.Lock()
+	if !exclusive && fs.lockHolders > 0 {
+		fs.lockHolders++
+		fs.lockMu.Unlock()
+		return nil
+	}
+	if fs.lockFile == nil {
+		file, err := os.OpenFile(fs.lockPath(), os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			fs.lockMu.Unlock()
+			return fmt.Errorf("failed to open lock file: %w", err)
+		}
+		fs.lockFile = file
+	}
+	fs.lockMu.Unlock()
+
+	if err := fs.waitForLock(exclusive, timeout); err != nil {
+		return err
+	}
+
+	// Only a process that died mid-transaction leaves a journal behind without holding the
+	// lock, so whoever gets the lock next rolls it back
+	if fs.hasJournal() {
+		if !exclusive {
+			if err := fs.waitForLock(true, timeout); err != nil {
+				_ = unlockFile(fs.lockFile)
+				return err
+			}
+		}
+		if err := fs.recoverJournal(); err != nil {
+			_ = unlockFile(fs.lockFile)
+			return fmt.Errorf("failed to roll back interrupted operation: %w", err)
+		}
+		if !exclusive {
+			if err := fs.waitForLock(false, timeout); err != nil {
+				_ = unlockFile(fs.lockFile)
+				return err
+			}
+		}
+	}
+
+	fs.lockMu.Lock()
+	fs.lockHolders++
+	fs.lockMu.Unlock()
+	return nil
+}
+
+// waitForLock polls the lock file until it can be locked or the timeout passes
+func (fs *FileStorage) waitForLock(exclusive bool, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLockFile(fs.lockFile, exclusive)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", fs.lockPath(), err)
+		}
+		if locked {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			zammErr := models.NewZammError(models.ErrTypeConflict, "the .zamm store is being modified by another process, try again once it finishes")
+			zammErr.Details = fmt.Sprintf("timed out after %s waiting for %s", timeout, fs.lockPath())
+			return zammErr
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// releaseLock gives up one hold on the lock file, unlocking it once nothing in the process
+// holds it anymore
+func (fs *FileStorage) releaseLock() {
+	fs.lockMu.Lock()
+	defer fs.lockMu.Unlock()
+
+	fs.lockHolders--
+	if fs.lockHolders == 0 {
+		_ = unlockFile(fs.lockFile) // Explicitly ignore error, closing the process releases it too
+	}
+}
+
+func (fs *FileStorage) hasJournal() bool {
+	_, err := os.Stat(fs.journalDir())
+	return !errors.Is(err, os.ErrNotExist)
+}
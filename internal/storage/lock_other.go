@@ -0,0 +1,25 @@
+//go:build !unix && !windows
+
+package storage
+
+import (
+	"log"
+	"os"
+	"sync"
+)
+
+var warnNoLockOnce sync.Once
+
+// tryLockFile always succeeds on platforms without flock or LockFileEx. Operations in one
+// process are still serialized, but processes sharing a store are not protected from each
+// other, so the first lock warns about it.
+func tryLockFile(file *os.File, exclusive bool) (bool, error) {
+	warnNoLockOnce.Do(func() {
+		log.Printf("warning: file locking is not supported on this platform, other processes using %s may overwrite its changes", file.Name())
+	})
+	return true, nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,185 @@
+//go:build unix
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// Each FileStorage opens its own lock file handle, so two of them on the same directory
+// contend for the lock like two processes would
+
+func TestLockRejectsSecondWriter(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".zamm")
+	first, err := New(baseDir)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	second, err := New(baseDir)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	second.SetLockTimeout(50 * time.Millisecond)
+
+	if err := first.WriteNode(models.NewSpecWithID("existing", "Existing", "Content")); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+
+	release := make(chan struct{})
+	holding := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- first.Transaction(func(Storage) error {
+			close(holding)
+			<-release
+			return nil
+		})
+	}()
+	<-holding
+
+	link := &models.SpecSpecLink{FromSpecID: "existing", ToSpecID: "other", LinkLabel: "child"}
+	err = second.CreateSpecSpecLink(link)
+	if zammErr, ok := err.(*models.ZammError); !ok || zammErr.Type != models.ErrTypeConflict {
+		t.Errorf("Expected a conflict error while another writer holds the store, got %v", err)
+	}
+	if _, err := second.ReadNode("existing"); err == nil {
+		t.Error("Expected reads to wait for the other writer")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if err := second.CreateSpecSpecLink(link); err != nil {
+		t.Errorf("Expected the write to succeed once the store is released: %v", err)
+	}
+	if _, err := second.ReadNode("existing"); err != nil {
+		t.Errorf("Expected the read to succeed once the store is released: %v", err)
+	}
+}
+
+func TestLockSerializesConcurrentWriters(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".zamm")
+	stores := make([]*FileStorage, 2)
+	for i := range stores {
+		store, err := New(baseDir)
+		if err != nil {
+			t.Fatalf("failed to create file storage: %v", err)
+		}
+		stores[i] = store
+	}
+
+	const linksPerStore = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, len(stores)*linksPerStore)
+	for i, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < linksPerStore; j++ {
+				errs <- store.CreateSpecSpecLink(&models.SpecSpecLink{
+					FromSpecID: fmt.Sprintf("child-%d-%d", i, j),
+					ToSpecID:   "parent",
+					LinkLabel:  "child",
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Failed to create link: %v", err)
+		}
+	}
+
+	links, err := stores[0].ListSpecSpecLinks()
+	if err != nil {
+		t.Fatalf("Failed to list spec links: %v", err)
+	}
+	if len(links) != len(stores)*linksPerStore {
+		t.Errorf("Expected %d links, got %d", len(stores)*linksPerStore, len(links))
+	}
+}
+
+func TestLockSerializesWritersWithinProcess(t *testing.T) {
+	fs, err := New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	const writers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- fs.CreateSpecCommitLink(&models.SpecCommitLink{
+				SpecID:   fmt.Sprintf("spec-%d", i),
+				CommitID: "abc123",
+				RepoPath: ".",
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Failed to create link: %v", err)
+		}
+	}
+
+	links, err := fs.ListSpecCommitLinks()
+	if err != nil {
+		t.Fatalf("Failed to list commit links: %v", err)
+	}
+	if len(links) != writers {
+		t.Errorf("Expected %d links, got %d", writers, len(links))
+	}
+}
+
+func TestTransactionIsNotJoinedByOtherGoroutines(t *testing.T) {
+	fs, err := New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	written := make(chan error)
+	failure := errors.New("step failed")
+	err = fs.Transaction(func(Storage) error {
+		go func() {
+			written <- fs.CreateSpecSpecLink(&models.SpecSpecLink{FromSpecID: "child", ToSpecID: "parent", LinkLabel: "child"})
+		}()
+		select {
+		case err := <-written:
+			t.Errorf("Expected the write to wait for the transaction, it returned %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the transaction's error, got %v", err)
+	}
+
+	if err := <-written; err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	links, err := fs.ListSpecSpecLinks()
+	if err != nil {
+		t.Fatalf("Failed to list spec links: %v", err)
+	}
+	if len(links) != 1 {
+		t.Errorf("Expected the other goroutine's link to survive the rollback, got %d links", len(links))
+	}
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package storage
+
+import (
+	"errors"
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// tryLockFile takes a shared or exclusive lock on the whole file without blocking. Unlike
+// flock, LockFileEx doesn't convert a lock the handle already holds, so that is released
+// first. It reports false if another process holds a conflicting lock.
+func tryLockFile(file *os.File, exclusive bool) (bool, error) {
+	_ = unlockFile(file) // Explicitly ignore error, the handle usually holds no lock yet
+
+	flags := uint32(windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if exclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+
+	err := windows.LockFileEx(windows.Handle(file.Fd()), flags, 0, math.MaxUint32, math.MaxUint32, new(windows.Overlapped))
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlockFile(file *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, math.MaxUint32, math.MaxUint32, new(windows.Overlapped))
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// indexTable describes one of the CSV indexes for merging. Rows are identified by their
+// leading key columns, so that two branches changing the same row can be told apart from
+// two branches each adding their own.
+type indexTable struct {
+	header     []string
+	keyColumns int
+	// sorted tables are written in key order, as writeNodeFileLinks does, rather than in
+	// the order rows were added
+	sorted   bool
+	describe func(key []string) string
+}
+
+var indexTables = []indexTable{
+	{
+		header:     []string{"node_id", "file_path"},
+		keyColumns: 1,
+		sorted:     true,
+		describe: func(key []string) string {
+			return fmt.Sprintf("node %s", key[0])
+		},
+	},
+	{
+		header:     []string{"from_spec_id", "to_spec_id", "link_label"},
+		keyColumns: 2,
+		describe: func(key []string) string {
+			return fmt.Sprintf("link from %s to %s", key[0], key[1])
+		},
+	},
+	{
+		header:     []string{"spec_id", "commit_id", "repo_path", "link_label"},
+		keyColumns: 3,
+		describe: func(key []string) string {
+			return fmt.Sprintf("link from spec %s to commit %s in %s", key[0], key[1], key[2])
+		},
+	},
+}
+
+// IndexMergeFiles are the CSV indexes that MergeIndex can merge
+var IndexMergeFiles = []string{"spec-links.csv", "commit-links.csv", "node-files.csv"}
+
+// IndexConflict is a row that both sides of a merge changed in different ways
+type IndexConflict struct {
+	Message string   `json:"message"`
+	Ours    []string `json:"ours,omitempty"`
+	Theirs  []string `json:"theirs,omitempty"`
+}
+
+// IndexMerge is the result of merging a CSV index. Conflicting rows are written to Data
+// between conflict markers, for git to leave in the working tree.
+type IndexMerge struct {
+	Data      []byte
+	Conflicts []IndexConflict
+}
+
+// MergeIndex merges two versions of a CSV index row by row against their common ancestor.
+// A row added, changed or removed on one side only is taken from that side, so branches
+// that add unrelated links merge cleanly. Only rows both sides changed differently, such
+// as a node mapped to two different paths, conflict.
+func MergeIndex(base, ours, theirs []byte) (*IndexMerge, error) {
+	versions := make([][][]string, 0, 3)
+	var table *indexTable
+	for _, data := range [][]byte{base, ours, theirs} {
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return nil, models.NewZammErrorWithCause(models.ErrTypeValidation, "failed to parse CSV index", err)
+		}
+		if len(records) == 0 {
+			versions = append(versions, records)
+			continue
+		}
+
+		recordTable := findIndexTable(records[0])
+		if recordTable == nil {
+			return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("unrecognized CSV index header: %s", strings.Join(records[0], ",")))
+		}
+		if table != nil && table != recordTable {
+			return nil, models.NewZammError(models.ErrTypeValidation, "the versions being merged are different CSV indexes")
+		}
+		table = recordTable
+		versions = append(versions, records[1:])
+	}
+	if table == nil {
+		return &IndexMerge{Data: base}, nil
+	}
+
+	baseRows, _ := table.rowsByKey(versions[0])
+	ourRows, ourOrder := table.rowsByKey(versions[1])
+	theirRows, theirOrder := table.rowsByKey(versions[2])
+
+	order := ourOrder
+	for _, key := range theirOrder {
+		if _, ok := ourRows[key]; !ok {
+			order = append(order, key)
+		}
+	}
+	if table.sorted {
+		slices.Sort(order)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write(table.header) // Errors surface from Flush
+
+	result := &IndexMerge{Conflicts: make([]IndexConflict, 0)}
+	for _, key := range order {
+		baseRow, ourRow, theirRow := baseRows[key], ourRows[key], theirRows[key]
+
+		var merged []string
+		switch {
+		case slices.Equal(ourRow, theirRow):
+			merged = ourRow
+		case slices.Equal(ourRow, baseRow):
+			merged = theirRow
+		case slices.Equal(theirRow, baseRow):
+			merged = ourRow
+		default:
+			result.Conflicts = append(result.Conflicts, table.conflict(ourRow, theirRow))
+			writer.Flush()
+			buf.WriteString("<<<<<<< ours\n")
+			writeRow(writer, ourRow)
+			writer.Flush()
+			buf.WriteString("=======\n")
+			writeRow(writer, theirRow)
+			writer.Flush()
+			buf.WriteString(">>>>>>> theirs\n")
+			continue
+		}
+		writeRow(writer, merged)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	result.Data = buf.Bytes()
+	return result, nil
+}
+
+func findIndexTable(header []string) *indexTable {
+	for i := range indexTables {
+		if slices.Equal(indexTables[i].header, header) {
+			return &indexTables[i]
+		}
+	}
+	return nil
+}
+
+// rowsByKey indexes rows by their key columns, keeping the order they first appear in.
+// Rows that repeat a key are the same link written twice, so only the first is kept.
+func (t *indexTable) rowsByKey(records [][]string) (map[string][]string, []string) {
+	rows := make(map[string][]string, len(records))
+	order := make([]string, 0, len(records))
+	for _, record := range records {
+		if len(record) != len(t.header) {
+			continue // Skip malformed records, as the readers do
+		}
+		key := strings.Join(record[:t.keyColumns], "\x00")
+		if _, ok := rows[key]; ok {
+			continue
+		}
+		rows[key] = record
+		order = append(order, key)
+	}
+	return rows, order
+}
+
+func (t *indexTable) conflict(ours, theirs []string) IndexConflict {
+	key := ours
+	if key == nil {
+		key = theirs
+	}
+
+	value := func(row []string) string {
+		if row == nil {
+			return "removed"
+		}
+		return strings.Join(row[t.keyColumns:], ",")
+	}
+	return IndexConflict{
+		Message: fmt.Sprintf("%s: %s in ours, %s in theirs", t.describe(key[:t.keyColumns]), value(ours), value(theirs)),
+		Ours:    ours,
+		Theirs:  theirs,
+	}
+}
+
+func writeRow(writer *csv.Writer, row []string) {
+	if row != nil {
+		_ = writer.Write(row) // Errors surface from Flush
+	}
+}
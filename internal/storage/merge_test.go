@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeIndexUnionsIndependentRows(t *testing.T) {
+	base := "from_spec_id,to_spec_id,link_label\na,root,child\nb,root,child\n"
+	ours := "from_spec_id,to_spec_id,link_label\na,root,child\nb,root,child\nc,root,child\n"
+	theirs := "from_spec_id,to_spec_id,link_label\nb,root,child\nd,root,child\n"
+
+	result, err := MergeIndex([]byte(base), []byte(ours), []byte(theirs))
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %+v", result.Conflicts)
+	}
+
+	expected := "from_spec_id,to_spec_id,link_label\nb,root,child\nc,root,child\nd,root,child\n"
+	if string(result.Data) != expected {
+		t.Errorf("Expected a removed on their side and c and d both added, got:\n%s", result.Data)
+	}
+}
+
+func TestMergeIndexKeepsNodeFilesSorted(t *testing.T) {
+	base := "node_id,file_path\nb,docs/b.md\n"
+	ours := "node_id,file_path\nb,docs/b.md\nc,docs/c.md\n"
+	theirs := "node_id,file_path\na,docs/a.md\nb,docs/moved/b.md\n"
+
+	result, err := MergeIndex([]byte(base), []byte(ours), []byte(theirs))
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %+v", result.Conflicts)
+	}
+
+	expected := "node_id,file_path\na,docs/a.md\nb,docs/moved/b.md\nc,docs/c.md\n"
+	if string(result.Data) != expected {
+		t.Errorf("Expected rows in node ID order with b's move, got:\n%s", result.Data)
+	}
+}
+
+func TestMergeIndexReportsTrueConflicts(t *testing.T) {
+	base := "node_id,file_path\na,.zamm/nodes/a.md\nb,docs/b.md\n"
+	ours := "node_id,file_path\na,docs/ours.md\nb,docs/b.md\n"
+	theirs := "node_id,file_path\na,docs/theirs.md\n"
+
+	result, err := MergeIndex([]byte(base), []byte(ours), []byte(theirs))
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("Expected one conflict, got %+v", result.Conflicts)
+	}
+	if !strings.Contains(result.Conflicts[0].Message, "node a: docs/ours.md in ours, docs/theirs.md in theirs") {
+		t.Errorf("Expected the conflict to name both paths, got %q", result.Conflicts[0].Message)
+	}
+
+	expected := "node_id,file_path\n<<<<<<< ours\na,docs/ours.md\n=======\na,docs/theirs.md\n>>>>>>> theirs\n"
+	if string(result.Data) != expected {
+		t.Errorf("Expected conflict markers around a and b removed, got:\n%s", result.Data)
+	}
+}
+
+func TestMergeIndexRejectsUnknownFiles(t *testing.T) {
+	if _, err := MergeIndex([]byte("id,name\n"), []byte("id,name\n"), []byte("id,name\n")); err == nil {
+		t.Error("Expected an unrecognized header to be rejected")
+	}
+	if _, err := MergeIndex([]byte("node_id,file_path\n"), []byte("node_id,file_path\n"), []byte("spec_id,commit_id,repo_path,link_label\n")); err == nil {
+		t.Error("Expected different indexes to be rejected")
+	}
+}
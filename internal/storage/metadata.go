@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// extractMetadata moves the frontmatter fields that zamm doesn't read into nodes out of
+// frontmatter and into its metadata field, in the order they appear in the YAML
+func extractMetadata(yamlContent string, frontmatter map[string]interface{}) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlContent), &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+
+	nodeType, _ := frontmatter["type"].(string)
+	metadata := make(models.Metadata, 0)
+	fields := doc.Content[0].Content
+	for i := 0; i+1 < len(fields); i += 2 {
+		key := fields[i].Value
+		if models.IsNodeField(nodeType, key) {
+			continue
+		}
+
+		value, err := metadataValue(fields[i+1])
+		if err != nil {
+			return fmt.Errorf("failed to read field %s: %w", key, err)
+		}
+		delete(frontmatter, key)
+		metadata = append(metadata, models.MetadataField{Key: key, Value: value})
+	}
+
+	delete(frontmatter, "metadata")
+	if len(metadata) > 0 {
+		frontmatter["metadata"] = metadata
+	}
+	return nil
+}
+
+// metadataValue converts a YAML value to metadata, keeping the order of nested mappings
+func metadataValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return metadataValue(node.Alias)
+	case yaml.MappingNode:
+		metadata := make(models.Metadata, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := metadataValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			metadata = append(metadata, models.MetadataField{Key: node.Content[i].Value, Value: value})
+		}
+		return metadata, nil
+	case yaml.SequenceNode:
+		values := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := metadataValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+
+	// Dates stay as written rather than becoming timestamps with a time zone
+	if node.ShortTag() == "!!timestamp" {
+		return node.Value, nil
+	}
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// marshalFrontmatter writes the fields zamm uses, sorted by name, followed by the
+// metadata fields in their own order
+func marshalFrontmatter(frontmatter map[string]interface{}, metadata models.Metadata) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(frontmatter); err != nil {
+		return nil, err
+	}
+	for _, field := range metadata {
+		var key yaml.Node
+		if err := key.Encode(field.Key); err != nil {
+			return nil, err
+		}
+		value, err := metadataNode(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write field %s: %w", field.Key, err)
+		}
+		doc.Content = append(doc.Content, &key, value)
+	}
+	return yaml.Marshal(&doc)
+}
+
+// metadataNode converts metadata back to YAML
+func metadataNode(value any) (*yaml.Node, error) {
+	switch value := value.(type) {
+	case models.Metadata:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, field := range value {
+			var key yaml.Node
+			if err := key.Encode(field.Key); err != nil {
+				return nil, err
+			}
+			fieldValue, err := metadataNode(field.Value)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &key, fieldValue)
+		}
+		return node, nil
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range value {
+			itemNode, err := metadataNode(item)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, itemNode)
+		}
+		return node, nil
+	case string:
+		// Dates were read as strings, so write them back unquoted
+		plain := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		if plain.ShortTag() == "!!timestamp" {
+			return plain, nil
+		}
+	}
+
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
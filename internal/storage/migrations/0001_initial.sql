@@ -0,0 +1,30 @@
+-- Nodes are stored as the same JSON their markdown frontmatter is built from
+CREATE TABLE nodes (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    data TEXT NOT NULL
+);
+
+-- Links keep their insertion order through the rowid, like the rows of the CSV files
+CREATE TABLE spec_links (
+    from_spec_id TEXT NOT NULL,
+    to_spec_id TEXT NOT NULL,
+    link_label TEXT NOT NULL
+);
+CREATE INDEX spec_links_from ON spec_links (from_spec_id);
+CREATE INDEX spec_links_to ON spec_links (to_spec_id);
+
+CREATE TABLE commit_links (
+    spec_id TEXT NOT NULL,
+    commit_id TEXT NOT NULL,
+    repo_path TEXT NOT NULL,
+    link_label TEXT NOT NULL
+);
+CREATE INDEX commit_links_spec ON commit_links (spec_id);
+CREATE INDEX commit_links_commit ON commit_links (commit_id, repo_path);
+
+CREATE TABLE project_metadata (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    root_spec_id TEXT
+);
+INSERT INTO project_metadata (id, root_spec_id) VALUES (1, NULL);
@@ -0,0 +1,4 @@
+-- The version of the store format, which zamm migrate brings up to date. Databases have
+-- only ever held nodes in the format of schema version 2, the first to support SQLite.
+ALTER TABLE project_metadata ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 0;
+UPDATE project_metadata SET schema_version = 2;
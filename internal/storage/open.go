@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// Storage backends that can be selected with storage.backend in the config
+const (
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+)
+
+// Backends lists the storage backends
+var Backends = []string{BackendFile, BackendSQLite}
+
+// Open opens the store of the given backend in a storage directory. File storage keeps
+// its CSV indexes and node files there, SQLite storage keeps its database there.
+func Open(backend, dir string) (Storage, error) {
+	switch backend {
+	case BackendFile, "":
+		return New(dir)
+	case BackendSQLite:
+		return NewSQLite(filepath.Join(dir, SQLiteFileName))
+	default:
+		return nil, models.NewZammError(models.ErrTypeValidation, fmt.Sprintf("unknown storage backend %q, expected one of %s", backend, strings.Join(Backends, ", ")))
+	}
+}
+
+// Copy copies every node, link and the root ID from one store into another, empty, store.
+// Nodes copied into file storage land in their default location under .zamm/nodes.
+func Copy(dst, src Storage) error {
+	existing, err := dst.ListNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list destination nodes: %w", err)
+	}
+	if len(existing) > 0 {
+		zammErr := models.NewZammError(models.ErrTypeConflict, "destination store is not empty")
+		zammErr.Details = fmt.Sprintf("it already holds %d node(s)", len(existing))
+		return zammErr
+	}
+
+	nodes, err := src.ListNodes()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	specLinks, err := src.ListSpecSpecLinks()
+	if err != nil {
+		return fmt.Errorf("failed to list spec links: %w", err)
+	}
+	commitLinks, err := src.ListSpecCommitLinks()
+	if err != nil {
+		return fmt.Errorf("failed to list commit links: %w", err)
+	}
+	metadata, err := src.GetProjectMetadata()
+	if err != nil {
+		return fmt.Errorf("failed to read project metadata: %w", err)
+	}
+
+	return dst.Transaction(func(tx Storage) error {
+		for _, node := range nodes {
+			if err := tx.WriteNode(node); err != nil {
+				return fmt.Errorf("failed to copy node %s: %w", node.ID(), err)
+			}
+		}
+		for _, link := range specLinks {
+			if err := tx.CreateSpecSpecLink(link); err != nil {
+				return fmt.Errorf("failed to copy link from %s to %s: %w", link.FromSpecID, link.ToSpecID, err)
+			}
+		}
+		for _, link := range commitLinks {
+			if err := tx.CreateSpecCommitLink(link); err != nil {
+				return fmt.Errorf("failed to copy commit link for %s: %w", link.SpecID, err)
+			}
+		}
+		return tx.SetRootSpecID(metadata.RootSpecID)
+	})
+}
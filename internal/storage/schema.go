@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// SchemaVersion is the version of the store format this build reads and writes. New stores
+// start at it, and zamm migrate brings older stores up to it.
+const SchemaVersion = 2
+
+// CheckSchemaVersion refuses to modify a store written by a newer zamm, whose format may
+// have changed in ways this build would undo
+func CheckSchemaVersion(version int) error {
+	if version <= SchemaVersion {
+		return nil
+	}
+	zammErr := models.NewZammError(models.ErrTypeConflict, "the store was written by a newer version of zamm, upgrade zamm to modify it")
+	zammErr.Details = fmt.Sprintf("store schema version is %d, this zamm supports up to version %d", version, SchemaVersion)
+	return zammErr
+}
+
+// checkWritable reads the schema version straight from project_metadata.json, since
+// another process may have migrated the store since it was opened
+func (fs *FileStorage) checkWritable() error {
+	var metadata models.ProjectMetadata
+	if err := fs.readJSONFile(filepath.Join(fs.baseDir, "project_metadata.json"), &metadata); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read project metadata: %w", err)
+	}
+	return CheckSchemaVersion(metadata.SchemaVersion)
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+// BM25 parameters: how quickly repeated words stop adding to a score, and how much long
+// nodes are penalized for containing more words
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// titleWeight counts a word in a node's title as this many words in its content
+	titleWeight = 3
+)
+
+// Word is a word of some text, lowercased, with its byte offsets in the text
+type Word struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// SplitWords splits text into the words that full-text search indexes: runs of letters
+// and digits, lowercased
+func SplitWords(text string) []Word {
+	words := make([]Word, 0)
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = i
+		} else if !isWordRune && start >= 0 {
+			words = append(words, Word{Text: strings.ToLower(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, Word{Text: strings.ToLower(text[start:]), Start: start, End: len(text)})
+	}
+	return words
+}
+
+// SearchHit is a node that matched a full-text search
+type SearchHit struct {
+	Node  models.Node
+	Score float64
+	// Words are the words of the node that matched the query, for highlighting
+	Words []string
+}
+
+// searchDoc is a node as the search index last saw it
+type searchDoc struct {
+	node    models.Node
+	title   string
+	content string
+	length  int
+}
+
+// posting counts the occurrences of a word in one node
+type posting struct {
+	title   int
+	content int
+}
+
+// searchIndex is an inverted index of the words in node titles and contents, ranked
+// with BM25. Backends update it as nodes are written and deleted, and bring it in line
+// with ListNodes before every search, which catches hand edits, rolled back transactions
+// and writes by other processes.
+type searchIndex struct {
+	mu          sync.Mutex
+	docs        map[string]*searchDoc
+	postings    map[string]map[string]posting // word -> node ID -> occurrences
+	totalLength int
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		docs:     make(map[string]*searchDoc),
+		postings: make(map[string]map[string]posting),
+	}
+}
+
+// put indexes a node, replacing what was indexed for it before
+func (idx *searchIndex) put(node models.Node) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.putLocked(node)
+}
+
+func (idx *searchIndex) putLocked(node models.Node) {
+	if doc, ok := idx.docs[node.ID()]; ok {
+		if doc.title == node.Title() && doc.content == node.Content() {
+			doc.node = node
+			return
+		}
+		idx.removeLocked(node.ID())
+	}
+
+	doc := &searchDoc{node: node, title: node.Title(), content: node.Content()}
+	counts := make(map[string]posting)
+	for _, word := range SplitWords(doc.title) {
+		p := counts[word.Text]
+		p.title++
+		counts[word.Text] = p
+		doc.length += titleWeight
+	}
+	for _, word := range SplitWords(doc.content) {
+		p := counts[word.Text]
+		p.content++
+		counts[word.Text] = p
+		doc.length++
+	}
+
+	for word, p := range counts {
+		if idx.postings[word] == nil {
+			idx.postings[word] = make(map[string]posting)
+		}
+		idx.postings[word][node.ID()] = p
+	}
+	idx.docs[node.ID()] = doc
+	idx.totalLength += doc.length
+}
+
+// remove drops a node from the index
+func (idx *searchIndex) remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(id)
+}
+
+func (idx *searchIndex) removeLocked(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, word := range SplitWords(doc.title + "\n" + doc.content) {
+		if postings, ok := idx.postings[word.Text]; ok {
+			delete(postings, id)
+			if len(postings) == 0 {
+				delete(idx.postings, word.Text)
+			}
+		}
+	}
+	idx.totalLength -= doc.length
+	delete(idx.docs, id)
+}
+
+// search brings the index in line with the nodes in the store, then returns the nodes
+// that contain every word of the query, best first. The last word of the query also
+// matches words it is the start of, so that results can be shown while it is typed.
+func (idx *searchIndex) search(nodes []models.Node, query string) []SearchHit {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	current := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		current[node.ID()] = true
+		idx.putLocked(node)
+	}
+	for id := range idx.docs {
+		if !current[id] {
+			idx.removeLocked(id)
+		}
+	}
+
+	queryWords := SplitWords(query)
+	if len(queryWords) == 0 || len(idx.docs) == 0 {
+		return []SearchHit{}
+	}
+
+	scores := make(map[string]float64)
+	matched := make(map[string][]string)
+	for i, queryWord := range queryWords {
+		words := []string{queryWord.Text}
+		// The prefix only applies while the last word is still being typed
+		if i == len(queryWords)-1 && queryWord.End == len(strings.TrimRightFunc(query, unicode.IsSpace)) {
+			words = idx.wordsStartingWith(queryWord.Text)
+		}
+
+		// A node scores the best of the words the query word matches in it
+		best := make(map[string]float64)
+		bestWord := make(map[string]string)
+		for _, word := range words {
+			for id, score := range idx.scoreWord(word) {
+				if score > best[id] || bestWord[id] == "" {
+					best[id] = score
+					bestWord[id] = word
+				}
+			}
+		}
+
+		next := make(map[string]float64, len(best))
+		for id, score := range best {
+			if i > 0 {
+				previous, ok := scores[id]
+				if !ok {
+					continue
+				}
+				score += previous
+			}
+			next[id] = score
+			matched[id] = append(matched[id], bestWord[id])
+		}
+		scores = next
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, SearchHit{Node: idx.docs[id].node, Score: score, Words: matched[id]})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Node.Title() < hits[j].Node.Title()
+	})
+	return hits
+}
+
+// scoreWord returns the BM25 score of a word for every node that contains it
+func (idx *searchIndex) scoreWord(word string) map[string]float64 {
+	postings := idx.postings[word]
+	scores := make(map[string]float64, len(postings))
+	if len(postings) == 0 {
+		return scores
+	}
+
+	n := float64(len(idx.docs))
+	df := float64(len(postings))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	avgLength := math.Max(float64(idx.totalLength)/n, 1)
+	for id, p := range postings {
+		tf := float64(titleWeight*p.title + p.content)
+		norm := 1 - bm25B + bm25B*float64(idx.docs[id].length)/avgLength
+		scores[id] = idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+	}
+	return scores
+}
+
+// wordsStartingWith returns the indexed words that start with a prefix, the prefix
+// itself included
+func (idx *searchIndex) wordsStartingWith(prefix string) []string {
+	words := make([]string, 0)
+	for word := range idx.postings {
+		if strings.HasPrefix(word, prefix) {
+			words = append(words, word)
+		}
+	}
+	// Go through them in a fixed order, so that ties between them resolve the same way
+	sort.Slice(words, func(i, j int) bool {
+		if utf8.RuneCountInString(words[i]) != utf8.RuneCountInString(words[j]) {
+			return utf8.RuneCountInString(words[i]) < utf8.RuneCountInString(words[j])
+		}
+		return words[i] < words[j]
+	})
+	return words
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+func searchTitles(t *testing.T, store Storage, query string) []string {
+	t.Helper()
+	hits, err := store.Search(query)
+	if err != nil {
+		t.Fatalf("Search %q failed: %v", query, err)
+	}
+	titles := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		titles = append(titles, hit.Node.Title())
+	}
+	return titles
+}
+
+func TestSearchRanksMatches(t *testing.T) {
+	stores := map[string]Storage{"sqlite": setupSQLiteStore(t)}
+	fs, err := New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	stores["file"] = fs
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			for _, spec := range []*models.Spec{
+				models.NewSpecWithID("storage", "Storage", "Nodes live in markdown files, indexed by CSV files."),
+				models.NewSpecWithID("merge", "Merge driver", "Merges the CSV indexes of the storage directory row by row."),
+				models.NewSpecWithID("login", "Login", "Users sign in with a password."),
+			} {
+				if err := store.WriteNode(spec); err != nil {
+					t.Fatalf("Failed to write node: %v", err)
+				}
+			}
+
+			// A word in the title counts for more than the same word in the content
+			if got := searchTitles(t, store, "storage"); !slices.Equal(got, []string{"Storage", "Merge driver"}) {
+				t.Errorf("Expected the title match first, got %v", got)
+			}
+			if got := searchTitles(t, store, "csv password"); len(got) != 0 {
+				t.Errorf("Expected every word to have to match, got %v", got)
+			}
+			if got := searchTitles(t, store, "sign in"); !slices.Equal(got, []string{"Login"}) {
+				t.Errorf("Expected the login spec, got %v", got)
+			}
+			if got := searchTitles(t, store, "merg"); !slices.Equal(got, []string{"Merge driver"}) {
+				t.Errorf("Expected the last word to match as a prefix, got %v", got)
+			}
+			if got := searchTitles(t, store, "merg driver"); len(got) != 0 {
+				t.Errorf("Expected only the last word to match as a prefix, got %v", got)
+			}
+
+			// Writes and deletes show up straight away
+			login, err := store.ReadNode("login")
+			if err != nil {
+				t.Fatalf("Failed to read node: %v", err)
+			}
+			login.SetContent("Users sign in with a passkey.")
+			if err := store.WriteNode(login); err != nil {
+				t.Fatalf("Failed to write node: %v", err)
+			}
+			if got := searchTitles(t, store, "password"); len(got) != 0 {
+				t.Errorf("Expected the old content to be forgotten, got %v", got)
+			}
+			if err := store.DeleteNode("merge"); err != nil {
+				t.Fatalf("Failed to delete node: %v", err)
+			}
+			if got := searchTitles(t, store, "csv"); !slices.Equal(got, []string{"Storage"}) {
+				t.Errorf("Expected the deleted node to be gone, got %v", got)
+			}
+		})
+	}
+}
+
+func TestSearchSeesHandEdits(t *testing.T) {
+	fs, err := New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	if err := fs.WriteNode(models.NewSpecWithID("notes", "Notes", "Nothing yet")); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+	if got := searchTitles(t, fs, "nothing"); len(got) != 1 {
+		t.Fatalf("Expected the node to be found, got %v", got)
+	}
+
+	edited := "---\nid: notes\ntype: specification\n---\n\n# Notes\n\nRetention is thirty days\n"
+	if err := os.WriteFile(fs.GetNodeFilePath("notes"), []byte(edited), 0644); err != nil {
+		t.Fatalf("Failed to edit node file: %v", err)
+	}
+	if got := searchTitles(t, fs, "retention"); len(got) != 1 {
+		t.Errorf("Expected the hand edit to be searchable, got %v", got)
+	}
+	if got := searchTitles(t, fs, "nothing"); len(got) != 0 {
+		t.Errorf("Expected the old content to be forgotten, got %v", got)
+	}
+}
@@ -0,0 +1,510 @@
+package storage
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteFileName is the name of the database inside the storage directory
+const SQLiteFileName = "zamm.db"
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// querier is what a database connection and a transaction have in common
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// SQLiteStorage implements storage in a single SQLite database
+type SQLiteStorage struct {
+	db *sql.DB
+
+	// tx is the transaction a view of the store passed to Transaction's fn runs in. The
+	// database has a single connection, so everyone else waits for it to finish.
+	tx *sql.Tx
+
+	search *searchIndex
+}
+
+// NewSQLite opens the SQLite database at path, creating it if needed, and brings its
+// schema up to date
+func NewSQLite(path string) (*SQLiteStorage, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	// Transactions take the write lock up front, and writers from other processes wait
+	// for it rather than failing straight away
+	dsn := "file:" + path + "?_txlock=immediate&_pragma=busy_timeout(" + strconv.Itoa(int(DefaultLockTimeout/time.Millisecond)) + ")&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	s := &SQLiteStorage{db: db, search: newSearchIndex()}
+	if _, err := s.Migrate(); err != nil {
+		_ = db.Close() // Explicitly ignore error, the migration error is more useful
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// Migrate applies the migrations the database hasn't seen yet, in order, and returns how
+// many were applied
+func (s *SQLiteStorage) Migrate() (int, error) {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	applied := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return applied, fmt.Errorf("migration %s doesn't start with a version number", name)
+		}
+
+		var count int
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&count); err != nil {
+			return applied, fmt.Errorf("failed to check migration %s: %w", name, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		script, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return applied, err
+		}
+		err = s.transaction(func(s *SQLiteStorage) error {
+			if _, err := s.q().Exec(string(script)); err != nil {
+				return err
+			}
+			_, err := s.q().Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", version, name, time.Now().UTC().Format(time.RFC3339))
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply migration %s: %w", name, err)
+		}
+		applied++
+	}
+
+	return applied, nil
+}
+
+// MigrationVersion returns the version of the latest migration applied to the database
+func (s *SQLiteStorage) MigrationVersion() (int, error) {
+	var version sql.NullInt64
+	if err := s.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// q returns the view's transaction, or the database outside a transaction
+func (s *SQLiteStorage) q() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// Transaction runs fn in a database transaction, which is rolled back if fn returns an
+// error. Transactions started on tx join this one.
+func (s *SQLiteStorage) Transaction(fn func(tx Storage) error) error {
+	return s.transaction(func(tx *SQLiteStorage) error {
+		return fn(tx)
+	})
+}
+
+func (s *SQLiteStorage) transaction(fn func(s *SQLiteStorage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return busyError(err)
+	}
+
+	if err := fn(&SQLiteStorage{db: s.db, tx: tx, search: s.search}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rolling back also failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+	return busyError(tx.Commit())
+}
+
+// exec runs a statement that changes the store, unless the store was migrated by a newer
+// zamm
+func (s *SQLiteStorage) exec(query string, args ...any) (sql.Result, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	result, err := s.q().Exec(query, args...)
+	return result, busyError(err)
+}
+
+// checkWritable refuses writes to a database migrated by a newer zamm
+func (s *SQLiteStorage) checkWritable() error {
+	var version int
+	if err := s.q().QueryRow("SELECT schema_version FROM project_metadata WHERE id = 1").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return CheckSchemaVersion(version)
+}
+
+// busyError turns SQLite's report that another process holds the database for too long
+// into a conflict
+func busyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if strings.Contains(err.Error(), "SQLITE_BUSY") || strings.Contains(err.Error(), "database is locked") {
+		return models.NewZammErrorWithCause(models.ErrTypeConflict, "the database is being modified by another process, try again once it finishes", err)
+	}
+	return err
+}
+
+// Node operations
+
+func (s *SQLiteStorage) ReadNode(id string) (models.Node, error) {
+	var parsed parsedNode
+	err := s.q().QueryRow("SELECT type, data FROM nodes WHERE id = ?", id).Scan(&parsed.nodeType, &parsed.jsonData)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.NewZammError(models.ErrTypeNotFound, "node not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parsed.decode()
+}
+
+func (s *SQLiteStorage) WriteNode(node models.Node) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return fmt.Errorf("failed to marshal node: %w", err)
+	}
+
+	_, err = s.exec(`INSERT INTO nodes (id, type, data) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET type = excluded.type, data = excluded.data`, node.ID(), node.Type(), string(data))
+	if err != nil {
+		return err
+	}
+	s.search.put(node)
+	return nil
+}
+
+// WriteNodeWithChildren writes a node. Child links are only rendered into node files, so
+// the database has nothing to store for them.
+func (s *SQLiteStorage) WriteNodeWithChildren(node models.Node, childGrouping models.ChildGroup) error {
+	return s.WriteNode(node)
+}
+
+// DeleteNode deletes a node. Links to the node are left for the caller to remove.
+func (s *SQLiteStorage) DeleteNode(id string) error {
+	result, err := s.exec("DELETE FROM nodes WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result, "node not found"); err != nil {
+		return err
+	}
+	s.search.remove(id)
+	return nil
+}
+
+// Search returns the nodes whose titles and contents contain every word of the query,
+// ranked by relevance
+func (s *SQLiteStorage) Search(query string) ([]SearchHit, error) {
+	nodes, err := s.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	return s.search.search(nodes, query), nil
+}
+
+// ListNodes returns all nodes, sorted by ID
+func (s *SQLiteStorage) ListNodes() ([]models.Node, error) {
+	rows, err := s.q().Query("SELECT type, data FROM nodes ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close() // Explicitly ignore error in defer
+	}()
+
+	nodes := make([]models.Node, 0)
+	for rows.Next() {
+		var parsed parsedNode
+		if err := rows.Scan(&parsed.nodeType, &parsed.jsonData); err != nil {
+			return nil, err
+		}
+		node, err := parsed.decode()
+		if err != nil {
+			continue // Skip invalid nodes
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
+
+// SpecCommitLink operations
+
+func (s *SQLiteStorage) CreateSpecCommitLink(link *models.SpecCommitLink) error {
+	_, err := s.exec("INSERT INTO commit_links (spec_id, commit_id, repo_path, link_label) VALUES (?, ?, ?, ?)",
+		link.SpecID, link.CommitID, link.RepoPath, link.LinkLabel)
+	return err
+}
+
+func (s *SQLiteStorage) GetSpecCommitLinks(specID string) ([]*models.SpecCommitLink, error) {
+	return s.queryCommitLinks("WHERE spec_id = ?", specID)
+}
+
+func (s *SQLiteStorage) DeleteSpecCommitLink(specID string) error {
+	result, err := s.exec("DELETE FROM commit_links WHERE spec_id = ?", specID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "spec-commit link not found")
+}
+
+func (s *SQLiteStorage) DeleteSpecCommitLinkByFields(specID, commitID, repoPath string) error {
+	result, err := s.exec("DELETE FROM commit_links WHERE spec_id = ? AND commit_id = ? AND repo_path = ?", specID, commitID, repoPath)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "spec-commit link not found")
+}
+
+func (s *SQLiteStorage) GetLinksByCommit(commitID, repoPath string) ([]*models.SpecCommitLink, error) {
+	return s.queryCommitLinks("WHERE commit_id = ? AND repo_path = ?", commitID, repoPath)
+}
+
+func (s *SQLiteStorage) GetLinksBySpec(specID string) ([]*models.SpecCommitLink, error) {
+	return s.GetSpecCommitLinks(specID)
+}
+
+func (s *SQLiteStorage) ListSpecCommitLinks() ([]*models.SpecCommitLink, error) {
+	return s.queryCommitLinks("")
+}
+
+func (s *SQLiteStorage) DeleteLink(specID string) error {
+	return s.DeleteSpecCommitLink(specID)
+}
+
+func (s *SQLiteStorage) queryCommitLinks(where string, args ...any) ([]*models.SpecCommitLink, error) {
+	rows, err := s.q().Query("SELECT spec_id, commit_id, repo_path, link_label FROM commit_links "+where+" ORDER BY rowid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close() // Explicitly ignore error in defer
+	}()
+
+	links := make([]*models.SpecCommitLink, 0)
+	for rows.Next() {
+		link := &models.SpecCommitLink{}
+		if err := rows.Scan(&link.SpecID, &link.CommitID, &link.RepoPath, &link.LinkLabel); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// SpecSpecLink operations
+
+func (s *SQLiteStorage) CreateSpecSpecLink(link *models.SpecSpecLink) error {
+	_, err := s.exec("INSERT INTO spec_links (from_spec_id, to_spec_id, link_label) VALUES (?, ?, ?)",
+		link.FromSpecID, link.ToSpecID, link.LinkLabel)
+	return err
+}
+
+func (s *SQLiteStorage) GetSpecSpecLinks(specID string, direction models.Direction) ([]*models.SpecSpecLink, error) {
+	switch direction {
+	case models.Outgoing:
+		return s.querySpecLinks("WHERE from_spec_id = ?", specID)
+	case models.Incoming:
+		return s.querySpecLinks("WHERE to_spec_id = ?", specID)
+	default:
+		return make([]*models.SpecSpecLink, 0), nil
+	}
+}
+
+func (s *SQLiteStorage) ListSpecSpecLinks() ([]*models.SpecSpecLink, error) {
+	return s.querySpecLinks("")
+}
+
+func (s *SQLiteStorage) DeleteSpecSpecLink(fromSpecID, toSpecID string) error {
+	result, err := s.exec("DELETE FROM spec_links WHERE from_spec_id = ? AND to_spec_id = ?", fromSpecID, toSpecID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, "spec-spec link not found")
+}
+
+func (s *SQLiteStorage) DeleteSpecLinkBySpecs(fromSpecID, toSpecID string) error {
+	return s.DeleteSpecSpecLink(fromSpecID, toSpecID)
+}
+
+func (s *SQLiteStorage) querySpecLinks(where string, args ...any) ([]*models.SpecSpecLink, error) {
+	rows, err := s.q().Query("SELECT from_spec_id, to_spec_id, link_label FROM spec_links "+where+" ORDER BY rowid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close() // Explicitly ignore error in defer
+	}()
+
+	links := make([]*models.SpecSpecLink, 0)
+	for rows.Next() {
+		link := &models.SpecSpecLink{}
+		if err := rows.Scan(&link.FromSpecID, &link.ToSpecID, &link.LinkLabel); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// Hierarchical operations
+
+func (s *SQLiteStorage) GetLinkedSpecs(specID string, direction models.Direction) ([]*models.Spec, error) {
+	nodes, err := s.GetLinkedNodes(specID, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]*models.Spec, 0, len(nodes))
+	for _, node := range nodes {
+		if spec, ok := node.(*models.Spec); ok {
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+func (s *SQLiteStorage) GetLinkedNodes(nodeID string, direction models.Direction) ([]models.Node, error) {
+	links, err := s.GetSpecSpecLinks(nodeID, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]models.Node, 0, len(links))
+	for _, link := range links {
+		targetNodeID := link.FromSpecID
+		if direction == models.Outgoing {
+			targetNodeID = link.ToSpecID
+		}
+
+		node, err := s.ReadNode(targetNodeID)
+		if err != nil {
+			continue // Skip if node not found
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// GetOrphanSpecs retrieves all specs that don't have any parent links
+func (s *SQLiteStorage) GetOrphanSpecs() ([]*models.Spec, error) {
+	rows, err := s.q().Query(`SELECT type, data FROM nodes
+		WHERE NOT EXISTS (SELECT 1 FROM spec_links WHERE from_spec_id = nodes.id)
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close() // Explicitly ignore error in defer
+	}()
+
+	orphans := make([]*models.Spec, 0)
+	for rows.Next() {
+		var parsed parsedNode
+		if err := rows.Scan(&parsed.nodeType, &parsed.jsonData); err != nil {
+			return nil, err
+		}
+		node, err := parsed.decode()
+		if err != nil {
+			continue // Skip invalid nodes
+		}
+		if spec, ok := node.(*models.Spec); ok {
+			orphans = append(orphans, spec)
+		}
+	}
+	return orphans, rows.Err()
+}
+
+// ProjectMetadata operations
+
+func (s *SQLiteStorage) GetProjectMetadata() (*models.ProjectMetadata, error) {
+	var rootSpecID sql.NullString
+	var schemaVersion int
+	if err := s.q().QueryRow("SELECT root_spec_id, schema_version FROM project_metadata WHERE id = 1").Scan(&rootSpecID, &schemaVersion); err != nil {
+		return nil, err
+	}
+
+	metadata := &models.ProjectMetadata{SchemaVersion: schemaVersion}
+	if rootSpecID.Valid {
+		metadata.RootSpecID = &rootSpecID.String
+	}
+	return metadata, nil
+}
+
+func (s *SQLiteStorage) SetRootSpecID(specID *string) error {
+	_, err := s.exec("UPDATE project_metadata SET root_spec_id = ? WHERE id = 1", specID)
+	return err
+}
+
+// SetSchemaVersion records the version of the store format
+func (s *SQLiteStorage) SetSchemaVersion(version int) error {
+	_, err := s.exec("UPDATE project_metadata SET schema_version = ? WHERE id = 1", version)
+	return err
+}
+
+func requireAffected(result sql.Result, notFoundMessage string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewZammError(models.ErrTypeNotFound, notFoundMessage)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+func setupSQLiteStore(t *testing.T) *SQLiteStorage {
+	t.Helper()
+
+	s, err := NewSQLite(filepath.Join(t.TempDir(), SQLiteFileName))
+	if err != nil {
+		t.Fatalf("failed to create SQLite storage: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = s.Close() // Explicitly ignore error in cleanup
+	})
+	return s
+}
+
+func TestSQLiteMigrateIsIdempotent(t *testing.T) {
+	s := setupSQLiteStore(t)
+
+	applied, err := s.Migrate()
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if applied != 0 {
+		t.Errorf("Expected an up to date database to apply no migrations, applied %d", applied)
+	}
+	if version, err := s.MigrationVersion(); err != nil || version != 2 {
+		t.Errorf("Expected migration version 2, got %d, %v", version, err)
+	}
+}
+
+func TestSQLiteNodesAndLinks(t *testing.T) {
+	s := setupSQLiteStore(t)
+
+	for _, node := range []models.Node{
+		models.NewSpecWithID("parent", "Parent", "Parent content"),
+		models.NewSpecWithID("child", "Child", "Child content"),
+	} {
+		if err := s.WriteNode(node); err != nil {
+			t.Fatalf("Failed to write node: %v", err)
+		}
+	}
+	if err := s.WriteNode(models.NewSpecWithID("child", "Renamed", "Child content")); err != nil {
+		t.Fatalf("Failed to overwrite node: %v", err)
+	}
+	if node, err := s.ReadNode("child"); err != nil || node.Title() != "Renamed" {
+		t.Errorf("Expected the overwritten title, got %v, %v", node, err)
+	}
+
+	if err := s.CreateSpecSpecLink(&models.SpecSpecLink{FromSpecID: "child", ToSpecID: "parent", LinkLabel: "child"}); err != nil {
+		t.Fatalf("Failed to create spec link: %v", err)
+	}
+	if children, err := s.GetLinkedNodes("parent", models.Incoming); err != nil || len(children) != 1 || children[0].ID() != "child" {
+		t.Errorf("Expected parent to have one child, got %v, %v", children, err)
+	}
+	if orphans, err := s.GetOrphanSpecs(); err != nil || len(orphans) != 1 || orphans[0].ID() != "parent" {
+		t.Errorf("Expected only the parent to be an orphan, got %v, %v", orphans, err)
+	}
+
+	if err := s.CreateSpecCommitLink(&models.SpecCommitLink{SpecID: "child", CommitID: "abc123", RepoPath: ".", LinkLabel: "implements"}); err != nil {
+		t.Fatalf("Failed to create commit link: %v", err)
+	}
+	if links, err := s.GetLinksByCommit("abc123", "."); err != nil || len(links) != 1 || links[0].SpecID != "child" {
+		t.Errorf("Expected one link for the commit, got %v, %v", links, err)
+	}
+
+	rootID := "parent"
+	if err := s.SetRootSpecID(&rootID); err != nil {
+		t.Fatalf("Failed to set root spec: %v", err)
+	}
+	if metadata, err := s.GetProjectMetadata(); err != nil || metadata.RootSpecID == nil || *metadata.RootSpecID != "parent" {
+		t.Errorf("Expected the root spec to be set, got %v, %v", metadata, err)
+	}
+
+	var zammErr *models.ZammError
+	if err := s.DeleteSpecSpecLink("parent", "child"); !errors.As(err, &zammErr) || zammErr.Type != models.ErrTypeNotFound {
+		t.Errorf("Expected deleting a missing link to be not found, got %v", err)
+	}
+	if err := s.DeleteNode("missing"); !errors.As(err, &zammErr) || zammErr.Type != models.ErrTypeNotFound {
+		t.Errorf("Expected deleting a missing node to be not found, got %v", err)
+	}
+}
+
+func TestSQLiteTransactionRollsBack(t *testing.T) {
+	s := setupSQLiteStore(t)
+
+	err := s.Transaction(func(tx Storage) error {
+		if err := tx.WriteNode(models.NewSpecWithID("spec", "Spec", "Content")); err != nil {
+			return err
+		}
+		return errors.New("fail partway")
+	})
+	if err == nil {
+		t.Fatal("Expected the transaction to fail")
+	}
+	if _, err := s.ReadNode("spec"); err == nil {
+		t.Error("Expected the write to have been rolled back")
+	}
+}
+
+func TestSQLiteTransactionIsNotJoinedByOtherGoroutines(t *testing.T) {
+	s := setupSQLiteStore(t)
+
+	written := make(chan error)
+	failure := errors.New("step failed")
+	err := s.Transaction(func(tx Storage) error {
+		if err := tx.WriteNode(models.NewSpecWithID("inside", "Inside", "Content")); err != nil {
+			return err
+		}
+		go func() {
+			written <- s.WriteNode(models.NewSpecWithID("outside", "Outside", "Content"))
+		}()
+		select {
+		case err := <-written:
+			t.Errorf("Expected the write to wait for the transaction, it returned %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the transaction's error, got %v", err)
+	}
+
+	if err := <-written; err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+	if _, err := s.ReadNode("inside"); err == nil {
+		t.Error("Expected the transaction's write to have been rolled back")
+	}
+	if _, err := s.ReadNode("outside"); err != nil {
+		t.Errorf("Expected the other goroutine's write to survive the rollback: %v", err)
+	}
+}
+
+func TestCopyRoundTrip(t *testing.T) {
+	fileStore := setupIndexedStore(t)
+	if err := fileStore.CreateSpecCommitLink(&models.SpecCommitLink{SpecID: "child", CommitID: "abc123", RepoPath: ".", LinkLabel: "implements"}); err != nil {
+		t.Fatalf("Failed to create commit link: %v", err)
+	}
+	rootID := "parent"
+	if err := fileStore.SetRootSpecID(&rootID); err != nil {
+		t.Fatalf("Failed to set root spec: %v", err)
+	}
+
+	sqliteStore := setupSQLiteStore(t)
+	if err := Copy(sqliteStore, fileStore); err != nil {
+		t.Fatalf("Failed to copy into SQLite: %v", err)
+	}
+	if err := Copy(sqliteStore, fileStore); err == nil {
+		t.Error("Expected copying into a store that isn't empty to fail")
+	}
+
+	roundTrip, err := New(filepath.Join(t.TempDir(), ".zamm"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	if err := Copy(roundTrip, sqliteStore); err != nil {
+		t.Fatalf("Failed to copy back into file storage: %v", err)
+	}
+
+	if nodes, err := roundTrip.ListNodes(); err != nil || len(nodes) != 2 {
+		t.Errorf("Expected both nodes, got %v, %v", nodes, err)
+	}
+	if node, err := roundTrip.ReadNode("child"); err != nil || node.Content() != "Child content" {
+		t.Errorf("Expected the child's content, got %v, %v", node, err)
+	}
+	if links, err := roundTrip.ListSpecSpecLinks(); err != nil || len(links) != 1 || links[0].ToSpecID != "parent" {
+		t.Errorf("Expected the spec link, got %v, %v", links, err)
+	}
+	if links, err := roundTrip.ListSpecCommitLinks(); err != nil || len(links) != 1 || links[0].CommitID != "abc123" {
+		t.Errorf("Expected the commit link, got %v, %v", links, err)
+	}
+	if metadata, err := roundTrip.GetProjectMetadata(); err != nil || metadata.RootSpecID == nil || *metadata.RootSpecID != "parent" {
+		t.Errorf("Expected the root spec, got %v, %v", metadata, err)
+	}
+}
+
+func TestSQLiteKeepsMetadataOrder(t *testing.T) {
+	s := setupSQLiteStore(t)
+
+	node := models.NewSpecWithID("spec", "Spec", "Content")
+	node.SetMetadata(models.Metadata{
+		{Key: "zeta", Value: int64(1)},
+		{Key: "alpha", Value: models.Metadata{{Key: "y", Value: "b"}, {Key: "x", Value: "a"}}},
+	})
+	if err := s.WriteNode(node); err != nil {
+		t.Fatalf("Failed to write node: %v", err)
+	}
+
+	read, err := s.ReadNode("spec")
+	if err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	if !reflect.DeepEqual(read.Metadata(), node.Metadata()) {
+		t.Errorf("Expected metadata %v, got %v", node.Metadata(), read.Metadata())
+	}
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ChangeKind describes what a Change reports
+type ChangeKind int
+
+const (
+	// NodeFileChanged means a node's markdown file was written, created, renamed or removed
+	NodeFileChanged ChangeKind = iota
+	// MetadataChanged means one of the link or metadata files under the storage directory
+	// changed, so the hierarchy or the location of node files may be different
+	MetadataChanged
+)
+
+// Change is a single change to the files backing a FileStorage
+type Change struct {
+	Kind   ChangeKind
+	NodeID string // set for NodeFileChanged
+	Path   string
+}
+
+// Watcher reports changes made to node files and metadata on disk, whether by zamm
+// itself, another process or a text editor. Node files can live anywhere that
+// node-files.csv points to, so the watcher follows the directories containing them
+// and updates that set whenever node-files.csv changes.
+type Watcher struct {
+	storage *FileStorage
+	watcher *fsnotify.Watcher
+
+	mu        sync.Mutex
+	nodePaths map[string]string // absolute file path -> node ID
+	dirs      map[string]bool
+
+	changes chan Change
+	errors  chan error
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewWatcher starts watching the files of the given storage
+func NewWatcher(fs *FileStorage) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	w := &Watcher{
+		storage: fs,
+		watcher: fsWatcher,
+		dirs:    make(map[string]bool),
+		changes: make(chan Change, 64),
+		errors:  make(chan error, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if err := w.refresh(); err != nil {
+		_ = fsWatcher.Close()
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Changes returns the channel that changes are delivered on. It is closed by Close.
+func (w *Watcher) Changes() <-chan Change {
+	return w.changes
+}
+
+// Errors returns the channel that watch errors are delivered on
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops watching and closes the changes channel
+func (w *Watcher) Close() error {
+	close(w.stop)
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
+
+// refresh re-reads node-files.csv and makes sure every directory holding a node file is watched
+func (w *Watcher) refresh() error {
+	nodeFiles, err := w.storage.GetAllNodeFileLinks()
+	if err != nil {
+		return fmt.Errorf("failed to read node file links: %w", err)
+	}
+
+	nodePaths := make(map[string]string, len(nodeFiles))
+	dirs := []string{w.storage.baseDir, w.storage.nodesDir()}
+	for nodeID := range nodeFiles {
+		path, err := filepath.Abs(w.storage.GetNodeFilePath(nodeID))
+		if err != nil {
+			return fmt.Errorf("failed to resolve file path for node %s: %w", nodeID, err)
+		}
+		nodePaths[path] = nodeID
+		dirs = append(dirs, filepath.Dir(path))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.nodePaths = nodePaths
+	for _, dir := range dirs {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve directory %s: %w", dir, err)
+		}
+		if w.dirs[absDir] {
+			continue
+		}
+		if _, err := os.Stat(absDir); os.IsNotExist(err) {
+			// Stale node-files.csv entries should not prevent watching everything else
+			continue
+		}
+		if err := w.watcher.Add(absDir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", absDir, err)
+		}
+		w.dirs[absDir] = true
+	}
+
+	return nil
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	defer close(w.changes)
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if change, ok := w.classify(event.Name); ok {
+				select {
+				case w.changes <- change:
+				case <-w.stop:
+					return
+				}
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.reportError(err)
+		}
+	}
+}
+
+// reportError delivers an error without blocking, dropping it if nobody is reading errors
+func (w *Watcher) reportError(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+// classify maps a changed path to the node or metadata file it belongs to
+func (w *Watcher) classify(path string) (Change, bool) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Change{}, false
+	}
+
+	baseDir, _ := filepath.Abs(w.storage.baseDir)
+	if filepath.Dir(absPath) == baseDir {
+		switch filepath.Base(absPath) {
+		case "node-files.csv":
+			if err := w.refresh(); err != nil {
+				w.reportError(err)
+			}
+			return Change{Kind: MetadataChanged, Path: absPath}, true
+		case "spec-links.csv", "commit-links.csv", "project_metadata.json":
+			return Change{Kind: MetadataChanged, Path: absPath}, true
+		}
+		return Change{}, false
+	}
+
+	w.mu.Lock()
+	nodeID, ok := w.nodePaths[absPath]
+	w.mu.Unlock()
+	if ok {
+		return Change{Kind: NodeFileChanged, NodeID: nodeID, Path: absPath}, true
+	}
+
+	// Nodes without an entry in node-files.csv live at their default location
+	nodesDir, _ := filepath.Abs(w.storage.nodesDir())
+	if filepath.Dir(absPath) == nodesDir && strings.HasSuffix(absPath, ".md") {
+		return Change{Kind: NodeFileChanged, NodeID: strings.TrimSuffix(filepath.Base(absPath), ".md"), Path: absPath}, true
+	}
+
+	return Change{}, false
+}
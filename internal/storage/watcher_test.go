@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/zamm-dev/zamm-golang-mvp-11/internal/models"
+)
+
+func TestWatcherReportsNodeFileChanges(t *testing.T) {
+	fs, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	node := models.NewSpecWithID("watched-spec", "Watched Spec", "Original content")
+	if err := fs.WriteNode(node); err != nil {
+		t.Fatalf("failed to write node: %v", err)
+	}
+
+	watcher, err := NewWatcher(fs)
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	// Edit the file directly, as a text editor would
+	path := fs.GetNodeFilePath(node.ID())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read node file: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, []byte("\nMore content\n")...), 0644); err != nil {
+		t.Fatalf("failed to edit node file: %v", err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case change := <-watcher.Changes():
+			if change.Kind == NodeFileChanged && change.NodeID == node.ID() {
+				return
+			}
+		case err := <-watcher.Errors():
+			t.Fatalf("watcher error: %v", err)
+		case <-timeout:
+			t.Fatal("timed out waiting for node file change")
+		}
+	}
+}